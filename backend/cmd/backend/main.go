@@ -2,19 +2,19 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
-	"time"
 
-	"log/slog"
-
-	"github.com/go-chi/chi/v5"
+	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"mcp-backend/internal/config"
+	"mcp-backend/internal/server"
 )
 
 var (
@@ -26,18 +26,29 @@ var (
 
 	serverCmd = &cobra.Command{
 		Use:   "server",
-		Short: "Start HTTP server",
+		Short: "Start the backend API server",
 		RunE:  runServer,
 	}
 )
 
 func init() {
-	// Flags
-	serverCmd.Flags().Int("port", 6161, "HTTP port")
-	_ = viper.BindPFlag("port", serverCmd.Flags().Lookup("port"))
+	// .env is loaded first so it feeds the flag defaults below
+	_ = godotenv.Load()
+
+	// Flags; their defaults come from config.Load, which reads PORT, READ_TIMEOUT, etc.
+	// Its errors are reported when the server command runs, not here, so --help works
+	defaults, _ := config.Load()
+	serverCmd.Flags().Int("port", defaults.Port, "HTTP port")
+	serverCmd.Flags().Duration("read-timeout", defaults.ReadTimeout, "maximum time to read a request")
+	serverCmd.Flags().Duration("write-timeout", defaults.WriteTimeout, "maximum time to write a response")
+	serverCmd.Flags().Duration("idle-timeout", defaults.IdleTimeout, "how long keep-alive connections stay open")
+	for _, name := range []string{"port", "read-timeout", "write-timeout", "idle-timeout"} {
+		_ = viper.BindPFlag(name, serverCmd.Flags().Lookup(name))
+	}
 
-	// Config precedence: flags > env > file
+	// Config precedence: flags > BACKEND_* env > config env > defaults
 	viper.SetEnvPrefix("BACKEND")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
 
 	rootCmd.AddCommand(serverCmd)
@@ -51,74 +62,19 @@ func main() {
 }
 
 func runServer(cmd *cobra.Command, args []string) error {
-	// Logger (JSON)
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
-	slog.SetDefault(logger)
-
-	port := viper.GetInt("port")
-	addr := fmt.Sprintf(":%d", port)
+	log := logrus.New()
+	log.SetLevel(logrus.InfoLevel)
 
-	// Router
-	r := chi.NewRouter()
-	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ok"))
-	})
-
-	server := &http.Server{
-		Addr:              addr,
-		Handler:           requestLoggerMiddleware(logger, r),
-		ReadTimeout:       15 * time.Second,
-		ReadHeaderTimeout: 15 * time.Second,
-		WriteTimeout:      30 * time.Second,
-		IdleTimeout:       60 * time.Second,
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
 	}
-
-	// Start
-	go func() {
-		logger.Info("server starting", slog.String("addr", addr))
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			logger.Error("server error", slog.String("error", err.Error()))
-		}
-	}()
-
-	// Graceful shutdown
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
-	<-stop
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	logger.Info("server shutting down")
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Error("graceful shutdown failed", slog.String("error", err.Error()))
-		return err
-	}
-	logger.Info("server stopped")
-	return nil
-}
-
-func requestLoggerMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		rlw := &responseLogger{ResponseWriter: w, statusCode: 200}
-		next.ServeHTTP(rlw, r)
-		dur := time.Since(start)
-		logger.Info("http_request",
-			slog.String("method", r.Method),
-			slog.String("path", r.URL.Path),
-			slog.Int("status", rlw.statusCode),
-			slog.Duration("duration_ms", dur),
-		)
-	})
-}
-
-type responseLogger struct {
-	http.ResponseWriter
-	statusCode int
-}
-
-func (lrw *responseLogger) WriteHeader(code int) {
-	lrw.statusCode = code
-	lrw.ResponseWriter.WriteHeader(code)
+	cfg.Port = viper.GetInt("port")
+	cfg.ReadTimeout = viper.GetDuration("read-timeout")
+	cfg.WriteTimeout = viper.GetDuration("write-timeout")
+	cfg.IdleTimeout = viper.GetDuration("idle-timeout")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return server.Run(ctx, cfg, log)
 }
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+
+	"mcp-backend/internal/auth"
+)
+
+// Action names an operation on server definitions that is subject to role checks
+type Action string
+
+const (
+	ActionRead      Action = "read"
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionDeploy    Action = "deploy"
+	ActionUpgrade   Action = "upgrade"
+	ActionUninstall Action = "uninstall"
+	ActionDelete    Action = "delete"
+)
+
+// actionRoles is the authorization policy: the membership roles allowed to perform each
+// action. Actions missing from the map are denied to everyone.
+var actionRoles = map[Action][]string{
+	ActionRead:      {"owner", "admin", "member", "guest"},
+	ActionCreate:    {"owner", "admin", "member"},
+	ActionUpdate:    {"owner", "admin", "member"},
+	ActionDeploy:    {"owner", "admin"},
+	ActionUpgrade:   {"owner", "admin"},
+	ActionUninstall: {"owner", "admin"},
+	ActionDelete:    {"owner", "admin"},
+}
+
+// Allowed reports whether role may perform action
+func Allowed(role string, action Action) bool {
+	for _, r := range actionRoles[action] {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole rejects requests whose JWT role is not allowed to perform action. It must
+// run after AuthMiddleware.
+func RequireRole(action Action) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.ClaimsFromContext(r.Context())
+			if !ok {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if !Allowed(claims.Role, action) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -4,13 +4,13 @@ import (
 	"net/http"
 	"strings"
 
-	jwt "github.com/golang-jwt/jwt/v5"
+	appauth "mcp-backend/internal/auth"
 )
 
 func AuthMiddleware(secret string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if strings.HasPrefix(r.URL.Path, "/health") || strings.HasPrefix(r.URL.Path, "/auth/") {
+			if strings.HasPrefix(r.URL.Path, "/health") || strings.HasPrefix(r.URL.Path, "/auth/") || r.URL.Path == "/metrics" || r.URL.Path == "/readyz" {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -19,13 +19,13 @@ func AuthMiddleware(secret string) func(http.Handler) http.Handler {
 				http.Error(w, "missing bearer token", http.StatusUnauthorized)
 				return
 			}
-			tokenStr := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer"))
-			_, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) { return []byte(secret), nil })
+			tokenStr := strings.TrimSpace(auth[len("bearer "):])
+			claims, err := appauth.ParseJWT(secret, tokenStr)
 			if err != nil {
 				http.Error(w, "invalid token", http.StatusUnauthorized)
 				return
 			}
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r.WithContext(appauth.WithClaims(r.Context(), claims)))
 		})
 	}
 }
@@ -1,57 +1,209 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"mcp-backend/internal/auth"
 	"mcp-backend/internal/helm"
+	"mcp-backend/internal/metrics"
+	"mcp-backend/internal/serverconfig"
 	"mcp-backend/internal/storage"
 )
 
 type ServerCreateRequest struct {
-	OwnerID    string                 `json:"owner_id"`
 	Name       string                 `json:"name"`
 	ConfigJSON map[string]interface{} `json:"config_json"`
 }
 
-// TODO: add middleware for JWT verification and tenant/workspace claims
+// ServerUpdateRequest is a partial update. ConfigJSON is merged into the stored config
+// as a JSON merge patch: nested objects merge and null removes a key.
+type ServerUpdateRequest struct {
+	Name       *string                `json:"name"`
+	ConfigJSON map[string]interface{} `json:"config_json"`
+}
+
+// maxPendingLogins caps the OAuth logins started but not yet called back
+const maxPendingLogins = 10000
+
+// maxLogTail is the largest ?tail accepted by the logs endpoint
+const maxLogTail = 5000
+
+// sessionTTL is how long app JWTs issued at login stay valid
+const sessionTTL = 24 * time.Hour
+
+// maxRequestBytes caps JSON request bodies so an oversized upload can't exhaust memory
+const maxRequestBytes = 4 << 20
 
-func AttachRoutes(r *chi.Mux, log *logrus.Logger, db *storage.MongoStore, helmSvc *helm.Service) {
+func AttachRoutes(r *chi.Mux, log *logrus.Logger, db *storage.MongoStore, helmSvc *helm.Service, jwtSecret string) {
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK); w.Write([]byte("ok")) })
+	r.Handle("/metrics", metrics.Handler())
+
+	// Readiness, unlike /health, checks the dependencies deploys need
+	r.Get("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		checks := map[string]string{"mongo": "ok", "helm": "ok"}
+		ready := true
+		if db == nil {
+			checks["mongo"], ready = "not connected", false
+		} else {
+			ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+			defer cancel()
+			if err := db.Ping(ctx); err != nil {
+				checks["mongo"], ready = err.Error(), false
+			}
+		}
+		if err := helmSvc.Check(); err != nil {
+			checks["helm"], ready = err.Error(), false
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		status := "ready"
+		if !ready {
+			status = "not ready"
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "checks": checks})
+	})
 
-	// Google OAuth (dev-simple version)
-	r.Get("/auth/google/login", func(w http.ResponseWriter, r *http.Request) { auth.BeginGoogleLogin(w, r) })
+	// Google OAuth
+	states := auth.NewLoginStateStore(10*time.Minute, maxPendingLogins)
+	r.Get("/auth/google/login", func(w http.ResponseWriter, r *http.Request) { auth.BeginGoogleLogin(w, r, states) })
 	r.Get("/auth/google/callback", func(w http.ResponseWriter, r *http.Request) {
-		_, err := auth.HandleGoogleCallback(r)
+		tok, err := auth.HandleGoogleCallback(w, r, states)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		// TODO: fetch userinfo, create/update user, issue our JWT
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("google auth ok (complete user linking in next step)"))
+		info, err := auth.FetchGoogleUserInfo(r.Context(), tok)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		user, err := db.UpsertUserByEmail(r.Context(), info.Email, info.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		ws, mem, err := db.EnsureDefaultWorkspace(r.Context(), user)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		token, err := auth.IssueJWT(jwtSecret, user.ID, ws.TenantID, ws.ID, mem.Role, sessionTTL)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.WithFields(logrus.Fields{"user_id": user.ID, "workspace_id": ws.ID}).Info("google login")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":        token,
+			"expires_in":   int(sessionTTL.Seconds()),
+			"user":         user,
+			"workspace_id": ws.ID,
+			"role":         mem.Role,
+		})
 	})
 
+	// Live releases for the caller's workspace, matched to their server definitions.
+	// Releases in the tenant's namespace that no server claims are listed as orphaned so
+	// they can be cleaned up. Release names don't record a workspace, so unclaimed
+	// releases in the shared legacy namespace are not shown.
+	r.With(RequireRole(ActionRead)).Get("/releases", func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := callerClaims(w, r)
+		if !ok {
+			return
+		}
+		tenantNS := helm.TenantNamespace(claims.TenantID)
+		// namespace -> release name -> server id
+		servers := map[string]map[string]string{tenantNS: {}}
+		if !collectReleases(w, r, db, helmSvc, map[string]interface{}{"workspace_id": claims.WorkspaceID}, servers) {
+			return
+		}
+		// Releases of the tenant's other workspaces aren't orphaned, just not ours
+		claimed := map[string]map[string]string{tenantNS: {}}
+		if !collectReleases(w, r, db, helmSvc, map[string]interface{}{"namespace": tenantNS}, claimed) {
+			return
+		}
+		type releaseView struct {
+			helm.ReleaseInfo
+			ServerID string `json:"server_id"`
+			Orphaned bool   `json:"orphaned"`
+		}
+		out := []releaseView{}
+		for ns, names := range servers {
+			releases, err := helmSvc.ListReleases(ns)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			for _, rel := range releases {
+				if id, ok := names[rel.Name]; ok {
+					out = append(out, releaseView{ReleaseInfo: rel, ServerID: id})
+				} else if _, ok := claimed[ns][rel.Name]; ns == tenantNS && !ok {
+					out = append(out, releaseView{ReleaseInfo: rel, Orphaned: true})
+				}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	})
+
+	// audit records a lifecycle action. It is best-effort: a failed write is logged but
+	// never fails the request.
+	audit := func(r *http.Request, serverID, action string, actionErr error) {
+		claims, _ := auth.ClaimsFromContext(r.Context())
+		entry := storage.AuditLog{WorkspaceID: claims.WorkspaceID, ServerID: serverID, Actor: claims.Sub, Action: action, Outcome: "success"}
+		if actionErr != nil {
+			entry.Outcome = "error"
+			entry.Error = actionErr.Error()
+		}
+		if err := db.AppendAudit(context.WithoutCancel(r.Context()), entry); err != nil {
+			log.WithError(err).WithFields(logrus.Fields{"server_id": serverID, "action": action}).Error("failed to write audit log")
+		}
+	}
+
 	r.Route("/servers", func(sr chi.Router) {
-		sr.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		sr.With(RequireRole(ActionCreate)).Post("/", func(w http.ResponseWriter, r *http.Request) {
 			var req ServerCreateRequest
-			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			if !validServerName(w, req.Name) {
+				return
+			}
+			if !validConfig(w, req.ConfigJSON) {
 				return
 			}
-			if req.Name == "" {
-				http.Error(w, "name required", http.StatusBadRequest)
+			claims, ok := callerClaims(w, r)
+			if !ok {
 				return
 			}
 			id := uuid.NewString()
-			s := storage.ServerDef{ID: id, OwnerID: req.OwnerID, Name: req.Name, ConfigJSON: req.ConfigJSON, CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}
+			s := storage.ServerDef{ID: id, OwnerID: claims.Sub, WorkspaceID: claims.WorkspaceID, Namespace: helm.TenantNamespace(claims.TenantID), Name: req.Name, Release: helm.ReleaseName(req.Name), ConfigJSON: req.ConfigJSON, CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}
+			// The name has to be free in the workspace, and its release in the namespace,
+			// which other workspaces of the tenant share
+			taken := map[string]interface{}{"$or": []interface{}{
+				map[string]interface{}{"workspace_id": s.WorkspaceID, "name": s.Name},
+				map[string]interface{}{"namespace": s.Namespace, "release": s.Release},
+			}}
+			if !serverNameFree(w, r, db, taken) {
+				return
+			}
 			res, err := db.Servers().InsertOne(r.Context(), s)
+			if mongo.IsDuplicateKeyError(err) {
+				http.Error(w, "a server with this name already exists", http.StatusConflict)
+				return
+			}
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -59,8 +211,12 @@ func AttachRoutes(r *chi.Mux, log *logrus.Logger, db *storage.MongoStore, helmSv
 			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": res.InsertedID})
 		})
 
-		sr.Get("/", func(w http.ResponseWriter, r *http.Request) {
-			cur, err := db.Servers().Find(r.Context(), map[string]interface{}{})
+		sr.With(RequireRole(ActionRead)).Get("/", func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := callerClaims(w, r)
+			if !ok {
+				return
+			}
+			cur, err := db.Servers().Find(r.Context(), map[string]interface{}{"workspace_id": claims.WorkspaceID})
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
@@ -75,76 +231,402 @@ func AttachRoutes(r *chi.Mux, log *logrus.Logger, db *storage.MongoStore, helmSv
 			_ = json.NewEncoder(w).Encode(out)
 		})
 
-		sr.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
-			id := chi.URLParam(r, "id")
-			var s storage.ServerDef
-			if err := db.Servers().FindOne(r.Context(), map[string]interface{}{"_id": id}).Decode(&s); err != nil {
-				http.Error(w, "not found", http.StatusNotFound)
+		sr.With(RequireRole(ActionRead)).Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			s, ok := workspaceServer(w, r, db, helmSvc)
+			if !ok {
+				return
+			}
+			_ = json.NewEncoder(w).Encode(s)
+		})
+
+		sr.With(RequireRole(ActionUpdate)).Patch("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			s, ok := workspaceServer(w, r, db, helmSvc)
+			if !ok {
+				return
+			}
+			var req ServerUpdateRequest
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			if req.Name != nil && *req.Name != s.Name {
+				if !validServerName(w, *req.Name) {
+					return
+				}
+				// A rename keeps the release, so only the name has to be free
+				taken := map[string]interface{}{"workspace_id": s.WorkspaceID, "name": *req.Name, "_id": map[string]interface{}{"$ne": s.ID}}
+				if !serverNameFree(w, r, db, taken) {
+					return
+				}
+				s.Name = *req.Name
+			}
+			if req.ConfigJSON != nil {
+				s.ConfigJSON = mergePatch(s.ConfigJSON, req.ConfigJSON)
+			}
+			if !validConfig(w, s.ConfigJSON) {
+				return
+			}
+			s.UpdatedAt = time.Now().UTC()
+			// release is written too, so a legacy server renamed here keeps the release it had
+			update := map[string]interface{}{"$set": map[string]interface{}{"name": s.Name, "release": s.Release, "config_json": s.ConfigJSON, "updated_at": s.UpdatedAt}}
+			if _, err := db.Servers().UpdateOne(r.Context(), map[string]interface{}{"_id": s.ID, "workspace_id": s.WorkspaceID}, update); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
 			_ = json.NewEncoder(w).Encode(s)
 		})
 
-		sr.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		sr.With(RequireRole(ActionDelete)).Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := callerClaims(w, r)
+			if !ok {
+				return
+			}
 			id := chi.URLParam(r, "id")
-			if _, err := db.Servers().DeleteOne(r.Context(), map[string]interface{}{"_id": id}); err != nil {
+			res, err := db.Servers().DeleteOne(r.Context(), map[string]interface{}{"_id": id, "workspace_id": claims.WorkspaceID})
+			if err != nil {
+				audit(r, id, "delete", err)
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
+			if res.DeletedCount == 0 {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			audit(r, id, "delete", nil)
 			w.WriteHeader(http.StatusNoContent)
 		})
 
 		// Deploy/Upgrade/Uninstall
-		sr.Post("/{id}/deploy", func(w http.ResponseWriter, r *http.Request) {
-			id := chi.URLParam(r, "id")
-			var s storage.ServerDef
-			if err := db.Servers().FindOne(r.Context(), map[string]interface{}{"_id": id}).Decode(&s); err != nil {
-				http.Error(w, "not found", http.StatusNotFound)
+		sr.With(RequireRole(ActionDeploy)).Post("/{id}/deploy", func(w http.ResponseWriter, r *http.Request) {
+			s, ok := workspaceServer(w, r, db, helmSvc)
+			if !ok {
+				return
+			}
+			values, ok := serverValues(w, helmSvc, s)
+			if !ok {
 				return
 			}
-			// Serialize config JSON as Helm values directly
-			values, _ := json.Marshal(s.ConfigJSON)
-			if err := helmSvc.UpsertRelease("mcp-"+s.Name, string(values), ""); err != nil {
+			if r.URL.Query().Get("dry_run") == "true" {
+				preview, err := helmSvc.PreviewRelease(s.Release, values, s.Namespace)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadGateway)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(preview)
+				return
+			}
+			err := helmSvc.UpsertRelease(s.Release, values, s.Namespace)
+			metrics.ObserveHelm("deploy", err)
+			audit(r, s.ID, "deploy", err)
+			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadGateway)
 				return
 			}
 			_ = json.NewEncoder(w).Encode(map[string]string{"status": "deployed"})
 		})
 
-		sr.Post("/{id}/upgrade", func(w http.ResponseWriter, r *http.Request) {
-			id := chi.URLParam(r, "id")
-			var s storage.ServerDef
-			if err := db.Servers().FindOne(r.Context(), map[string]interface{}{"_id": id}).Decode(&s); err != nil {
-				http.Error(w, "not found", http.StatusNotFound)
+		sr.With(RequireRole(ActionUpgrade)).Post("/{id}/upgrade", func(w http.ResponseWriter, r *http.Request) {
+			s, ok := workspaceServer(w, r, db, helmSvc)
+			if !ok {
 				return
 			}
 			var overrides map[string]interface{}
-			_ = json.NewDecoder(r.Body).Decode(&overrides)
+			// The body is optional, so only an oversized one is an error
+			err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&overrides)
+			if bodyTooLarge(w, err) {
+				return
+			}
 			if overrides != nil {
 				for k, v := range overrides {
 					s.ConfigJSON[k] = v
 				}
 			}
-			values, _ := json.Marshal(s.ConfigJSON)
-			if err := helmSvc.UpsertRelease("mcp-"+s.Name, string(values), ""); err != nil {
+			values, ok := serverValues(w, helmSvc, s)
+			if !ok {
+				return
+			}
+			err = helmSvc.UpsertRelease(s.Release, values, s.Namespace)
+			metrics.ObserveHelm("upgrade", err)
+			audit(r, s.ID, "upgrade", err)
+			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadGateway)
 				return
 			}
 			_ = json.NewEncoder(w).Encode(map[string]string{"status": "upgraded"})
 		})
 
-		sr.Post("/{id}/uninstall", func(w http.ResponseWriter, r *http.Request) {
-			id := chi.URLParam(r, "id")
-			var s storage.ServerDef
-			if err := db.Servers().FindOne(r.Context(), map[string]interface{}{"_id": id}).Decode(&s); err != nil {
-				http.Error(w, "not found", http.StatusNotFound)
+		sr.With(RequireRole(ActionRead)).Get("/{id}/status", func(w http.ResponseWriter, r *http.Request) {
+			s, ok := workspaceServer(w, r, db, helmSvc)
+			if !ok {
+				return
+			}
+			info, err := helmSvc.ReleaseStatus(s.Release, s.Namespace)
+			if errors.Is(err, helm.ErrReleaseNotFound) {
+				http.Error(w, "not deployed", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(info)
+		})
+
+		sr.With(RequireRole(ActionRead)).Get("/{id}/history", func(w http.ResponseWriter, r *http.Request) {
+			s, ok := workspaceServer(w, r, db, helmSvc)
+			if !ok {
+				return
+			}
+			revisions, err := helmSvc.ReleaseHistory(s.Release, s.Namespace)
+			if errors.Is(err, helm.ErrReleaseNotFound) {
+				http.Error(w, "not deployed", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(revisions)
+		})
+
+		// Logs of the release's newest pod; ?tail=N limits history, ?follow=true streams
+		sr.With(RequireRole(ActionRead)).Get("/{id}/logs", func(w http.ResponseWriter, r *http.Request) {
+			s, ok := workspaceServer(w, r, db, helmSvc)
+			if !ok {
+				return
+			}
+			opts := helm.LogOptions{TailLines: 100, Follow: r.URL.Query().Get("follow") == "true"}
+			if v := r.URL.Query().Get("tail"); v != "" {
+				n, err := strconv.ParseInt(v, 10, 64)
+				if err != nil || n < 1 || n > maxLogTail {
+					http.Error(w, fmt.Sprintf("tail must be between 1 and %d", maxLogTail), http.StatusBadRequest)
+					return
+				}
+				opts.TailLines = n
+			}
+			logs, err := helmSvc.PodLogs(r.Context(), s.Release, s.Namespace, opts)
+			if errors.Is(err, helm.ErrNoPods) {
+				http.Error(w, "not running", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			defer logs.Close()
+
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			if !opts.Follow {
+				_, _ = io.Copy(w, logs)
 				return
 			}
-			if err := helmSvc.UninstallRelease("mcp-"+s.Name, ""); err != nil {
+			// A followed stream outlives the server's write timeout
+			rc := http.NewResponseController(w)
+			_ = rc.SetWriteDeadline(time.Time{})
+			buf := make([]byte, 4096)
+			for {
+				n, err := logs.Read(buf)
+				if n > 0 {
+					if _, werr := w.Write(buf[:n]); werr != nil {
+						return
+					}
+					_ = rc.Flush()
+				}
+				if err != nil {
+					return
+				}
+			}
+		})
+
+		sr.With(RequireRole(ActionUninstall)).Post("/{id}/uninstall", func(w http.ResponseWriter, r *http.Request) {
+			s, ok := workspaceServer(w, r, db, helmSvc)
+			if !ok {
+				return
+			}
+			err := helmSvc.UninstallRelease(s.Release, s.Namespace)
+			metrics.ObserveHelm("uninstall", err)
+			audit(r, s.ID, "uninstall", err)
+			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadGateway)
 				return
 			}
 			_ = json.NewEncoder(w).Encode(map[string]string{"status": "uninstalled"})
 		})
+
+		sr.With(RequireRole(ActionRead)).Get("/{id}/audit", func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := callerClaims(w, r)
+			if !ok {
+				return
+			}
+			entries, err := db.ListAudit(r.Context(), claims.WorkspaceID, chi.URLParam(r, "id"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(entries)
+		})
 	})
 }
+
+// callerClaims returns the claims AuthMiddleware attached to the request
+func callerClaims(w http.ResponseWriter, r *http.Request) (*auth.Claims, bool) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}
+	return claims, ok
+}
+
+// workspaceServer loads the {id} server if it belongs to the caller's workspace.
+// Servers in other workspaces get the same 404 as missing ones so IDs don't leak.
+func workspaceServer(w http.ResponseWriter, r *http.Request, db *storage.MongoStore, helmSvc *helm.Service) (*storage.ServerDef, bool) {
+	claims, ok := callerClaims(w, r)
+	if !ok {
+		return nil, false
+	}
+	var s storage.ServerDef
+	filter := map[string]interface{}{"_id": chi.URLParam(r, "id"), "workspace_id": claims.WorkspaceID}
+	if err := db.Servers().FindOne(r.Context(), filter).Decode(&s); err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return nil, false
+	}
+	backfillLegacy(&s, helmSvc)
+	return &s, true
+}
+
+// collectReleases adds the release of every server matching filter to releases, keyed
+// by namespace and release name. It answers 500 and returns false when the query fails.
+func collectReleases(w http.ResponseWriter, r *http.Request, db *storage.MongoStore, helmSvc *helm.Service, filter map[string]interface{}, releases map[string]map[string]string) bool {
+	cur, err := db.Servers().Find(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	defer cur.Close(r.Context())
+	for cur.Next(r.Context()) {
+		var s storage.ServerDef
+		if err := cur.Decode(&s); err != nil {
+			continue
+		}
+		backfillLegacy(&s, helmSvc)
+		if releases[s.Namespace] == nil {
+			releases[s.Namespace] = map[string]string{}
+		}
+		releases[s.Namespace][s.Release] = s.ID
+	}
+	return true
+}
+
+// backfillLegacy fills in the fields of servers stored before they were recorded
+func backfillLegacy(s *storage.ServerDef, helmSvc *helm.Service) {
+	// Servers created before per-tenant namespaces were installed in the Helm namespace
+	s.Namespace = helmSvc.Namespace(s.Namespace)
+	// and those created before the release name was stored used their current name
+	if s.Release == "" {
+		s.Release = helm.ReleaseName(s.Name)
+	}
+}
+
+// decodeJSON decodes the request body into v, writing 413 for bodies over maxRequestBytes
+// or 400 for malformed JSON. It reports whether decoding succeeded.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(v)
+	if err == nil {
+		return true
+	}
+	if !bodyTooLarge(w, err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+	return false
+}
+
+// bodyTooLarge writes 413 and returns true when err came from exceeding the body limit
+func bodyTooLarge(w http.ResponseWriter, err error) bool {
+	var maxErr *http.MaxBytesError
+	if !errors.As(err, &maxErr) {
+		return false
+	}
+	http.Error(w, fmt.Sprintf("request body exceeds %d bytes", maxErr.Limit), http.StatusRequestEntityTooLarge)
+	return true
+}
+
+// validServerName answers 400 unless name can be used for a server's Helm release
+func validServerName(w http.ResponseWriter, name string) bool {
+	if name == "" {
+		http.Error(w, "name required", http.StatusBadRequest)
+		return false
+	}
+	if err := helm.ValidateServerName(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// serverNameFree answers 409 when a server matching filter already exists
+func serverNameFree(w http.ResponseWriter, r *http.Request, db *storage.MongoStore, filter map[string]interface{}) bool {
+	n, err := db.Servers().CountDocuments(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if n > 0 {
+		http.Error(w, "a server with this name already exists", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// validConfig checks a server's config against the MCP template schema, answering 400
+// with the list of problems when it doesn't conform
+func validConfig(w http.ResponseWriter, configJSON map[string]interface{}) bool {
+	err := serverconfig.Validate(configJSON)
+	if err == nil {
+		return true
+	}
+	var invalid *serverconfig.ValidationError
+	if !errors.As(err, &invalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": "invalid config", "problems": invalid.Problems})
+	return false
+}
+
+// serverValues renders the Helm values for s, answering 400 when its config can't be
+// mapped onto the chart
+func serverValues(w http.ResponseWriter, helmSvc *helm.Service, s *storage.ServerDef) (string, bool) {
+	vals, err := helmSvc.ChartValues(s.Name, s.ConfigJSON)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	values, err := helmSvc.RenderValues(vals)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return "", false
+	}
+	return values, true
+}
+
+// mergePatch applies patch to dst following RFC 7396 and returns the result
+func mergePatch(dst, patch map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = map[string]interface{}{}
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+		if pm, ok := v.(map[string]interface{}); ok {
+			dm, _ := dst[k].(map[string]interface{})
+			dst[k] = mergePatch(dm, pm)
+			continue
+		}
+		dst[k] = v
+	}
+	return dst
+}
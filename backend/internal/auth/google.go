@@ -2,6 +2,10 @@ package auth
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
 
@@ -19,18 +23,94 @@ func GoogleOAuthConfig() *oauth2.Config {
 	}
 }
 
-func BeginGoogleLogin(w http.ResponseWriter, r *http.Request) {
+const stateCookie = "oauth_state"
+
+var (
+	ErrMissingState  = errors.New("missing oauth state")
+	ErrStateMismatch = errors.New("oauth state does not match this browser")
+	ErrInvalidState  = errors.New("oauth state expired or already used")
+)
+
+// BeginGoogleLogin redirects to Google with a random state, bound to the browser by a
+// cookie, and a PKCE challenge
+func BeginGoogleLogin(w http.ResponseWriter, r *http.Request, states *LoginStateStore) {
 	cfg := GoogleOAuthConfig()
-	state := "dev" // TODO: CSRF-safe random state and session storage
-	url := cfg.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	verifier := oauth2.GenerateVerifier()
+	state, err := states.Begin(verifier)
+	if err != nil {
+		http.Error(w, "could not start login", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     "/auth/google",
+		MaxAge:   int(states.ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	url := cfg.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier))
 	http.Redirect(w, r, url, http.StatusFound)
 }
 
-func HandleGoogleCallback(r *http.Request) (*oauth2.Token, error) {
+// VerifyGoogleCallback checks that the callback's state matches the cookie set by
+// BeginGoogleLogin and is still pending, and returns the PKCE verifier for the exchange.
+// The cookie is cleared either way.
+func VerifyGoogleCallback(w http.ResponseWriter, r *http.Request, states *LoginStateStore) (string, error) {
+	http.SetCookie(w, &http.Cookie{Name: stateCookie, Value: "", Path: "/auth/google", MaxAge: -1, HttpOnly: true})
+
+	state := r.URL.Query().Get("state")
+	cookie, err := r.Cookie(stateCookie)
+	if state == "" || err != nil || cookie.Value == "" {
+		return "", ErrMissingState
+	}
+	if subtle.ConstantTimeCompare([]byte(state), []byte(cookie.Value)) != 1 {
+		return "", ErrStateMismatch
+	}
+	verifier, ok := states.Consume(state)
+	if !ok {
+		return "", ErrInvalidState
+	}
+	return verifier, nil
+}
+
+func HandleGoogleCallback(w http.ResponseWriter, r *http.Request, states *LoginStateStore) (*oauth2.Token, error) {
+	verifier, err := VerifyGoogleCallback(w, r, states)
+	if err != nil {
+		return nil, err
+	}
 	cfg := GoogleOAuthConfig()
 	code := r.URL.Query().Get("code")
-	ctx := context.Background()
-	return cfg.Exchange(ctx, code)
+	return cfg.Exchange(r.Context(), code, oauth2.VerifierOption(verifier))
 }
 
+const googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+
+type GoogleUserInfo struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
 
+// FetchGoogleUserInfo reads the signed-in user's profile with the exchanged token
+func FetchGoogleUserInfo(ctx context.Context, token *oauth2.Token) (*GoogleUserInfo, error) {
+	resp, err := GoogleOAuthConfig().Client(ctx, token).Get(googleUserInfoURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo returned %d", resp.StatusCode)
+	}
+
+	var info GoogleUserInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	if info.Email == "" || !info.EmailVerified {
+		return nil, fmt.Errorf("google account has no verified email")
+	}
+	return &info, nil
+}
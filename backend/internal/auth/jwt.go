@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v5"
@@ -30,4 +32,33 @@ func IssueJWT(secret string, sub, tenantID, workspaceID, role string, ttl time.D
 	return t.SignedString([]byte(secret))
 }
 
+// ParseJWT verifies an app token and returns its claims. Only HS256 is accepted, so a
+// token cannot pick a weaker algorithm, and tokens without an expiry are rejected.
+func ParseJWT(secret, tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims,
+		func(t *jwt.Token) (interface{}, error) { return []byte(secret), nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Sub == "" || claims.WorkspaceID == "" {
+		return nil, errors.New("token is missing subject or workspace")
+	}
+	return claims, nil
+}
+
+type claimsKey struct{}
 
+// WithClaims returns a copy of ctx carrying the caller's verified claims
+func WithClaims(ctx context.Context, c *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, c)
+}
+
+// ClaimsFromContext returns the claims stored by the auth middleware
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(*Claims)
+	return c, ok
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// LoginStateStore remembers the OAuth state and PKCE verifier of each login in
+// progress. Entries are single-use and expire, so a callback cannot be replayed. At
+// most limit logins are kept; beyond that the oldest is dropped, so unfinished logins
+// can't grow the store without bound. It is in-memory: run one backend replica, or use
+// sticky sessions, while logins are open.
+type LoginStateStore struct {
+	ttl     time.Duration
+	limit   int
+	mu      sync.Mutex
+	pending map[string]loginState
+}
+
+type loginState struct {
+	verifier string
+	expires  time.Time
+}
+
+func NewLoginStateStore(ttl time.Duration, limit int) *LoginStateStore {
+	return &LoginStateStore{ttl: ttl, limit: limit, pending: make(map[string]loginState)}
+}
+
+// Begin stores the PKCE verifier of a new login under a fresh random state
+func (s *LoginStateStore) Begin(verifier string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	state := base64.RawURLEncoding.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	oldest := ""
+	for k, v := range s.pending {
+		if now.After(v.expires) {
+			delete(s.pending, k)
+		} else if oldest == "" || v.expires.Before(s.pending[oldest].expires) {
+			oldest = k
+		}
+	}
+	if len(s.pending) >= s.limit && oldest != "" {
+		delete(s.pending, oldest)
+	}
+	s.pending[state] = loginState{verifier: verifier, expires: now.Add(s.ttl)}
+	return state, nil
+}
+
+// Consume removes state and returns its verifier if it was pending and unexpired
+func (s *LoginStateStore) Consume(state string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.pending[state]
+	if !ok {
+		return "", false
+	}
+	delete(s.pending, state)
+	if time.Now().After(entry.expires) {
+		return "", false
+	}
+	return entry.verifier, true
+}
@@ -1,25 +1,62 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
+	Port           int
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	IdleTimeout    time.Duration
 	MongoURI       string
 	MongoDB        string
 	HelmNamespace  string
 	HelmChartPath  string
 	KubeConfigPath string
+	JWTSecret      string
+	ServerImage    string // default image for deployed MCP servers
+	ServerImageTag string
+	// CORSAllowedOrigins lists the browser origins allowed to call the API; empty disables CORS
+	CORSAllowedOrigins []string
 }
 
-func Load() Config {
-	return Config{
-		MongoURI:       env("MONGO_URI", "mongodb://localhost:27017"),
-		MongoDB:        env("MONGO_DB", "mcp"),
-		HelmNamespace:  env("HELM_NAMESPACE", "mcp"),
-		HelmChartPath:  env("HELM_CHART_PATH", "../mcp-server-template/deploy/helm"),
-		KubeConfigPath: env("KUBECONFIG", ""),
+// MinJWTSecretBytes is the shortest JWT_SECRET accepted; shorter secrets can be brute-forced
+const MinJWTSecretBytes = 32
+
+// Load reads the configuration from the environment. It fails when a setting the
+// backend can't run safely without is missing.
+func Load() (Config, error) {
+	var errs []error
+	cfg := Config{
+		Port:               envInt("PORT", 6000, &errs),
+		ReadTimeout:        envDuration("READ_TIMEOUT", 15*time.Second, &errs),
+		WriteTimeout:       envDuration("WRITE_TIMEOUT", 30*time.Second, &errs),
+		IdleTimeout:        envDuration("IDLE_TIMEOUT", 60*time.Second, &errs),
+		MongoURI:           env("MONGO_URI", "mongodb://localhost:27017"),
+		MongoDB:            env("MONGO_DB", "mcp"),
+		HelmNamespace:      env("HELM_NAMESPACE", "mcp"),
+		HelmChartPath:      env("HELM_CHART_PATH", "../mcp-server-template/deploy/helm"),
+		KubeConfigPath:     env("KUBECONFIG", ""),
+		JWTSecret:          os.Getenv("JWT_SECRET"),
+		ServerImage:        env("MCP_SERVER_IMAGE", "mcp-server"),
+		ServerImageTag:     env("MCP_SERVER_IMAGE_TAG", "latest"),
+		CORSAllowedOrigins: list(env("CORS_ALLOWED_ORIGINS", "")),
+	}
+
+	// Anyone who knows the signing secret can mint tokens for any user
+	switch {
+	case cfg.JWTSecret == "":
+		errs = append(errs, errors.New("JWT_SECRET must be set"))
+	case len(cfg.JWTSecret) < MinJWTSecretBytes:
+		errs = append(errs, fmt.Errorf("JWT_SECRET must be at least %d bytes", MinJWTSecretBytes))
 	}
+	return cfg, errors.Join(errs...)
 }
 
 func env(k, d string) string {
@@ -28,3 +65,44 @@ func env(k, d string) string {
 	}
 	return d
 }
+
+// envInt reads an integer, falling back to d when the value is unset. A malformed value
+// is added to errs rather than silently replaced by the default.
+func envInt(k string, d int, errs *[]error) int {
+	v := os.Getenv(k)
+	if v == "" {
+		return d
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %q is not an integer", k, v))
+		return d
+	}
+	return n
+}
+
+// envDuration reads a positive duration such as "15s" or "2m", falling back to d when
+// the value is unset. A malformed value is added to errs.
+func envDuration(k string, d time.Duration, errs *[]error) time.Duration {
+	v := os.Getenv(k)
+	if v == "" {
+		return d
+	}
+	parsed, err := time.ParseDuration(v)
+	if err != nil || parsed <= 0 {
+		*errs = append(*errs, fmt.Errorf("%s: %q is not a positive duration", k, v))
+		return d
+	}
+	return parsed
+}
+
+// list splits a comma-separated value, dropping blank entries
+func list(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
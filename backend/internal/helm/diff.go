@@ -0,0 +1,116 @@
+package helm
+
+import (
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ResourceChange describes how one Kubernetes resource differs between two manifests
+type ResourceChange struct {
+	Resource string `json:"resource"` // Kind/namespace/name
+	Change   string `json:"change"`   // added, removed or changed
+	Diff     string `json:"diff,omitempty"`
+}
+
+// DiffManifests compares two rendered multi-document manifests resource by resource.
+// Unchanged resources are left out; the result is sorted by resource.
+func DiffManifests(current, proposed string) []ResourceChange {
+	before := splitResources(current)
+	after := splitResources(proposed)
+
+	var changes []ResourceChange
+	for key, doc := range after {
+		old, ok := before[key]
+		switch {
+		case !ok:
+			changes = append(changes, ResourceChange{Resource: key, Change: "added", Diff: lineDiff("", doc)})
+		case old != doc:
+			changes = append(changes, ResourceChange{Resource: key, Change: "changed", Diff: lineDiff(old, doc)})
+		}
+	}
+	for key, doc := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, ResourceChange{Resource: key, Change: "removed", Diff: lineDiff(doc, "")})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Resource < changes[j].Resource })
+	return changes
+}
+
+// splitResources indexes the documents of a manifest by Kind/namespace/name
+func splitResources(manifest string) map[string]string {
+	out := map[string]string{}
+	for _, doc := range strings.Split(manifest, "\n---") {
+		doc = strings.TrimSpace(strings.TrimPrefix(doc, "---"))
+		var head struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name      string `yaml:"name"`
+				Namespace string `yaml:"namespace"`
+			} `yaml:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &head); err != nil || head.Kind == "" {
+			continue
+		}
+		out[head.Kind+"/"+head.Metadata.Namespace+"/"+head.Metadata.Name] = stripComments(doc)
+	}
+	return out
+}
+
+// stripComments drops the "# Source: ..." lines Helm adds so they don't show up as changes
+func stripComments(doc string) string {
+	var kept []string
+	for _, line := range strings.Split(doc, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// lineDiff renders a minimal line diff, prefixing removed lines with "-", added lines
+// with "+" and unchanged lines with a space
+func lineDiff(a, b string) string {
+	var x, y []string
+	if a != "" {
+		x = strings.Split(a, "\n")
+	}
+	if b != "" {
+		y = strings.Split(b, "\n")
+	}
+
+	// lcs[i][j] is the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			sb.WriteString(" " + x[i] + "\n")
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("-" + x[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+" + y[j] + "\n")
+			j++
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,73 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// MaxLogBytes caps how much log output a single request can read from a pod
+const MaxLogBytes = 1 << 20
+
+// LogOptions selects which part of a release's logs to read
+type LogOptions struct {
+	TailLines int64
+	Follow    bool
+}
+
+// ErrNoPods is returned when a release has no pods to read logs from
+var ErrNoPods = errors.New("release has no pods")
+
+// PodLogs opens the log stream of the newest pod of a release. The caller must close
+// the returned reader, which never yields more than MaxLogBytes.
+func (s *Service) PodLogs(ctx context.Context, releaseName string, namespace string, opts LogOptions) (io.ReadCloser, error) {
+	if namespace == "" {
+		namespace = s.cfg.HelmNamespace
+	}
+	settings := cli.New()
+	if s.cfg.KubeConfigPath != "" {
+		settings.KubeConfig = s.cfg.KubeConfigPath
+	}
+	restCfg, err := settings.RESTClientGetter().ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes config failed: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes client failed: %w", err)
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/instance=" + releaseName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pods failed: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, ErrNoPods
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].CreationTimestamp.After(pods.Items[j].CreationTimestamp.Time)
+	})
+
+	limit := int64(MaxLogBytes)
+	logOpts := &corev1.PodLogOptions{Follow: opts.Follow, LimitBytes: &limit}
+	if opts.TailLines > 0 {
+		logOpts.TailLines = &opts.TailLines
+	}
+	stream, err := client.CoreV1().Pods(namespace).GetLogs(pods.Items[0].Name, logOpts).Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("open logs failed: %w", err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(stream, MaxLogBytes), stream}, nil
+}
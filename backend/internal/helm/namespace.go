@@ -0,0 +1,45 @@
+package helm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var invalidNamespaceChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+var dns1123Label = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// maxReleaseNameLen is Helm's limit on release names
+const maxReleaseNameLen = 53
+
+// ValidateServerName checks that a server name makes a valid release name: a DNS-1123
+// label (lowercase letters, digits and dashes) short enough for Helm once prefixed.
+func ValidateServerName(name string) error {
+	if !dns1123Label.MatchString(name) {
+		return fmt.Errorf("name %q must consist of lowercase letters, digits and '-', and start and end with a letter or digit", name)
+	}
+	if limit := maxReleaseNameLen - len(ReleaseName("")); len(name) > limit {
+		return fmt.Errorf("name %q must be at most %d characters", name, limit)
+	}
+	return nil
+}
+
+// TenantNamespace returns the Kubernetes namespace a tenant's servers are deployed to.
+// The result is a valid DNS-1123 label.
+func TenantNamespace(tenantID string) string {
+	ns := "mcp-" + invalidNamespaceChars.ReplaceAllString(strings.ToLower(tenantID), "-")
+	if len(ns) > 63 {
+		ns = ns[:63]
+	}
+	return strings.TrimRight(ns, "-")
+}
+
+// Namespace returns namespace, or when it is empty the configured Helm namespace, which
+// servers were deployed to before each tenant had its own
+func (s *Service) Namespace(namespace string) string {
+	if namespace == "" {
+		return s.cfg.HelmNamespace
+	}
+	return namespace
+}
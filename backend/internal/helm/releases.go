@@ -0,0 +1,159 @@
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// releasePrefix starts the name of every release the backend creates for a server
+const releasePrefix = "mcp-"
+
+// ReleaseName returns the Helm release name used for a server
+func ReleaseName(serverName string) string { return releasePrefix + serverName }
+
+// ErrReleaseNotFound is returned when a server has never been deployed
+var ErrReleaseNotFound = driver.ErrReleaseNotFound
+
+// ReleaseInfo summarises one revision of a release
+type ReleaseInfo struct {
+	Name         string    `json:"name"`
+	Namespace    string    `json:"namespace"`
+	Revision     int       `json:"revision"`
+	Phase        string    `json:"phase"` // deployed, failed, pending-upgrade, ...
+	Description  string    `json:"description"`
+	Chart        string    `json:"chart"`
+	AppVersion   string    `json:"app_version"`
+	LastDeployed time.Time `json:"last_deployed"`
+}
+
+// ReleaseStatus returns the latest revision of a release
+func (s *Service) ReleaseStatus(releaseName string, namespace string) (*ReleaseInfo, error) {
+	cfg, err := s.actionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := action.NewStatus(cfg).Run(releaseName)
+	if err != nil {
+		return nil, fmt.Errorf("helm status failed: %w", err)
+	}
+	return releaseInfo(rel), nil
+}
+
+// ReleaseHistory returns every stored revision of a release, oldest first
+func (s *Service) ReleaseHistory(releaseName string, namespace string) ([]ReleaseInfo, error) {
+	cfg, err := s.actionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	rels, err := action.NewHistory(cfg).Run(releaseName)
+	if err != nil {
+		return nil, fmt.Errorf("helm history failed: %w", err)
+	}
+	if len(rels) == 0 {
+		return nil, ErrReleaseNotFound
+	}
+	out := make([]ReleaseInfo, 0, len(rels))
+	for _, rel := range rels {
+		out = append(out, *releaseInfo(rel))
+	}
+	// Storage drivers return revisions in no particular order
+	sort.Slice(out, func(i, j int) bool { return out[i].Revision < out[j].Revision })
+	return out, nil
+}
+
+// ListReleases returns the latest revision of every MCP server release in the
+// namespace, whatever its state, sorted by name
+func (s *Service) ListReleases(namespace string) ([]ReleaseInfo, error) {
+	cfg, err := s.actionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	list := action.NewList(cfg)
+	list.All = true
+	list.Filter = "^" + releasePrefix
+	list.SetStateMask()
+	rels, err := list.Run()
+	if err != nil {
+		return nil, fmt.Errorf("helm list failed: %w", err)
+	}
+	out := make([]ReleaseInfo, 0, len(rels))
+	for _, rel := range rels {
+		out = append(out, *releaseInfo(rel))
+	}
+	return out, nil
+}
+
+// Preview is the outcome of a dry-run deploy
+type Preview struct {
+	Manifest string           `json:"manifest"`
+	Changes  []ResourceChange `json:"changes"`
+}
+
+// PreviewRelease renders what UpsertRelease would apply, without touching the cluster
+// state, and diffs it against the manifest of the currently deployed revision
+func (s *Service) PreviewRelease(releaseName string, valuesYAML string, namespace string) (*Preview, error) {
+	if namespace == "" {
+		namespace = s.cfg.HelmNamespace
+	}
+	cfg, err := s.actionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	chart, err := loader.Load(s.cfg.HelmChartPath)
+	if err != nil {
+		return nil, fmt.Errorf("load chart failed: %w", err)
+	}
+	vals := map[string]interface{}{}
+	if valuesYAML != "" {
+		if err := yaml.Unmarshal([]byte(valuesYAML), &vals); err != nil {
+			return nil, fmt.Errorf("values parse failed: %w", err)
+		}
+	}
+
+	var current string
+	var rel *release.Release
+	last, err := action.NewStatus(cfg).Run(releaseName)
+	switch {
+	case err == nil:
+		current = last.Manifest
+		up := action.NewUpgrade(cfg)
+		up.Namespace = namespace
+		up.DryRun = true
+		up.HideSecret = true
+		rel, err = up.Run(releaseName, chart, vals)
+	case errors.Is(err, driver.ErrReleaseNotFound):
+		in := action.NewInstall(cfg)
+		in.ReleaseName = releaseName
+		in.Namespace = namespace
+		in.DryRun = true
+		in.HideSecret = true
+		rel, err = in.Run(chart, vals)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("helm dry-run failed: %w", err)
+	}
+
+	return &Preview{Manifest: rel.Manifest, Changes: DiffManifests(current, rel.Manifest)}, nil
+}
+
+func releaseInfo(rel *release.Release) *ReleaseInfo {
+	info := &ReleaseInfo{Name: rel.Name, Namespace: rel.Namespace, Revision: rel.Version}
+	if rel.Info != nil {
+		info.Phase = rel.Info.Status.String()
+		info.Description = rel.Info.Description
+		info.LastDeployed = rel.Info.LastDeployed.Time
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		info.Chart = rel.Chart.Metadata.Name + "-" + rel.Chart.Metadata.Version
+		info.AppVersion = rel.Chart.Metadata.AppVersion
+	}
+	return info
+}
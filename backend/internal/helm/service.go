@@ -1,6 +1,7 @@
 package helm
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
@@ -8,6 +9,7 @@ import (
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/storage/driver"
 
 	"mcp-backend/internal/config"
 )
@@ -16,20 +18,16 @@ type Service struct{ cfg config.Config }
 
 func NewService(cfg config.Config) *Service { return &Service{cfg: cfg} }
 
-// Install or upgrade a release for an MCP server using Helm SDK
+// Install or upgrade a release for an MCP server using Helm SDK. The namespace is
+// created on first install if it doesn't exist.
 func (s *Service) UpsertRelease(releaseName string, valuesYAML string, namespace string) error {
 	if namespace == "" {
 		namespace = s.cfg.HelmNamespace
 	}
 
-	settings := cli.New()
-	if s.cfg.KubeConfigPath != "" {
-		settings.KubeConfig = s.cfg.KubeConfigPath
-	}
-
-	var cfg action.Configuration
-	if err := cfg.Init(settings.RESTClientGetter(), namespace, "secrets", logrus.Debugf); err != nil {
-		return fmt.Errorf("helm init failed: %w", err)
+	cfg, err := s.actionConfig(namespace)
+	if err != nil {
+		return err
 	}
 
 	chart, err := loader.Load(s.cfg.HelmChartPath)
@@ -44,17 +42,46 @@ func (s *Service) UpsertRelease(releaseName string, valuesYAML string, namespace
 		}
 	}
 
-	up := action.NewUpgrade(&cfg)
-	up.Namespace = namespace
-	up.Install = true // upgrade --install semantics
+	// upgrade --install semantics: the SDK's Upgrade only works on existing releases
+	if _, err := cfg.Releases.History(releaseName); errors.Is(err, driver.ErrReleaseNotFound) {
+		in := action.NewInstall(cfg)
+		in.ReleaseName = releaseName
+		in.Namespace = namespace
+		in.CreateNamespace = true
+		if _, err := in.Run(chart, vals); err != nil {
+			return fmt.Errorf("helm install failed: %w", err)
+		}
+		return nil
+	}
 
+	up := action.NewUpgrade(cfg)
+	up.Namespace = namespace
 	if _, err := up.Run(releaseName, chart, vals); err != nil {
-		return fmt.Errorf("helm upgrade/install failed: %w", err)
+		return fmt.Errorf("helm upgrade failed: %w", err)
 	}
 	return nil
 }
 
 func (s *Service) UninstallRelease(releaseName string, namespace string) error {
+	cfg, err := s.actionConfig(namespace)
+	if err != nil {
+		return err
+	}
+	un := action.NewUninstall(cfg)
+	if _, err := un.Run(releaseName); err != nil {
+		return fmt.Errorf("helm uninstall failed: %w", err)
+	}
+	return nil
+}
+
+// Check verifies that a Helm action configuration can be built for the default namespace
+func (s *Service) Check() error {
+	_, err := s.actionConfig("")
+	return err
+}
+
+// actionConfig initialises Helm for namespace, defaulting to the configured one
+func (s *Service) actionConfig(namespace string) (*action.Configuration, error) {
 	if namespace == "" {
 		namespace = s.cfg.HelmNamespace
 	}
@@ -64,13 +91,9 @@ func (s *Service) UninstallRelease(releaseName string, namespace string) error {
 	}
 	var cfg action.Configuration
 	if err := cfg.Init(settings.RESTClientGetter(), namespace, "secrets", logrus.Debugf); err != nil {
-		return fmt.Errorf("helm init failed: %w", err)
-	}
-	un := action.NewUninstall(&cfg)
-	if _, err := un.Run(releaseName); err != nil {
-		return fmt.Errorf("helm uninstall failed: %w", err)
+		return nil, fmt.Errorf("helm init failed: %w", err)
 	}
-	return nil
+	return &cfg, nil
 }
 
 // RenderValues maps arbitrary map[string]interface{} to YAML for Helm values.
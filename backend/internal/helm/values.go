@@ -0,0 +1,101 @@
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"mcp-backend/internal/serverconfig"
+)
+
+// ValuesError lists why a server definition can't be turned into chart values
+type ValuesError struct{ Problems []string }
+
+func (e *ValuesError) Error() string {
+	return "incomplete chart values: " + strings.Join(e.Problems, "; ")
+}
+
+// ChartValues maps a server's ConfigJSON onto the values of the mcp-server chart. The
+// MCP config goes under "config", which the chart renders into the server's ConfigMap.
+// Optional "image" ({repository, tag}) and "replicas" keys override the deployment
+// defaults; any other top-level key is rejected rather than passed to Helm unchecked.
+func (s *Service) ChartValues(serverName string, configJSON map[string]interface{}) (map[string]interface{}, error) {
+	var problems []string
+	for key := range configJSON {
+		if key != "config" && key != "image" && key != "replicas" {
+			problems = append(problems, fmt.Sprintf("unknown key %q", key))
+		}
+	}
+	var invalid *serverconfig.ValidationError
+	if err := serverconfig.Validate(configJSON); errors.As(err, &invalid) {
+		problems = append(problems, invalid.Problems...)
+	} else if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	image := map[string]interface{}{"repository": s.cfg.ServerImage, "tag": s.cfg.ServerImageTag}
+	if raw, ok := configJSON["image"]; ok {
+		override, ok := raw.(map[string]interface{})
+		if !ok {
+			problems = append(problems, "image must be an object")
+		}
+		for _, field := range []string{"repository", "tag"} {
+			if v, ok := override[field]; ok {
+				if str, ok := v.(string); ok && str != "" {
+					image[field] = str
+				} else {
+					problems = append(problems, "image."+field+" must be a non-empty string")
+				}
+			}
+		}
+	}
+	if image["repository"] == "" {
+		problems = append(problems, "image.repository is required")
+	}
+
+	replicas := 1
+	if raw, ok := configJSON["replicas"]; ok {
+		n, ok := toInt(raw)
+		if !ok || n < 1 {
+			problems = append(problems, "replicas must be a positive integer")
+		}
+		replicas = n
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValuesError{Problems: problems}
+	}
+
+	version := "1.0.0"
+	if mcp, ok := configJSON["config"].(map[string]interface{}); ok {
+		if server, ok := mcp["server"].(map[string]interface{}); ok {
+			if v, ok := server["version"].(string); ok {
+				version = v
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"app":          map[string]interface{}{"name": serverName, "version": version},
+		"image":        image,
+		"replicaCount": replicas,
+		"config":       configJSON["config"],
+	}, nil
+}
+
+// toInt accepts the numeric types JSON and BSON decoding produce
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
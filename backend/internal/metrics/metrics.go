@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// API metrics, recorded once per request. route is the chi pattern, e.g. /servers/{id},
+// so ids don't blow up label cardinality.
+var (
+	Requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "backend_http_requests_total",
+		Help: "API requests by route, method and status code",
+	}, []string{"route", "method", "status"})
+
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "backend_http_request_duration_seconds",
+		Help:    "API request latency by route, method and status code",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	RequestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "backend_http_requests_in_flight",
+		Help: "API requests currently being served",
+	}, []string{"route"})
+)
+
+// HelmOperations counts release operations by action (deploy, upgrade, uninstall) and
+// outcome (success, error)
+var HelmOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "backend_helm_operations_total",
+	Help: "Helm release operations by action and outcome",
+}, []string{"action", "outcome"})
+
+// ObserveHelm records the outcome of a Helm operation
+func ObserveHelm(action string, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	HelmOperations.WithLabelValues(action, outcome).Inc()
+}
+
+// Handler serves the backend's metrics, plus Go runtime and process metrics
+func Handler() http.Handler {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		Requests,
+		RequestDuration,
+		RequestsInFlight,
+		HelmOperations,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
+
+// Middleware instruments every request served by routes. The route is resolved up front
+// so the in-flight gauge can be labelled before the handler runs.
+func Middleware(routes chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := "unmatched"
+			if rctx := chi.NewRouteContext(); routes.Match(rctx, r.Method, r.URL.Path) {
+				route = rctx.RoutePattern()
+			}
+
+			inFlight := RequestsInFlight.WithLabelValues(route)
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			labels := prometheus.Labels{"route": route, "method": r.Method, "status": strconv.Itoa(status)}
+			Requests.With(labels).Inc()
+			RequestDuration.With(labels).Observe(time.Since(start).Seconds())
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/sirupsen/logrus"
+
+	"mcp-backend/internal/api"
+	"mcp-backend/internal/config"
+	"mcp-backend/internal/helm"
+	"mcp-backend/internal/metrics"
+	"mcp-backend/internal/storage"
+)
+
+// shutdownTimeout bounds how long in-flight requests may take to finish on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// NewRouter builds the API router with its middleware stack and routes
+func NewRouter(cfg config.Config, log *logrus.Logger, mongo *storage.MongoStore, helmSvc *helm.Service) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(metrics.Middleware(r))
+	// CORS runs before auth so browser preflight requests get an answer without a token
+	if len(cfg.CORSAllowedOrigins) > 0 {
+		r.Use(api.CORSMiddleware(cfg.CORSAllowedOrigins))
+	}
+	// JWT middleware (HMAC shared secret)
+	r.Use(api.AuthMiddleware(cfg.JWTSecret))
+
+	api.AttachRoutes(r, log, mongo, helmSvc, cfg.JWTSecret)
+	return r
+}
+
+// Run connects to MongoDB, serves the API on cfg.Port and blocks until ctx is cancelled,
+// then shuts the server down gracefully
+func Run(ctx context.Context, cfg config.Config, log *logrus.Logger) error {
+	// Mongo connection
+	mongo, err := storage.NewMongoStore(ctx, cfg.MongoURI, cfg.MongoDB)
+	if err != nil {
+		log.WithError(err).Warn("mongo not available, continuing (dev mode)")
+	}
+	if mongo != nil {
+		defer mongo.Close(context.Background())
+		ictx, icancel := context.WithTimeout(ctx, 5*time.Second)
+		if err := mongo.EnsureIndexes(ictx); err != nil {
+			log.WithError(err).Warn("failed to create mongo indexes")
+		}
+		icancel()
+	}
+
+	srv := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Port),
+		Handler:      NewRouter(cfg, log, mongo, helm.NewService(cfg)),
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		log.WithField("addr", srv.Addr).Info("backend listening")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errc <- err
+		}
+		close(errc)
+	}()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Info("backend shutting down")
+	sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(sctx); err != nil {
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+	log.Info("backend stopped")
+	return nil
+}
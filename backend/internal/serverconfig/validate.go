@@ -0,0 +1,37 @@
+package serverconfig
+
+import (
+	"encoding/json"
+	"strings"
+
+	"mcp-server-template/pkg/configcheck"
+)
+
+// ValidationError lists every problem found in a config
+type ValidationError struct{ Problems []string }
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the MCP server config held under the "config" key of a server's Helm
+// values with the template's own config loader: defaults, base_url and profiles are
+// applied as the deployed server applies them. Nothing on the backend's host is
+// consulted: ${VAR} placeholders are not resolved against its environment, and TLS
+// files and exec commands are not looked up, since they only exist in the pod. The
+// server can still refuse a config for those. Includes are refused since the chart
+// mounts a single file. Problems are returned as a *ValidationError.
+func Validate(values map[string]interface{}) error {
+	raw, ok := values["config"]
+	if !ok {
+		return &ValidationError{Problems: []string{"config is required"}}
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if problems := configcheck.Check(b); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditLog records one lifecycle action taken on a server
+type AuditLog struct {
+	ID          string    `bson:"_id,omitempty" json:"id"`
+	WorkspaceID string    `bson:"workspace_id" json:"workspace_id"`
+	ServerID    string    `bson:"server_id" json:"server_id"`
+	Actor       string    `bson:"actor" json:"actor"`     // user id from the JWT subject
+	Action      string    `bson:"action" json:"action"`   // deploy|upgrade|uninstall|delete
+	Outcome     string    `bson:"outcome" json:"outcome"` // success|error
+	Error       string    `bson:"error,omitempty" json:"error,omitempty"`
+	Timestamp   time.Time `bson:"timestamp" json:"timestamp"`
+}
+
+func (m *MongoStore) AuditLogs() *mongo.Collection { return m.db.Collection("audit_logs") }
+
+// AppendAudit stores an audit entry, filling in its id and timestamp
+func (m *MongoStore) AppendAudit(ctx context.Context, e AuditLog) error {
+	e.ID = uuid.NewString()
+	e.Timestamp = time.Now().UTC()
+	_, err := m.AuditLogs().InsertOne(ctx, e)
+	return err
+}
+
+// ListAudit returns a server's audit entries, newest first. Entries outlive the server
+// so its history stays available after deletion.
+func (m *MongoStore) ListAudit(ctx context.Context, workspaceID, serverID string) ([]AuditLog, error) {
+	cur, err := m.AuditLogs().Find(ctx,
+		bson.M{"workspace_id": workspaceID, "server_id": serverID},
+		options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	out := []AuditLog{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
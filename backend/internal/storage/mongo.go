@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -27,18 +29,59 @@ func NewMongoStore(ctx context.Context, uri, db string) (*MongoStore, error) {
 
 func (m *MongoStore) Close(ctx context.Context) error { return m.client.Disconnect(ctx) }
 
+// Ping checks that the primary is reachable
+func (m *MongoStore) Ping(ctx context.Context) error { return m.client.Ping(ctx, nil) }
+
 // Models
 type ServerDef struct {
-	ID         string                 `bson:"_id,omitempty" json:"id"`
-	OwnerID    string                 `bson:"owner_id" json:"owner_id"`
-	Name       string                 `bson:"name" json:"name"`
-	ConfigJSON map[string]interface{} `bson:"config_json" json:"config_json"`
-	CreatedAt  time.Time              `bson:"created_at" json:"created_at"`
-	UpdatedAt  time.Time              `bson:"updated_at" json:"updated_at"`
+	ID          string                 `bson:"_id,omitempty" json:"id"`
+	OwnerID     string                 `bson:"owner_id" json:"owner_id"`
+	WorkspaceID string                 `bson:"workspace_id" json:"workspace_id"`
+	Namespace   string                 `bson:"namespace" json:"namespace"` // Kubernetes namespace the release is deployed to
+	Name        string                 `bson:"name" json:"name"`
+	Release     string                 `bson:"release" json:"release"` // Helm release name, fixed at creation so renames keep the release
+	ConfigJSON  map[string]interface{} `bson:"config_json" json:"config_json"`
+	CreatedAt   time.Time              `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time              `bson:"updated_at" json:"updated_at"`
 }
 
 func (m *MongoStore) Servers() *mongo.Collection { return m.db.Collection("servers") }
 
+// EnsureIndexes creates the indexes the API queries rely on. Creating an index that
+// already exists with the same keys and options is a no-op, so this runs on every start.
+func (m *MongoStore) EnsureIndexes(ctx context.Context) error {
+	indexes := []struct {
+		coll   *mongo.Collection
+		models []mongo.IndexModel
+	}{
+		{m.Users(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		}},
+		{m.Servers(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "workspace_id", Value: 1}}},
+			{Keys: bson.D{{Key: "owner_id", Value: 1}}},
+			{Keys: bson.D{{Key: "created_at", Value: -1}}},
+			// Two servers must never share a release. Servers stored before release names
+			// were recorded have none, so they are left out.
+			{Keys: bson.D{{Key: "namespace", Value: 1}, {Key: "release", Value: 1}},
+				Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"release": bson.M{"$gt": ""}})},
+		}},
+		{m.Memberships(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "workspace_id", Value: 1}}},
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		}},
+		{m.AuditLogs(), []mongo.IndexModel{
+			{Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "server_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		}},
+	}
+	for _, idx := range indexes {
+		if _, err := idx.coll.Indexes().CreateMany(ctx, idx.models); err != nil {
+			return fmt.Errorf("create indexes on %s: %w", idx.coll.Name(), err)
+		}
+	}
+	return nil
+}
+
 // Multi-tenant models
 type User struct {
 	ID        string    `bson:"_id,omitempty" json:"id"`
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpsertUserByEmail creates the user on first login and refreshes the display name on
+// later ones. The upsert keeps concurrent first logins from creating duplicates.
+func (m *MongoStore) UpsertUserByEmail(ctx context.Context, email, name string) (*User, error) {
+	now := time.Now().UTC()
+	_, err := m.Users().UpdateOne(ctx,
+		bson.M{"email": email},
+		bson.M{
+			"$set":         bson.M{"name": name},
+			"$setOnInsert": bson.M{"_id": uuid.NewString(), "created_at": now},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var u User
+	if err := m.Users().FindOne(ctx, bson.M{"email": email}).Decode(&u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// EnsureDefaultWorkspace returns the user's first membership and its workspace. Users
+// without one get a personal workspace, in their own tenant, which they own.
+func (m *MongoStore) EnsureDefaultWorkspace(ctx context.Context, u *User) (*Workspace, *Membership, error) {
+	var mem Membership
+	err := m.Memberships().FindOne(ctx, bson.M{"user_id": u.ID}, options.FindOne().SetSort(bson.M{"created_at": 1})).Decode(&mem)
+	if err == nil {
+		var ws Workspace
+		if err := m.Workspaces().FindOne(ctx, bson.M{"_id": mem.WorkspaceID}).Decode(&ws); err != nil {
+			return nil, nil, err
+		}
+		return &ws, &mem, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, err
+	}
+
+	now := time.Now().UTC()
+	name := u.Name
+	if name == "" {
+		name = u.Email
+	}
+	ws := Workspace{ID: uuid.NewString(), TenantID: u.ID, Name: name + "'s workspace", CreatedAt: now}
+	if _, err := m.Workspaces().InsertOne(ctx, ws); err != nil {
+		return nil, nil, err
+	}
+	mem = Membership{ID: uuid.NewString(), WorkspaceID: ws.ID, UserID: u.ID, Role: "owner", CreatedAt: now}
+	if _, err := m.Memberships().InsertOne(ctx, mem); err != nil {
+		return nil, nil, err
+	}
+	return &ws, &mem, nil
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+
+	"mcp-backend/internal/api"
+	"mcp-backend/internal/auth"
+)
+
+const testSecret = "test-secret"
+
+func serveWithToken(t *testing.T, token string) (*httptest.ResponseRecorder, *auth.Claims) {
+	t.Helper()
+
+	var seen *auth.Claims
+	h := api.AuthMiddleware(testSecret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = auth.ClaimsFromContext(r.Context())
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/servers", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec, seen
+}
+
+func TestAuthMiddlewareStoresClaims(t *testing.T) {
+	token, err := auth.IssueJWT(testSecret, "user-1", "tenant-1", "ws-1", "owner", time.Hour)
+	require.NoError(t, err)
+
+	rec, claims := serveWithToken(t, token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, claims)
+	require.Equal(t, "ws-1", claims.WorkspaceID)
+	require.Equal(t, "tenant-1", claims.TenantID)
+	require.Equal(t, "owner", claims.Role)
+}
+
+func TestAuthMiddlewareRejectsExpiredToken(t *testing.T) {
+	token, err := auth.IssueJWT(testSecret, "user-1", "tenant-1", "ws-1", "owner", -time.Minute)
+	require.NoError(t, err)
+
+	rec, claims := serveWithToken(t, token)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Nil(t, claims)
+}
+
+func TestAuthMiddlewareRejectsOtherAlgorithms(t *testing.T) {
+	claims := auth.Claims{
+		Sub:              "user-1",
+		WorkspaceID:      "ws-1",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+
+	rec, _ := serveWithToken(t, token)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	rec, _ = serveWithToken(t, unsigned)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"mcp-backend/internal/api"
+	"mcp-backend/internal/auth"
+)
+
+// authzRouter mounts stub handlers behind the same middleware the server routes use
+func authzRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Use(api.AuthMiddleware(testSecret))
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	r.With(api.RequireRole(api.ActionRead)).Get("/servers", ok)
+	r.With(api.RequireRole(api.ActionCreate)).Post("/servers", ok)
+	r.With(api.RequireRole(api.ActionDeploy)).Post("/servers/{id}/deploy", ok)
+	r.With(api.RequireRole(api.ActionUninstall)).Post("/servers/{id}/uninstall", ok)
+	r.With(api.RequireRole(api.ActionDelete)).Delete("/servers/{id}", ok)
+	return r
+}
+
+func TestRequireRoleEnforcesPolicy(t *testing.T) {
+	router := authzRouter()
+
+	cases := []struct {
+		role, method, path string
+		want               int
+	}{
+		{"guest", http.MethodGet, "/servers", http.StatusOK},
+		{"member", http.MethodGet, "/servers", http.StatusOK},
+		{"guest", http.MethodPost, "/servers", http.StatusForbidden},
+		{"member", http.MethodPost, "/servers", http.StatusOK},
+		{"guest", http.MethodPost, "/servers/s1/uninstall", http.StatusForbidden},
+		{"member", http.MethodPost, "/servers/s1/deploy", http.StatusForbidden},
+		{"member", http.MethodDelete, "/servers/s1", http.StatusForbidden},
+		{"admin", http.MethodPost, "/servers/s1/deploy", http.StatusOK},
+		{"owner", http.MethodPost, "/servers/s1/uninstall", http.StatusOK},
+		{"owner", http.MethodDelete, "/servers/s1", http.StatusOK},
+		{"", http.MethodGet, "/servers", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		token, err := auth.IssueJWT(testSecret, "user-1", "tenant-1", "ws-1", tc.role, time.Hour)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, tc.want, rec.Code, "%s %s as %q", tc.method, tc.path, tc.role)
+	}
+}
@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"mcp-backend/internal/config"
+	"mcp-backend/internal/helm"
+)
+
+func TestChartValuesNestsConfigAndAppliesDefaults(t *testing.T) {
+	svc := helm.NewService(config.Config{ServerImage: "mcp-server", ServerImageTag: "v1"})
+
+	configJSON := validValues()
+	configJSON["replicas"] = float64(2)
+	configJSON["image"] = map[string]interface{}{"tag": "v2"}
+
+	vals, err := svc.ChartValues("weather", configJSON)
+	require.NoError(t, err)
+	require.Equal(t, configJSON["config"], vals["config"])
+	require.Equal(t, 2, vals["replicaCount"])
+	require.Equal(t, map[string]interface{}{"repository": "mcp-server", "tag": "v2"}, vals["image"])
+	require.Equal(t, map[string]interface{}{"name": "weather", "version": "1.0.0"}, vals["app"])
+}
+
+func TestChartValuesReportsEveryProblem(t *testing.T) {
+	svc := helm.NewService(config.Config{ServerImage: "mcp-server", ServerImageTag: "latest"})
+
+	_, err := svc.ChartValues("weather", map[string]interface{}{"tools": []interface{}{}, "replicas": 0})
+
+	var valuesErr *helm.ValuesError
+	require.True(t, errors.As(err, &valuesErr))
+	require.Len(t, valuesErr.Problems, 3)
+	require.Contains(t, err.Error(), `unknown key "tools"`)
+	require.Contains(t, err.Error(), "config is required")
+	require.Contains(t, err.Error(), "replicas must be a positive integer")
+}
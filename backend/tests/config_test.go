@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mcp-backend/internal/config"
+)
+
+func TestLoadRequiresJWTSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+	_, err := config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "JWT_SECRET must be set")
+
+	t.Setenv("JWT_SECRET", "secret")
+	_, err = config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least 32 bytes")
+
+	t.Setenv("JWT_SECRET", strings.Repeat("k", config.MinJWTSecretBytes))
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("k", config.MinJWTSecretBytes), cfg.JWTSecret)
+}
+
+func TestLoadRejectsMalformedNumbers(t *testing.T) {
+	t.Setenv("JWT_SECRET", strings.Repeat("k", config.MinJWTSecretBytes))
+	t.Setenv("PORT", "60OO")
+	t.Setenv("READ_TIMEOUT", "15")
+
+	_, err := config.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `PORT: "60OO" is not an integer`)
+	assert.Contains(t, err.Error(), `READ_TIMEOUT: "15" is not a positive duration`)
+}
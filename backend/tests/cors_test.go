@@ -0,0 +1,35 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"mcp-backend/internal/api"
+)
+
+func preflight(origin string) *httptest.ResponseRecorder {
+	h := api.CORSMiddleware([]string{"https://dashboard.example.com"})(api.AuthMiddleware(testSecret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	req := httptest.NewRequest(http.MethodOptions, "/servers", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "Authorization, Content-Type")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORSPreflightFromAllowedOrigin(t *testing.T) {
+	rec := preflight("https://dashboard.example.com")
+	require.Less(t, rec.Code, 300)
+	require.Equal(t, "https://dashboard.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	require.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), http.MethodPost)
+	require.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+}
+
+func TestCORSPreflightFromOtherOrigin(t *testing.T) {
+	rec := preflight("https://evil.example.com")
+	require.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+}
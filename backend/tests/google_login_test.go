@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"mcp-backend/internal/auth"
+)
+
+// beginLogin runs the login redirect and returns the issued state and its cookie
+func beginLogin(t *testing.T, states *auth.LoginStateStore) (string, *http.Cookie) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	auth.BeginGoogleLogin(rec, httptest.NewRequest(http.MethodGet, "/auth/google/login", nil), states)
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	location, err := url.Parse(rec.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+	require.NotEmpty(t, location.Query().Get("code_challenge"))
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	return location.Query().Get("state"), cookies[0]
+}
+
+func callback(states *auth.LoginStateStore, state string, cookie *http.Cookie) error {
+	req := httptest.NewRequest(http.MethodGet, "/auth/google/callback?code=abc&state="+url.QueryEscape(state), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	_, err := auth.VerifyGoogleCallback(httptest.NewRecorder(), req, states)
+	return err
+}
+
+func TestGoogleCallbackRejectsMismatchedState(t *testing.T) {
+	states := auth.NewLoginStateStore(time.Minute, 100)
+	state, cookie := beginLogin(t, states)
+	require.NotEqual(t, "dev", state)
+
+	// A state from another browser's login must not pass with this browser's cookie
+	otherState, _ := beginLogin(t, states)
+	require.ErrorIs(t, callback(states, otherState, cookie), auth.ErrStateMismatch)
+
+	require.ErrorIs(t, callback(states, state, nil), auth.ErrMissingState)
+	require.ErrorIs(t, callback(states, "", cookie), auth.ErrMissingState)
+}
+
+func TestGoogleCallbackRejectsReplayedState(t *testing.T) {
+	states := auth.NewLoginStateStore(time.Minute, 100)
+	state, cookie := beginLogin(t, states)
+
+	require.NoError(t, callback(states, state, cookie))
+	require.ErrorIs(t, callback(states, state, cookie), auth.ErrInvalidState)
+}
+
+func TestLoginStateStoreDropsOldestWhenFull(t *testing.T) {
+	states := auth.NewLoginStateStore(time.Minute, 2)
+	first, err := states.Begin("v1")
+	require.NoError(t, err)
+	second, err := states.Begin("v2")
+	require.NoError(t, err)
+	third, err := states.Begin("v3")
+	require.NoError(t, err)
+
+	_, ok := states.Consume(first)
+	require.False(t, ok)
+	verifier, ok := states.Consume(second)
+	require.True(t, ok)
+	require.Equal(t, "v2", verifier)
+	verifier, ok = states.Consume(third)
+	require.True(t, ok)
+	require.Equal(t, "v3", verifier)
+}
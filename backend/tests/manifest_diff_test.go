@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"mcp-backend/internal/helm"
+)
+
+const deployedManifest = `---
+# Source: mcp-server/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: mcp-weather
+spec:
+  ports:
+    - port: 8080
+---
+# Source: mcp-server/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: mcp-weather-config
+data:
+  config.json: "{}"
+`
+
+const proposedManifest = `---
+# Source: mcp-server/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: mcp-weather
+spec:
+  ports:
+    - port: 9090
+---
+# Source: mcp-server/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: mcp-weather
+`
+
+func TestDiffManifestsReportsResourceChanges(t *testing.T) {
+	changes := helm.DiffManifests(deployedManifest, proposedManifest)
+	require.Len(t, changes, 3)
+
+	require.Equal(t, "ConfigMap//mcp-weather-config", changes[0].Resource)
+	require.Equal(t, "removed", changes[0].Change)
+
+	require.Equal(t, "Deployment//mcp-weather", changes[1].Resource)
+	require.Equal(t, "added", changes[1].Change)
+
+	require.Equal(t, "Service//mcp-weather", changes[2].Resource)
+	require.Equal(t, "changed", changes[2].Change)
+	require.Contains(t, changes[2].Diff, "-    - port: 8080\n+    - port: 9090\n")
+	require.NotContains(t, changes[2].Diff, "# Source")
+
+	require.Empty(t, helm.DiffManifests(deployedManifest, deployedManifest))
+}
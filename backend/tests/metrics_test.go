@@ -0,0 +1,31 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"mcp-backend/internal/metrics"
+)
+
+func TestMetricsMiddlewareLabelsByRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(metrics.Middleware(r))
+	r.Handle("/metrics", metrics.Handler())
+	r.Route("/servers", func(sr chi.Router) {
+		sr.Get("/{id}", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) })
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/servers/abc", nil))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `backend_http_requests_total{method="GET",route="/servers/{id}",status="404"} 1`)
+	require.NotContains(t, string(body), "/servers/abc")
+}
@@ -0,0 +1,19 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"mcp-backend/internal/helm"
+)
+
+func TestValidateServerName(t *testing.T) {
+	for _, name := range []string{"weather", "weather-api-2", "a", strings.Repeat("a", 49)} {
+		require.NoError(t, helm.ValidateServerName(name), name)
+	}
+	for _, name := range []string{"", "Weather", "weather_api", "-weather", "weather-", "weather.api", strings.Repeat("a", 50)} {
+		require.Error(t, helm.ValidateServerName(name), name)
+	}
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"mcp-backend/internal/serverconfig"
+)
+
+func validValues() map[string]interface{} {
+	return map[string]interface{}{
+		"config": map[string]interface{}{
+			"server": map[string]interface{}{"name": "weather", "version": "1.0.0"},
+			"tools": []interface{}{
+				map[string]interface{}{
+					"name":        "get_weather",
+					"description": "Current weather",
+					"endpoint":    "https://api.example.com/weather",
+					"method":      "GET",
+				},
+			},
+		},
+	}
+}
+
+func TestValidateAcceptsTemplateConfig(t *testing.T) {
+	require.NoError(t, serverconfig.Validate(validValues()))
+
+	// The template fills in the method and joins relative endpoints to base_url
+	values := validValues()
+	cfg := values["config"].(map[string]interface{})
+	cfg["defaults"] = map[string]interface{}{"base_url": "${WEATHER_API:-https://api.example.com}"}
+	cfg["tools"] = []interface{}{
+		map[string]interface{}{"name": "get_weather", "description": "Current weather", "endpoint": "/weather"},
+	}
+	require.NoError(t, serverconfig.Validate(values))
+}
+
+func TestValidateReportsSchemaViolations(t *testing.T) {
+	values := validValues()
+	cfg := values["config"].(map[string]interface{})
+	cfg["server"] = map[string]interface{}{"name": "weather", "version": "latest"}
+	cfg["includes"] = []interface{}{"tools/*.json"}
+
+	err := serverconfig.Validate(values)
+	var invalid *serverconfig.ValidationError
+	require.True(t, errors.As(err, &invalid))
+	require.Len(t, invalid.Problems, 2)
+	require.Contains(t, invalid.Problems[0], "Version")
+	require.Contains(t, invalid.Problems[1], "includes are not supported")
+
+	values = validValues()
+	cfg = values["config"].(map[string]interface{})
+	cfg["tools"] = append(cfg["tools"].([]interface{}), cfg["tools"].([]interface{})[0])
+	require.ErrorContains(t, serverconfig.Validate(values), "duplicate tool name: get_weather")
+
+	require.ErrorContains(t, serverconfig.Validate(map[string]interface{}{}), "config is required")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+
+	"github.com/sirupsen/logrus"
+)
+
+// exportMethods are the list calls whose results make up an export
+var exportMethods = []string{"tools/list", "prompts/list", "resources/list", "resources/templates/list"}
+
+// runExport implements the export subcommand. It answers the list methods for a config
+// without starting the server and writes the results keyed by method, exactly as a
+// client would receive them.
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	var (
+		configPath = flags.String("config", "config.json", "Path to configuration file")
+		outPath    = flags.String("out", "-", "Where to write the export, or - for stdout")
+		profile    = flags.String("profile", "", "Config profile to apply (default $MCP_PROFILE)")
+	)
+	flags.Parse(args)
+
+	// Keep stdout clean for the export itself
+	logrus.SetLevel(logrus.WarnLevel)
+
+	cfg, err := config.LoadWithOptions(*configPath, config.LoadOptions{Profile: profileOption(*profile)})
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(cfg); err != nil {
+		return err
+	}
+
+	logger := logrus.StandardLogger()
+	handler := handlers.NewJSONRPCHandler(cfg, handlers.NewToolHandler(cfg, logger), handlers.NewResourceLoader(cfg, logger), logger)
+	export := make(map[string]interface{}, len(exportMethods))
+	for i, method := range exportMethods {
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":%q}`, i+1, method)
+		resp, ok := handler.HandleMessage(context.Background(), []byte(request)).(*handlers.JSONRPCResponse)
+		if !ok {
+			return fmt.Errorf("%s: no response", method)
+		}
+		if resp.Error != nil {
+			return fmt.Errorf("%s: %s", method, resp.Error.Message)
+		}
+		export[method] = resp.Result
+	}
+
+	out, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode export: %w", err)
+	}
+	out = append(out, '\n')
+
+	if *outPath == "-" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return os.WriteFile(*outPath, out, 0o644)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/openapi"
+)
+
+// runImportOpenAPI implements the import-openapi subcommand, which scaffolds a config
+// file from an OpenAPI 3 document
+func runImportOpenAPI(args []string) error {
+	flags := flag.NewFlagSet("import-openapi", flag.ExitOnError)
+	var (
+		specPath = flags.String("spec", "", "Path to the OpenAPI 3 document (JSON or YAML)")
+		outPath  = flags.String("out", "config.json", "Where to write the generated config, or - for stdout")
+		baseURL  = flags.String("base-url", "", "API base URL, overriding the document's servers")
+	)
+	flags.Parse(args)
+	if *specPath == "" {
+		return fmt.Errorf("-spec is required")
+	}
+
+	spec, err := os.ReadFile(*specPath)
+	if err != nil {
+		return fmt.Errorf("failed to read spec: %w", err)
+	}
+	cfg, err := openapi.Import(spec, openapi.Options{BaseURL: *baseURL})
+	if err != nil {
+		return err
+	}
+
+	// Only the generated sections are written; everything else keeps its defaults
+	out, err := json.MarshalIndent(struct {
+		Server config.ServerConfig `json:"server"`
+		Tools  []config.ToolConfig `json:"tools"`
+	}{cfg.Server, cfg.Tools}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	out = append(out, '\n')
+
+	// Check the file as the server will load it
+	generated, err := config.Parse(out)
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(generated); err != nil {
+		return fmt.Errorf("generated config does not validate: %w", err)
+	}
+
+	if *outPath == "-" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	if err := os.WriteFile(*outPath, out, 0o644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d tools to %s\n", len(cfg.Tools), *outPath)
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runLint implements the lint subcommand. Validation errors always fail; warnings only
+// fail with --strict.
+func runLint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	var (
+		configPath = flags.String("config", "config.json", "Path to configuration file")
+		strict     = flags.Bool("strict", false, "Exit non-zero when there are warnings")
+		profile    = flags.String("profile", "", "Config profile to apply (default $MCP_PROFILE)")
+	)
+	flags.Parse(args)
+
+	logrus.SetLevel(logrus.WarnLevel)
+
+	cfg, err := config.LoadWithOptions(*configPath, config.LoadOptions{Profile: profileOption(*profile)})
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(cfg); err != nil {
+		return err
+	}
+
+	warnings := config.Lint(cfg)
+	for _, w := range warnings {
+		fmt.Println(w)
+	}
+	if len(warnings) == 0 {
+		fmt.Println("No problems found")
+		return nil
+	}
+	if *strict {
+		return fmt.Errorf("%d warning(s)", len(warnings))
+	}
+	return nil
+}
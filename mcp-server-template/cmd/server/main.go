@@ -3,10 +3,10 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"mcp-server-template/internal/config"
 	"mcp-server-template/internal/server"
@@ -15,16 +15,46 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// subcommands run instead of the server when named as the first argument
+var subcommands = map[string]func(args []string) error{
+	"import-openapi": runImportOpenAPI,
+	"export":         runExport,
+	"lint":           runLint,
+}
+
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	// Parse command line flags
 	var (
 		configPath = flag.String("config", "config.json", "Path to configuration file")
 		port       = flag.Int("port", 8080, "Server port")
 		logLevel   = flag.String("log-level", "info", "Log level (debug, info, warn, error)")
 		envFile    = flag.String("env", ".env", "Environment file path")
+		strictEnv  = flag.Bool("strict-env", false, "Fail when a ${VAR} placeholder in the config has no value")
+		profile    = flag.String("profile", "", "Config profile to apply (default $MCP_PROFILE)")
+		transport  = flag.String("transport", "http", "Transport to serve: http or stdio")
+		validate   = flag.Bool("validate-only", false, "Check the config, print a summary and exit without serving")
 	)
 	flag.Parse()
 
+	if *transport != "http" && *transport != "stdio" {
+		logrus.WithField("transport", *transport).Fatal("Unknown transport, expected http or stdio")
+	}
+	// Over stdio, stdout belongs to the protocol; keep even startup logs off it
+	if *transport == "stdio" {
+		logrus.SetOutput(os.Stderr)
+	}
+
 	// Setup logging
 	level, err := logrus.ParseLevel(*logLevel)
 	if err != nil {
@@ -40,8 +70,17 @@ func main() {
 		}
 	}
 
+	loadOptions := config.LoadOptions{StrictEnv: *strictEnv, Profile: profileOption(*profile)}
+	if *validate {
+		if err := validateConfig(*configPath, loadOptions); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Load configuration
-	cfg, err := config.Load(*configPath)
+	cfg, err := config.LoadWithOptions(*configPath, loadOptions)
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to load configuration")
 	}
@@ -51,6 +90,14 @@ func main() {
 		logrus.WithError(err).Fatal("Configuration validation failed")
 	}
 
+	// An explicit -log-level wins over runtime.log_level for the server's logger. This
+	// happens after validation, which only knows the config file's spelling of levels.
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "log-level" {
+			cfg.Runtime.LogLevel = level.String()
+		}
+	})
+
 	logrus.WithFields(logrus.Fields{
 		"server_name":     cfg.Server.Name,
 		"server_version":  cfg.Server.Version,
@@ -65,6 +112,13 @@ func main() {
 		logrus.WithError(err).Fatal("Failed to create MCP server")
 	}
 
+	if *transport == "stdio" {
+		if err := mcpServer.StartStdio(); err != nil {
+			logrus.WithError(err).Fatal("Stdio server failed")
+		}
+		return
+	}
+
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -73,18 +127,10 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	// Start drains running tool calls and shuts the server down once ctx is cancelled
 	go func() {
 		sig := <-sigChan
 		logrus.WithField("signal", sig).Info("Received shutdown signal")
-
-		// Give the server time to finish processing
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer shutdownCancel()
-
-		if err := mcpServer.Shutdown(shutdownCtx); err != nil {
-			logrus.WithError(err).Error("Error during server shutdown")
-		}
-
 		cancel()
 	}()
 
@@ -100,3 +146,13 @@ func main() {
 
 	logrus.Info("MCP server stopped gracefully")
 }
+
+// profileOption returns the --profile flag, or the MCP_PROFILE environment variable
+// when the flag isn't given. Only the command line falls back to the environment;
+// config.LoadWithOptions applies exactly the profile it is handed.
+func profileOption(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv(config.ProfileEnvVar)
+}
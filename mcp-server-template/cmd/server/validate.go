@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// validateConfig loads and validates a config the way the server would, prints what it
+// defines along with any lint warnings, and returns without serving. Warnings don't
+// fail the check; use the lint subcommand with --strict for that.
+func validateConfig(configPath string, opts config.LoadOptions) error {
+	logrus.SetLevel(logrus.WarnLevel)
+
+	cfg, err := config.LoadWithOptions(configPath, opts)
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: valid\n", configPath)
+	fmt.Printf("Server: %s %s\n", cfg.Server.Name, cfg.Server.Version)
+	printNames("Tools", len(cfg.Tools), func(i int) string { return cfg.Tools[i].Name })
+	printNames("Prompts", len(cfg.Prompts), func(i int) string { return cfg.Prompts[i].Name })
+	printNames("Resources", len(cfg.Resources), func(i int) string { return cfg.Resources[i].URI })
+	printNames("Resource templates", len(cfg.ResourceTemplates), func(i int) string { return cfg.ResourceTemplates[i].URITemplate })
+
+	if warnings := config.Lint(cfg); len(warnings) > 0 {
+		fmt.Printf("Warnings (%d):\n", len(warnings))
+		for _, w := range warnings {
+			fmt.Printf("  %s\n", w)
+		}
+	}
+	return nil
+}
+
+// printNames prints a summary line such as "Tools (2): get_user, list_orders"
+func printNames(kind string, count int, name func(i int) string) {
+	if count == 0 {
+		fmt.Printf("%s (0)\n", kind)
+		return
+	}
+	names := make([]string, count)
+	for i := range names {
+		names[i] = name(i)
+	}
+	fmt.Printf("%s (%d): %s\n", kind, count, strings.Join(names, ", "))
+}
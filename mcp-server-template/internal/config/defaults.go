@@ -0,0 +1,123 @@
+package config
+
+import "strings"
+
+// inheritDefaults fills the settings a file's defaults leave unset from the defaults of
+// the file that included it
+func inheritDefaults(own *DefaultsConfig, inherited *DefaultsConfig) {
+	if inherited == nil {
+		return
+	}
+	if own.BaseURL == "" {
+		own.BaseURL = inherited.BaseURL
+	}
+	own.Headers = mergeHeaders(own.Headers, inherited.Headers)
+	if own.Auth == nil {
+		own.Auth = inherited.Auth
+	}
+	if own.Timeout == nil {
+		own.Timeout = inherited.Timeout
+	}
+	if own.Retries == nil {
+		own.Retries = inherited.Retries
+	}
+}
+
+// applyToolDefaults copies defaults into every tool that doesn't set its own value. It
+// runs before the built-in tool defaults. A timeout or retry count set to zero is kept,
+// and auth of type "none" opts the tool out of the default auth.
+func applyToolDefaults(cfg *Config) {
+	defaults := &cfg.Defaults
+	for i := range cfg.Tools {
+		tool := &cfg.Tools[i]
+
+		if tool.BaseURL == "" {
+			tool.BaseURL = defaults.BaseURL
+		}
+		resolveEndpoints(tool)
+		tool.Headers = mergeHeaders(tool.Headers, defaults.Headers)
+		if tool.Auth == nil && defaults.Auth != nil {
+			auth := *defaults.Auth
+			tool.Auth = &auth
+		}
+		if tool.Auth != nil && tool.Auth.Type == "none" {
+			tool.Auth = nil
+		}
+		if tool.Timeout == nil && defaults.Timeout != nil {
+			timeout := *defaults.Timeout
+			tool.Timeout = &timeout
+		}
+		if tool.Retries == nil && defaults.Retries != nil {
+			retries := *defaults.Retries
+			tool.Retries = &retries
+		}
+	}
+}
+
+// resolveEndpoints joins a tool's relative endpoints to its base URL. Endpoints are
+// joined as text rather than resolved as URLs, so {param} placeholders and templates
+// in the path survive.
+func resolveEndpoints(tool *ToolConfig) {
+	if tool.BaseURL == "" || (tool.Protocol != "" && tool.Protocol != "http") {
+		return
+	}
+	if isRelativeEndpoint(tool.Endpoint) {
+		tool.Endpoint = joinBaseURL(tool.BaseURL, tool.Endpoint)
+	}
+	for i := range tool.Endpoints {
+		if isRelativeEndpoint(tool.Endpoints[i].URL) {
+			tool.Endpoints[i].URL = joinBaseURL(tool.BaseURL, tool.Endpoints[i].URL)
+		}
+	}
+}
+
+// isRelativeEndpoint reports whether an endpoint is a path to join to a base URL. An
+// endpoint that is entirely a template, e.g. "{{.url}}", or starts with an unresolved
+// ${VAR} placeholder is left alone.
+func isRelativeEndpoint(endpoint string) bool {
+	return endpoint != "" && !strings.Contains(endpoint, "://") &&
+		!strings.HasPrefix(endpoint, "{{") && !strings.HasPrefix(endpoint, "${")
+}
+
+func endpointURLs(endpoints []EndpointConfig) []string {
+	urls := make([]string, len(endpoints))
+	for i, ep := range endpoints {
+		urls[i] = ep.URL
+	}
+	return urls
+}
+
+func joinBaseURL(base, endpoint string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(endpoint, "/")
+}
+
+// mergeHeaders returns own plus every header in fallback whose name own doesn't already
+// set. Names are compared case-insensitively, as HTTP does.
+func mergeHeaders(own, fallback map[string]string) map[string]string {
+	if len(fallback) == 0 {
+		return own
+	}
+	merged := make(map[string]string, len(own)+len(fallback))
+	for name, value := range fallback {
+		merged[name] = value
+	}
+	for name, value := range own {
+		for existing := range merged {
+			if strings.EqualFold(existing, name) {
+				delete(merged, existing)
+			}
+		}
+		merged[name] = value
+	}
+	return merged
+}
+
+// hasHeader reports whether headers sets name, compared case-insensitively
+func hasHeader(headers map[string]string, name string) bool {
+	for existing := range headers {
+		if strings.EqualFold(existing, name) {
+			return true
+		}
+	}
+	return false
+}
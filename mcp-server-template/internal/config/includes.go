@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveIncludes merges the tools, prompts, resources and resource templates of every
+// file cfg includes, recursively, into cfg. Paths and globs are relative to the
+// including file. Only those sections are taken from included files.
+func resolveIncludes(cfg *Config, configPath string, opts LoadOptions) error {
+	root, err := filepath.Abs(configPath)
+	if err != nil {
+		return err
+	}
+	// A strict root applies to every file it pulls in
+	opts.StrictEnv = opts.StrictEnv || cfg.Runtime.StrictEnv
+	// Included files apply the profile only if they declare it
+	opts.included = true
+
+	m := &includeMerger{
+		opts:      opts,
+		loaded:    map[string]bool{root: true},
+		tools:     make(map[string]string),
+		prompts:   make(map[string]string),
+		resources: make(map[string]string),
+		templates: make(map[string]string),
+	}
+	if err := m.record(cfg, root); err != nil {
+		return err
+	}
+	return m.include(cfg, cfg, root, []string{root})
+}
+
+// includeMerger tracks which file defined each name so clashes can name both files
+type includeMerger struct {
+	opts   LoadOptions
+	loaded map[string]bool
+
+	tools, prompts, resources, templates map[string]string
+}
+
+// include loads the files from's includes list names, appending their entries to into.
+// chain is the path of includes leading to from, for cycle errors.
+func (m *includeMerger) include(from, into *Config, fromPath string, chain []string) error {
+	dir := filepath.Dir(fromPath)
+	for _, pattern := range from.Includes {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include %q in %s: %w", pattern, fromPath, err)
+		}
+		if len(paths) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			return fmt.Errorf("include %s in %s: file not found", pattern, fromPath)
+		}
+
+		for _, path := range paths {
+			for _, ancestor := range chain {
+				if ancestor == path {
+					return fmt.Errorf("include cycle: %s -> %s", strings.Join(chain, " -> "), path)
+				}
+			}
+			if m.loaded[path] {
+				continue
+			}
+			m.loaded[path] = true
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read included file: %w", err)
+			}
+			child, err := parse(data, m.opts, &from.Defaults)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if err := m.record(child, path); err != nil {
+				return err
+			}
+
+			into.Tools = append(into.Tools, child.Tools...)
+			into.Prompts = append(into.Prompts, child.Prompts...)
+			into.Resources = append(into.Resources, child.Resources...)
+			into.ResourceTemplates = append(into.ResourceTemplates, child.ResourceTemplates...)
+
+			if err := m.include(child, into, path, append(chain, path)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// record notes where each of cfg's entries came from, failing on names already taken
+func (m *includeMerger) record(cfg *Config, path string) error {
+	claim := func(kind string, owners map[string]string, name string) error {
+		if other, ok := owners[name]; ok {
+			if other == path {
+				return fmt.Errorf("duplicate %s %s in %s", kind, name, path)
+			}
+			return fmt.Errorf("duplicate %s %s: defined in %s and %s", kind, name, other, path)
+		}
+		owners[name] = path
+		return nil
+	}
+
+	for _, tool := range cfg.Tools {
+		if err := claim("tool name", m.tools, tool.Name); err != nil {
+			return err
+		}
+	}
+	for _, prompt := range cfg.Prompts {
+		if err := claim("prompt name", m.prompts, prompt.Name); err != nil {
+			return err
+		}
+	}
+	for _, resource := range cfg.Resources {
+		if err := claim("resource URI", m.resources, resource.URI); err != nil {
+			return err
+		}
+	}
+	for _, tmpl := range cfg.ResourceTemplates {
+		if err := claim("resource template", m.templates, tmpl.URITemplate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
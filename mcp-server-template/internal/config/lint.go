@@ -0,0 +1,239 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LintWarning is a likely mistake in a config that still passes Validate
+type LintWarning struct {
+	Location string `json:"location"` // e.g. "tools[get_weather].body_template"
+	Message  string `json:"message"`
+}
+
+func (w LintWarning) String() string {
+	return w.Location + ": " + w.Message
+}
+
+var (
+	templateActionPattern = regexp.MustCompile(`\{\{-?(.*?)-?\}\}`)
+	// fieldRefPattern matches .name at the start of an argument, and index . "name"
+	fieldRefPattern = regexp.MustCompile(`(?:^|[\s(|])\.([A-Za-z_][A-Za-z0-9_]*)|index\s+\.\s+"([^"]+)"`)
+	// wholeDotPattern matches a bare . passed to a function, as in {{toJson .}}
+	wholeDotPattern = regexp.MustCompile(`(?:^|[\s(|])\.(?:$|[\s)|])`)
+	// promptPlaceholderPattern matches the {name} placeholders filled by prompts/get
+	promptPlaceholderPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// Lint reports problems Validate lets through: templates that reference undeclared
+// parameters, parameters a tool never sends, prompt placeholders without an argument,
+// hosts reached over both http and https, and credentials read from unset environment
+// variables. It expects a config that has already passed Validate.
+func Lint(cfg *Config) []LintWarning {
+	var warnings []LintWarning
+	warn := func(location, format string, args ...interface{}) {
+		warnings = append(warnings, LintWarning{Location: location, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, tool := range cfg.Tools {
+		loc := fmt.Sprintf("tools[%s]", tool.Name)
+		declared := make(map[string]bool, len(tool.Parameters))
+		for _, p := range tool.Parameters {
+			declared[p.Name] = true
+		}
+
+		templates := toolTemplates(&tool)
+		used := make(map[string]bool)
+		usesAll := false
+		for _, field := range sortedKeys(templates) {
+			refs, whole := templateRefs(templates[field])
+			usesAll = usesAll || whole
+			for _, ref := range refs {
+				used[ref] = true
+				// .ctx holds values saved by save_as, not a parameter
+				if !declared[ref] && ref != "ctx" {
+					warn(loc+"."+field, "references undeclared parameter %q", ref)
+				}
+			}
+		}
+
+		if !usesAll && sendsOnlyReferencedParams(&tool) {
+			if tool.Kind == "graphql" && tool.GraphQL != nil {
+				for _, param := range tool.GraphQL.Variables {
+					used[param] = true
+				}
+			}
+			for _, p := range tool.Parameters {
+				placed := p.In == "path" || p.In == "query" || p.In == "header"
+				if !used[p.Name] && !placed {
+					warn(loc, "parameter %q is declared but never sent", p.Name)
+				}
+			}
+		}
+
+		if tool.Auth != nil && tool.Auth.EnvVar != "" && os.Getenv(tool.Auth.EnvVar) == "" {
+			warn(loc+".auth", "environment variable %s is not set", tool.Auth.EnvVar)
+		}
+		if oauth := tool.UpstreamOAuth; oauth != nil {
+			for _, name := range []string{oauth.ClientIDEnv, oauth.ClientSecretEnv} {
+				if name != "" && os.Getenv(name) == "" {
+					warn(loc+".upstream_oauth", "environment variable %s is not set", name)
+				}
+			}
+		}
+	}
+
+	for _, host := range mixedSchemeHosts(cfg.Tools) {
+		warn("tools", "host %s is called over both http and https", host)
+	}
+
+	for _, prompt := range cfg.Prompts {
+		args := make(map[string]bool, len(prompt.Arguments))
+		for _, arg := range prompt.Arguments {
+			args[arg.Name] = true
+		}
+		texts := map[string]string{"content": prompt.Content}
+		for i, message := range prompt.Messages {
+			texts[fmt.Sprintf("messages[%d].content", i)] = message.Content
+		}
+		for _, field := range sortedKeys(texts) {
+			loc := fmt.Sprintf("prompts[%s].%s", prompt.Name, field)
+			for _, name := range promptRefs(texts[field]) {
+				if args[name] {
+					continue
+				}
+				if strings.Contains(texts[field], "{{") {
+					warn(loc, "references undeclared argument %q", name)
+				} else {
+					warn(loc, "placeholder {%s} has no matching argument", name)
+				}
+			}
+		}
+	}
+
+	return warnings
+}
+
+// toolTemplates returns the tool's templated fields keyed by their config path
+func toolTemplates(tool *ToolConfig) map[string]string {
+	templates := map[string]string{"endpoint": tool.Endpoint}
+	if tool.BodyTemplate != "" {
+		templates["body_template"] = tool.BodyTemplate
+	}
+	for key, value := range tool.QueryParams {
+		templates["query_params."+key] = value
+	}
+	for key, value := range tool.Headers {
+		templates["headers."+key] = value
+	}
+	if tool.Exec != nil {
+		for i, arg := range tool.Exec.Args {
+			templates[fmt.Sprintf("exec.args[%d]", i)] = arg
+		}
+	}
+	return templates
+}
+
+// sendsOnlyReferencedParams reports whether a tool passes on just the parameters its
+// templates name. GET requests add every argument to the query, and JSON and gRPC
+// requests without a body template send them all, so nothing can go unused there.
+func sendsOnlyReferencedParams(tool *ToolConfig) bool {
+	switch {
+	case tool.Protocol == "exec":
+		return true
+	case tool.Protocol == "grpc", tool.BodyType == "multipart":
+		return false
+	case tool.Kind == "graphql":
+		return tool.GraphQL != nil && len(tool.GraphQL.Variables) > 0
+	case strings.EqualFold(tool.Method, "GET"):
+		return false
+	}
+	return tool.BodyTemplate != ""
+}
+
+// templateRefs lists the parameters a template reads, and whether it also passes the
+// whole argument map somewhere
+func templateRefs(tmpl string) ([]string, bool) {
+	var refs []string
+	whole := false
+	for _, action := range templateActionPattern.FindAllStringSubmatch(tmpl, -1) {
+		body := action[1]
+		if wholeDotPattern.MatchString(body) && !strings.Contains(body, "index .") {
+			whole = true
+		}
+		for _, match := range fieldRefPattern.FindAllStringSubmatch(body, -1) {
+			if match[1] != "" {
+				refs = append(refs, match[1])
+			} else {
+				refs = append(refs, match[2])
+			}
+		}
+	}
+	return refs, whole
+}
+
+// promptRefs lists, once each, the arguments prompt text reads: template fields when
+// the text contains {{, and {name} placeholders otherwise
+func promptRefs(text string) []string {
+	var refs []string
+	if strings.Contains(text, "{{") {
+		refs, _ = templateRefs(text)
+	} else {
+		for _, match := range promptPlaceholderPattern.FindAllStringSubmatch(text, -1) {
+			refs = append(refs, match[1])
+		}
+	}
+
+	seen := make(map[string]bool, len(refs))
+	unique := refs[:0]
+	for _, ref := range refs {
+		if !seen[ref] {
+			seen[ref] = true
+			unique = append(unique, ref)
+		}
+	}
+	return unique
+}
+
+// mixedSchemeHosts returns hosts that tools reach over both http and https
+func mixedSchemeHosts(tools []ToolConfig) []string {
+	schemes := make(map[string]map[string]bool)
+	add := func(rawURL string) {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" || strings.Contains(u.Host, "{{") {
+			return
+		}
+		if schemes[u.Host] == nil {
+			schemes[u.Host] = make(map[string]bool)
+		}
+		schemes[u.Host][u.Scheme] = true
+	}
+	for _, tool := range tools {
+		add(tool.Endpoint)
+		for _, ep := range tool.Endpoints {
+			add(ep.URL)
+		}
+	}
+
+	var mixed []string
+	for host, seen := range schemes {
+		if seen["http"] && seen["https"] {
+			mixed = append(mixed, host)
+		}
+	}
+	sort.Strings(mixed)
+	return mixed
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
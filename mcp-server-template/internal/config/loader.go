@@ -3,17 +3,25 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/itchyny/gojq"
 	"github.com/sirupsen/logrus"
 )
 
 var validate *validator.Validate
 
+// TemplateVariablePattern matches {name} placeholders in resource URI templates
+var TemplateVariablePattern = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
 func init() {
 	validate = validator.New()
 
@@ -21,18 +29,87 @@ func init() {
 	validate.RegisterValidation("semver", validateSemVer)
 }
 
+// LoadOptions adjust how a configuration file is read
+type LoadOptions struct {
+	// StrictEnv makes unresolved ${VAR} placeholders an error. runtime.strict_env in
+	// the file turns it on as well.
+	StrictEnv bool
+	// Profile names the entry of the file's profiles merged over the rest of it. When
+	// empty, no profile is applied; the command line resolves MCP_PROFILE itself.
+	Profile string
+	// IgnoreEnv leaves ${VAR} placeholders as written, using only their :- defaults,
+	// for checking a config away from the environment it will run in
+	IgnoreEnv bool
+
+	// included is set for files pulled in by includes, which may leave the profile out
+	included bool
+}
+
 // Load reads and parses a configuration file
 func Load(configPath string) (*Config, error) {
+	return LoadWithOptions(configPath, LoadOptions{})
+}
+
+// LoadWithOptions reads and parses a configuration file
+func LoadWithOptions(configPath string, opts LoadOptions) (*Config, error) {
 	logrus.WithField("config_path", configPath).Debug("Loading configuration")
 
+	if opts.Profile != "" {
+		logrus.WithFields(logrus.Fields{
+			"config_path": configPath,
+			"profile":     opts.Profile,
+		}).Info("Using configuration profile")
+	}
+
 	// Read configuration file
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	cfg, err := parse(data, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveIncludes(cfg, configPath, opts); err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"server_name":     cfg.Server.Name,
+		"tools_count":     len(cfg.Tools),
+		"prompts_count":   len(cfg.Prompts),
+		"resources_count": len(cfg.Resources),
+	}).Info("Configuration loaded successfully")
+
+	return cfg, nil
+}
+
+// Parse decodes a JSON configuration, substituting environment variables and filling
+// in defaults the same way Load does. Includes are not resolved, since they are
+// relative to a file.
+func Parse(data []byte) (*Config, error) {
+	return parse(data, LoadOptions{}, nil)
+}
+
+// ParseWithOptions decodes a JSON configuration like Parse, reading it as opts say
+func ParseWithOptions(data []byte, opts LoadOptions) (*Config, error) {
+	return parse(data, opts, nil)
+}
+
+// parse decodes one config file. inherited holds the defaults of the file that included
+// it, if any.
+func parse(data []byte, opts LoadOptions, inherited *DefaultsConfig) (*Config, error) {
+	data, err := applyProfile(data, opts.Profile, opts.included)
+	if err != nil {
+		return nil, err
+	}
+
 	// Perform environment variable substitution
-	configContent := substituteEnvVars(string(data))
+	configContent, unresolved, err := substituteEnvVars(string(data), opts.IgnoreEnv)
+	if err != nil {
+		return nil, err
+	}
 
 	// Parse JSON configuration
 	var cfg Config
@@ -40,21 +117,36 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config JSON: %w", err)
 	}
 
+	// Placeholders are left in place, so the strict setting can come from the file itself
+	if len(unresolved) > 0 && (opts.StrictEnv || cfg.Runtime.StrictEnv) {
+		return nil, fmt.Errorf("unresolved environment variables: %s", strings.Join(unresolved, ", "))
+	}
+
+	// Shared tool settings first, so built-in defaults only fill what neither sets
+	inheritDefaults(&cfg.Defaults, inherited)
+	applyToolDefaults(&cfg)
+
 	// Set default values
 	setDefaults(&cfg)
 
-	logrus.WithFields(logrus.Fields{
-		"server_name":     cfg.Server.Name,
-		"tools_count":     len(cfg.Tools),
-		"prompts_count":   len(cfg.Prompts),
-		"resources_count": len(cfg.Resources),
-	}).Info("Configuration loaded successfully")
-
 	return &cfg, nil
 }
 
+// ValidateOptions adjust how a configuration is validated
+type ValidateOptions struct {
+	// SkipHostChecks leaves out the checks that look at this machine: that TLS files
+	// exist and that exec commands resolve to an allowlisted binary on PATH. For checking
+	// a config away from the host it will run on.
+	SkipHostChecks bool
+}
+
 // Validate validates the configuration using struct tags and business logic
 func Validate(cfg *Config) error {
+	return ValidateWithOptions(cfg, ValidateOptions{})
+}
+
+// ValidateWithOptions validates the configuration like Validate, as opts say
+func ValidateWithOptions(cfg *Config, opts ValidateOptions) error {
 	logrus.Debug("Validating configuration")
 
 	// Struct validation using tags
@@ -63,7 +155,7 @@ func Validate(cfg *Config) error {
 	}
 
 	// Business logic validation
-	if err := validateBusinessRules(cfg); err != nil {
+	if err := validateBusinessRules(cfg, opts); err != nil {
 		return fmt.Errorf("business rule validation failed: %w", err)
 	}
 
@@ -71,18 +163,53 @@ func Validate(cfg *Config) error {
 	return nil
 }
 
-// substituteEnvVars replaces ${VAR_NAME} patterns with environment variable values
-func substituteEnvVars(content string) string {
-	envVarRegex := regexp.MustCompile(`\${([^}]+)}`)
-
-	return envVarRegex.ReplaceAllStringFunc(content, func(match string) string {
-		// Extract variable name (remove ${ and })
-		varName := match[2 : len(match)-1]
+// envVarRegex matches ${VAR}, ${VAR:-default} and ${VAR:?message} placeholders
+var envVarRegex = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// substituteEnvVars replaces ${VAR_NAME} patterns with environment variable values,
+// following the shell's rules for the forms with an operator:
+//
+//	${VAR:-default}  default when VAR is unset or empty
+//	${VAR:?message}  an error carrying message when VAR is unset or empty
+//
+// Plain placeholders with no value are kept as they are and their names returned, each
+// once, in order. With ignoreEnv every variable counts as unset, and placeholders
+// without a default are kept without being reported.
+func substituteEnvVars(content string, ignoreEnv bool) (string, []string, error) {
+	var unresolved, missing []string
+	seen := make(map[string]bool)
+
+	substituted := envVarRegex.ReplaceAllStringFunc(content, func(match string) string {
+		// Split "NAME:-word" or "NAME:?word" (remove ${ and })
+		varName, operator, word := match[2:len(match)-1], "", ""
+		if i := strings.Index(varName, ":"); i >= 0 && i+1 < len(varName) && strings.ContainsRune("-?", rune(varName[i+1])) {
+			varName, operator, word = varName[:i], varName[i:i+2], varName[i+2:]
+		}
 
 		// Look up environment variable
-		value := os.Getenv(varName)
+		var value string
+		if !ignoreEnv {
+			value = os.Getenv(varName)
+		}
 		if value == "" {
-			logrus.WithField("var_name", varName).Warn("Environment variable not found, keeping placeholder")
+			switch {
+			case operator == ":-":
+				logrus.WithField("var_name", varName).Debug("Environment variable not set, using default")
+				return word
+			case ignoreEnv:
+				return match
+			case operator == ":?":
+				if word == "" {
+					word = "must be set"
+				}
+				missing = append(missing, varName+": "+word)
+				return match
+			}
+			if !seen[varName] {
+				seen[varName] = true
+				unresolved = append(unresolved, varName)
+				logrus.WithField("var_name", varName).Warn("Environment variable not found, keeping placeholder")
+			}
 			return match
 		}
 
@@ -93,6 +220,11 @@ func substituteEnvVars(content string) string {
 
 		return value
 	})
+
+	if len(missing) > 0 {
+		return "", nil, fmt.Errorf("required environment variables not set: %s", strings.Join(missing, "; "))
+	}
+	return substituted, unresolved, nil
 }
 
 // setDefaults sets default values for optional configuration fields
@@ -110,24 +242,46 @@ func setDefaults(cfg *Config) {
 			tool.Method = "GET"
 		}
 
+		// The first balanced endpoint doubles as the tool's primary endpoint
+		if tool.Endpoint == "" && len(tool.Endpoints) > 0 {
+			tool.Endpoint = tool.Endpoints[0].URL
+		}
+		for j := range tool.Endpoints {
+			if tool.Endpoints[j].Weight == 0 {
+				tool.Endpoints[j].Weight = 1
+			}
+		}
+
 		if tool.ContentType == "" && (tool.Method == "POST" || tool.Method == "PUT" || tool.Method == "PATCH") {
 			tool.ContentType = "application/json"
 		}
 
-		if tool.Timeout == 0 {
-			tool.Timeout = Duration(30 * time.Second)
+		if tool.Timeout == nil {
+			timeout := Duration(30 * time.Second)
+			tool.Timeout = &timeout
+		}
+
+		if tool.Retries == nil {
+			retries := 3
+			tool.Retries = &retries
 		}
 
-		if tool.Retries == 0 {
-			tool.Retries = 3
+		if tool.Pagination != nil && tool.Pagination.MaxPages == 0 {
+			tool.Pagination.MaxPages = 10
+		}
+
+		if cb := tool.CircuitBreaker; cb != nil {
+			if cb.FailureThreshold == 0 {
+				cb.FailureThreshold = 5
+			}
+			if cb.Cooldown == 0 {
+				cb.Cooldown = Duration(30 * time.Second)
+			}
 		}
 
 		// Set default parameter types
 		for j := range tool.Parameters {
-			param := &tool.Parameters[j]
-			if param.Type == "" {
-				param.Type = "string"
-			}
+			setParameterDefaults(&tool.Parameters[j])
 		}
 	}
 
@@ -156,10 +310,49 @@ func setDefaults(cfg *Config) {
 	if cfg.Runtime.Environment == "" {
 		cfg.Runtime.Environment = "development"
 	}
+
+	if cfg.Runtime.RequestIDHeader == "" {
+		cfg.Runtime.RequestIDHeader = "X-Request-ID"
+	}
+
+	if cfg.Runtime.UserAgent == "" {
+		// Product tokens can't contain spaces
+		cfg.Runtime.UserAgent = strings.ReplaceAll(cfg.Server.Name, " ", "-") + "/" + cfg.Server.Version
+	}
+
+	if cfg.Runtime.MaxRequestBytes == 0 {
+		cfg.Runtime.MaxRequestBytes = 4 << 20
+	}
+
+	if cfg.Runtime.MaxResourceSize == 0 {
+		cfg.Runtime.MaxResourceSize = 10 << 20
+	}
+
+	if cfg.Runtime.MaxResponseBytes == 0 {
+		cfg.Runtime.MaxResponseBytes = 10 << 20
+	}
+
+	if cfg.Runtime.ErrorBodyLimit == 0 {
+		cfg.Runtime.ErrorBodyLimit = 1000
+	}
+
+	httpClient := &cfg.Runtime.HTTPClient
+	if httpClient.Timeout == 0 {
+		httpClient.Timeout = Duration(30 * time.Second)
+	}
+	if httpClient.MaxIdleConns == 0 {
+		httpClient.MaxIdleConns = 100
+	}
+	if httpClient.MaxIdleConnsPerHost == 0 {
+		httpClient.MaxIdleConnsPerHost = 2
+	}
+	if httpClient.IdleConnTimeout == 0 {
+		httpClient.IdleConnTimeout = Duration(90 * time.Second)
+	}
 }
 
 // validateBusinessRules performs business logic validation
-func validateBusinessRules(cfg *Config) error {
+func validateBusinessRules(cfg *Config, opts ValidateOptions) error {
 	// Validate unique tool names
 	toolNames := make(map[string]bool)
 	for _, tool := range cfg.Tools {
@@ -176,6 +369,24 @@ func validateBusinessRules(cfg *Config) error {
 			return fmt.Errorf("duplicate prompt name: %s", prompt.Name)
 		}
 		promptNames[prompt.Name] = true
+
+		contentSources := 0
+		for _, source := range []string{prompt.Content, prompt.FilePath, prompt.URL} {
+			if source != "" {
+				contentSources++
+			}
+		}
+		if len(prompt.Messages) > 0 {
+			contentSources++
+		}
+		if contentSources != 1 {
+			return fmt.Errorf("prompt %s must have exactly one content source (content, file_path, url, or messages)", prompt.Name)
+		}
+		for i, message := range prompt.Messages {
+			if (message.Content == "") == (message.Resource == "") {
+				return fmt.Errorf("prompt %s message %d must have exactly one of content or resource", prompt.Name, i)
+			}
+		}
 	}
 
 	// Validate unique resource URIs
@@ -207,6 +418,32 @@ func validateBusinessRules(cfg *Config) error {
 		}
 	}
 
+	// Validate resource templates
+	templateURIs := make(map[string]bool)
+	for _, tmpl := range cfg.ResourceTemplates {
+		if templateURIs[tmpl.URITemplate] {
+			return fmt.Errorf("duplicate resource template: %s", tmpl.URITemplate)
+		}
+		templateURIs[tmpl.URITemplate] = true
+
+		if (tmpl.FilePath == "") == (tmpl.URL == "") {
+			return fmt.Errorf("resource template %s must have exactly one content source (file_path or url)", tmpl.URITemplate)
+		}
+
+		variables := make(map[string]bool)
+		for _, match := range TemplateVariablePattern.FindAllStringSubmatch(tmpl.URITemplate, -1) {
+			variables[match[1]] = true
+		}
+		if len(variables) == 0 {
+			return fmt.Errorf("resource template %s has no {variables}; declare it as a resource instead", tmpl.URITemplate)
+		}
+		for _, match := range TemplateVariablePattern.FindAllStringSubmatch(tmpl.FilePath+tmpl.URL, -1) {
+			if !variables[match[1]] {
+				return fmt.Errorf("resource template %s references unknown variable {%s}", tmpl.URITemplate, match[1])
+			}
+		}
+	}
+
 	// Validate tool authentication
 	for _, tool := range cfg.Tools {
 		if tool.Auth != nil {
@@ -214,6 +451,277 @@ func validateBusinessRules(cfg *Config) error {
 				return fmt.Errorf("invalid auth config for tool %s: %w", tool.Name, err)
 			}
 		}
+		if tool.TLS != nil {
+			if err := validateUpstreamTLS(tool.TLS, opts); err != nil {
+				return fmt.Errorf("invalid tls config for tool %s: %w", tool.Name, err)
+			}
+		}
+		if tool.Protocol == "exec" && tool.Exec != nil && !opts.SkipHostChecks {
+			if !ExecAllowed(tool.Exec.Command, cfg.Security.ExecAllowlist) {
+				return fmt.Errorf("command %s for tool %s is not in security.exec_allowlist", tool.Exec.Command, tool.Name)
+			}
+		}
+		if tool.Kind == "graphql" && tool.Method != "POST" {
+			return fmt.Errorf("graphql tool %s must use method POST", tool.Name)
+		}
+		if tool.Protocol == "" || tool.Protocol == "http" {
+			for _, endpoint := range append([]string{tool.Endpoint}, endpointURLs(tool.Endpoints)...) {
+				if isRelativeEndpoint(endpoint) {
+					return fmt.Errorf("endpoint %s of tool %s is relative, but no base_url is set for it", endpoint, tool.Name)
+				}
+			}
+		}
+		if tool.BodyType == "multipart" && hasHeader(tool.Headers, "Content-Type") {
+			return fmt.Errorf("multipart tool %s cannot set a Content-Type header, which must carry the generated boundary", tool.Name)
+		}
+		if p := tool.Pagination; p != nil && p.NextPath == "" && !p.UseLinkHeader {
+			return fmt.Errorf("pagination for tool %s needs next_path or use_link_header", tool.Name)
+		}
+		if tool.Streaming && (tool.Pagination != nil || tool.ResponsePath != "" || tool.Transform != "") {
+			return fmt.Errorf("streaming tool %s cannot use pagination, response_path or transform, which need the parsed body", tool.Name)
+		}
+		if err := validateProxyURL(tool.ProxyURL); err != nil {
+			return fmt.Errorf("invalid proxy_url for tool %s: %w", tool.Name, err)
+		}
+		if tool.Transform != "" {
+			if err := validateTransform(tool.Transform); err != nil {
+				return fmt.Errorf("invalid transform for tool %s: %w", tool.Name, err)
+			}
+		}
+		for _, param := range tool.Parameters {
+			if param.Name == "ctx" {
+				return fmt.Errorf("tool %s has a parameter named ctx, which templates reserve for saved values", tool.Name)
+			}
+			if err := validateParameterConstraints(&param); err != nil {
+				return fmt.Errorf("invalid validation for parameter %s of tool %s: %w", param.Name, tool.Name, err)
+			}
+			if param.In == "path" && !endpointHasPathParam(&tool, param.Name) {
+				return fmt.Errorf("path parameter %s of tool %s has no {%s} segment in the endpoint", param.Name, tool.Name, param.Name)
+			}
+		}
+	}
+
+	// Turning auth on without a key would leave the endpoints open
+	if cfg.Security.EnableAuth && len(cfg.Security.APIKeys) == 0 {
+		return fmt.Errorf("security.enable_auth is set but security.api_keys is empty")
+	}
+	// The OAuth layer does not verify tokens yet, so any bearer value would get past the keys
+	if cfg.Security.EnableAuth && cfg.Security.OAuth.Enabled {
+		return fmt.Errorf("security.enable_auth cannot be combined with security.oauth.enabled")
+	}
+	for i, key := range cfg.Security.APIKeys {
+		if key == "" {
+			return fmt.Errorf("security.api_keys[%d] is empty", i)
+		}
+	}
+	for i, key := range cfg.Security.AdminKeys {
+		if key == "" {
+			return fmt.Errorf("security.admin_keys[%d] is empty", i)
+		}
+	}
+
+	if err := validateProxyURL(cfg.Runtime.ProxyURL); err != nil {
+		return fmt.Errorf("invalid runtime.proxy_url: %w", err)
+	}
+
+	if err := validateUpstreamTLS(&cfg.Security.UpstreamTLS, opts); err != nil {
+		return fmt.Errorf("invalid security.upstream_tls: %w", err)
+	}
+
+	for _, proxy := range cfg.Security.TrustedProxies {
+		if _, err := ParseProxyNetwork(proxy); err != nil {
+			return fmt.Errorf("invalid security.trusted_proxies entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ParseProxyNetwork parses a trusted_proxies entry, either an IP address or a CIDR
+func ParseProxyNetwork(entry string) (*net.IPNet, error) {
+	if _, network, err := net.ParseCIDR(entry); err == nil {
+		return network, nil
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("%q is neither an IP address nor a CIDR", entry)
+	}
+	bits := 8 * len(ip.To4())
+	if bits == 0 {
+		bits = 8 * net.IPv6len
+	} else {
+		ip = ip.To4()
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// validateTransform checks that a jq program parses and refers only to known functions
+func validateTransform(program string) error {
+	query, err := gojq.Parse(program)
+	if err != nil {
+		return err
+	}
+	_, err = gojq.Compile(query)
+	return err
+}
+
+// setParameterDefaults defaults the type of a parameter and of any nested fields and
+// items to string
+func setParameterDefaults(param *ParameterConfig) {
+	if param.Type == "" {
+		param.Type = "string"
+	}
+	for name, field := range param.Properties {
+		setParameterDefaults(&field)
+		param.Properties[name] = field
+	}
+	if param.Items != nil {
+		setParameterDefaults(param.Items)
+	}
+}
+
+// validateParameterConstraints checks that value constraints and nested schemas fit the
+// parameter's type
+func validateParameterConstraints(param *ParameterConfig) error {
+	if len(param.Properties) > 0 && param.Type != "object" {
+		return fmt.Errorf("properties apply to object parameters only")
+	}
+	if param.Items != nil && param.Type != "array" {
+		return fmt.Errorf("items apply to array parameters only")
+	}
+	if param.Style != "" && param.Type != "array" && param.Type != "object" {
+		return fmt.Errorf("style applies to array and object parameters only")
+	}
+	for name, field := range param.Properties {
+		if err := validateNestedParameter(&field); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	if param.Items != nil {
+		if err := validateNestedParameter(param.Items); err != nil {
+			return fmt.Errorf("items: %w", err)
+		}
+	}
+
+	v := param.Validation
+	if v == nil {
+		return nil
+	}
+	if len(v.Enum) > 0 && param.Type != "string" {
+		return fmt.Errorf("enum applies to string parameters; use number_enum or const for %s", param.Type)
+	}
+	if len(v.NumberEnum) > 0 && param.Type != "number" {
+		return fmt.Errorf("number_enum applies to number parameters only")
+	}
+	if v.Const != nil {
+		var ok bool
+		switch param.Type {
+		case "string":
+			_, ok = v.Const.(string)
+		case "number":
+			_, ok = v.Const.(float64)
+		case "boolean":
+			_, ok = v.Const.(bool)
+		}
+		if !ok {
+			return fmt.Errorf("const %v does not match parameter type %s", v.Const, param.Type)
+		}
+	}
+	return nil
+}
+
+// validateNestedParameter checks the type and constraints of an object field or array
+// item schema
+func validateNestedParameter(param *ParameterConfig) error {
+	switch param.Type {
+	case "string", "number", "boolean", "object", "array":
+	default:
+		return fmt.Errorf("unknown type %q", param.Type)
+	}
+	if param.In != "" {
+		return fmt.Errorf("in applies to top-level parameters only")
+	}
+	return validateParameterConstraints(param)
+}
+
+// endpointHasPathParam reports whether every endpoint of a tool has a {name} segment
+func endpointHasPathParam(tool *ToolConfig, name string) bool {
+	placeholder := "{" + name + "}"
+	if len(tool.Endpoints) == 0 {
+		return strings.Contains(tool.Endpoint, placeholder)
+	}
+	for _, ep := range tool.Endpoints {
+		if !strings.Contains(ep.URL, placeholder) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExecAllowed reports whether command is on the allowlist. Entries match by exact name
+// or by the binary both resolve to, so "kubectl" and "/usr/local/bin/kubectl" are the
+// same entry when PATH finds that file.
+func ExecAllowed(command string, allowlist []string) bool {
+	resolved, err := exec.LookPath(command)
+	if err == nil {
+		resolved, _ = filepath.Abs(resolved)
+	}
+
+	for _, entry := range allowlist {
+		if entry == command {
+			return true
+		}
+		if err != nil {
+			continue
+		}
+		if allowed, lookErr := exec.LookPath(entry); lookErr == nil {
+			if allowed, _ = filepath.Abs(allowed); allowed == resolved {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateProxyURL accepts an empty value, "none", or an http(s)/socks5 proxy URL
+func validateProxyURL(rawURL string) error {
+	if rawURL == "" || rawURL == "none" {
+		return nil
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("proxy URL %q has no host", rawURL)
+	}
+	return nil
+}
+
+// validateUpstreamTLS checks that client certificates come with their key and, unless
+// host checks are skipped, that referenced certificate files exist
+func validateUpstreamTLS(tlsCfg *UpstreamTLSConfig, opts ValidateOptions) error {
+	if (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+		return fmt.Errorf("cert_file and key_file must be set together")
+	}
+
+	for _, path := range []string{tlsCfg.CAFile, tlsCfg.CertFile, tlsCfg.KeyFile} {
+		if path == "" || opts.SkipHostChecks {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("cannot read %s: %w", path, err)
+		}
+	}
+
+	if tlsCfg.InsecureSkipVerify {
+		logrus.Warn("TLS certificate verification is disabled for upstream calls; use only for development")
 	}
 
 	return nil
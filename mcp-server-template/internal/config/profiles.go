@@ -0,0 +1,96 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ProfileEnvVar selects a profile when the command line doesn't name one
+const ProfileEnvVar = "MCP_PROFILE"
+
+// applyProfile merges the named profile from a config file's profiles over the rest of
+// the file and drops the profiles section, so placeholders in profiles that weren't
+// selected are never substituted. It runs before ${VAR} substitution, which means a
+// file with profiles has to be valid JSON as written.
+//
+// An unknown profile is an error unless optional is set, as it is for included files,
+// which only apply the profiles they declare.
+func applyProfile(data []byte, profile string, optional bool) ([]byte, error) {
+	doc, err := decodeDocument(data)
+	if err != nil {
+		if profile == "" || optional {
+			// Leave placeholder-dependent files to the regular parse and its errors
+			return data, nil
+		}
+		return nil, fmt.Errorf("config with profiles must be valid JSON before ${VAR} substitution: %w", err)
+	}
+
+	profiles, _ := doc["profiles"].(map[string]interface{})
+	if profiles == nil && profile == "" {
+		return data, nil
+	}
+	delete(doc, "profiles")
+
+	if profile != "" {
+		patch, ok := profiles[profile]
+		switch {
+		case ok:
+			doc = mergePatch(doc, patch).(map[string]interface{})
+		case !optional:
+			return nil, fmt.Errorf("unknown profile %q, expected one of: %s", profile, profileNames(profiles))
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// decodeDocument parses a whole config file, keeping numbers as json.Number so large
+// integers survive being marshalled again unchanged
+func decodeDocument(data []byte) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the top-level value")
+	}
+	return doc, nil
+}
+
+// mergePatch applies patch to target as a JSON merge patch (RFC 7386): objects merge
+// key by key, null removes a key, and any other value, arrays included, replaces it
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{}, len(patchObject))
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+func profileNames(profiles map[string]interface{}) string {
+	if len(profiles) == 0 {
+		return "(none defined)"
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
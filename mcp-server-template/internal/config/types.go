@@ -8,12 +8,20 @@ import (
 
 // Config represents the complete configuration for an MCP server instance
 type Config struct {
-	Server    ServerConfig     `json:"server" validate:"required"`
+	Server ServerConfig `json:"server" validate:"required"`
+	// Defaults fill in headers, auth, timeout and retries for every tool in this file
+	// and the files it includes, unless a tool sets its own
+	Defaults  DefaultsConfig   `json:"defaults"`
 	Tools     []ToolConfig     `json:"tools"`
 	Prompts   []PromptConfig   `json:"prompts"`
 	Resources []ResourceConfig `json:"resources"`
-	Security  SecurityConfig   `json:"security"`
-	Runtime   RuntimeConfig    `json:"runtime"`
+	// ResourceTemplates serve families of resources from one entry, e.g. file:///logs/{date}.log
+	ResourceTemplates []ResourceTemplateConfig `json:"resource_templates"`
+	Security          SecurityConfig           `json:"security"`
+	Runtime           RuntimeConfig            `json:"runtime"`
+	// Includes are files or globs, relative to this file, whose tools, prompts,
+	// resources and resource templates are added to this config
+	Includes []string `json:"includes,omitempty"`
 }
 
 // ServerConfig defines the basic server metadata and configuration
@@ -25,23 +33,160 @@ type ServerConfig struct {
 	License     string `json:"license" validate:"max=50"`
 }
 
+// DefaultsConfig holds tool settings shared by every tool. A tool's own value wins on
+// conflict; headers are merged name by name.
+type DefaultsConfig struct {
+	BaseURL string            `json:"base_url,omitempty" validate:"omitempty,url"` // Joined with relative endpoints
+	Headers map[string]string `json:"headers,omitempty"`
+	Auth    *AuthConfig       `json:"auth,omitempty"`
+	Timeout *Duration         `json:"timeout,omitempty"`
+	Retries *int              `json:"retries,omitempty" validate:"omitempty,min=0,max=5"`
+}
+
 // ToolConfig defines a single tool that makes HTTP API calls
 type ToolConfig struct {
-	Name          string            `json:"name" validate:"required,min=1,max=100"`
-	Description   string            `json:"description" validate:"required,min=1,max=500"`
-	Endpoint      string            `json:"endpoint" validate:"required,url"`
-	Method        string            `json:"method" validate:"required,oneof=GET POST PUT PATCH DELETE HEAD OPTIONS"`
-	Headers       map[string]string `json:"headers"`
-	QueryParams   map[string]string `json:"query_params"`
-	BodyTemplate  string            `json:"body_template"`
-	ContentType   string            `json:"content_type" validate:"omitempty,oneof=application/json application/xml text/plain application/x-www-form-urlencoded"`
-	Parameters    []ParameterConfig `json:"parameters"`
-	ReturnType    string            `json:"return_type" validate:"omitempty,oneof=string number boolean object array"`
-	Timeout       Duration          `json:"timeout"`
-	Retries       int               `json:"retries" validate:"min=0,max=5"`
-	Auth          *AuthConfig       `json:"auth,omitempty"`
-	Validation    *ValidationConfig `json:"validation,omitempty"`
-	UpstreamOAuth *OAuth2Config     `json:"upstream_oauth,omitempty"`
+	Name           string                `json:"name" validate:"required,min=1,max=100"`
+	Description    string                `json:"description" validate:"required,min=1,max=500"`
+	Protocol       string                `json:"protocol,omitempty" validate:"omitempty,oneof=http grpc exec"` // Defaults to http
+	Kind           string                `json:"kind,omitempty" validate:"omitempty,oneof=http graphql"`       // Defaults to http
+	Endpoint       string                `json:"endpoint" validate:"required_unless=Protocol grpc Protocol exec,omitempty,url"`
+	BaseURL        string                `json:"base_url,omitempty" validate:"omitempty,url"` // Relative endpoints are joined to it; defaults to defaults.base_url
+	Method         string                `json:"method" validate:"required,oneof=GET POST PUT PATCH DELETE HEAD OPTIONS"`
+	Headers        map[string]string     `json:"headers"`
+	QueryParams    map[string]string     `json:"query_params"`
+	BodyTemplate   string                `json:"body_template"`
+	BodyType       string                `json:"body_type,omitempty" validate:"omitempty,oneof=json multipart"`
+	Multipart      *MultipartConfig      `json:"multipart,omitempty" validate:"required_if=BodyType multipart"`
+	ContentType    string                `json:"content_type" validate:"omitempty,oneof=application/json application/xml text/plain application/x-www-form-urlencoded"`
+	Parameters     []ParameterConfig     `json:"parameters"`
+	ReturnType     string                `json:"return_type" validate:"omitempty,oneof=string number boolean object array"`
+	Timeout        *Duration             `json:"timeout,omitempty"`                                  // Unset takes defaults.timeout, then 30s; "0s" means no tool timeout
+	Retries        *int                  `json:"retries,omitempty" validate:"omitempty,min=0,max=5"` // Unset takes defaults.retries, then 3
+	Auth           *AuthConfig           `json:"auth,omitempty"`
+	Validation     *ValidationConfig     `json:"validation,omitempty"`
+	UpstreamOAuth  *OAuth2Config         `json:"upstream_oauth,omitempty"`
+	CacheTTL       Duration              `json:"cache_ttl,omitempty"` // Cache successful GET/HEAD responses for this long
+	Endpoints      []EndpointConfig      `json:"endpoints,omitempty" validate:"omitempty,dive"`
+	ResponsePath   string                `json:"response_path,omitempty"` // Dotted path (e.g. "data.items[0].name") extracted from the response
+	Transform      string                `json:"transform,omitempty"`     // jq program applied to the response after response_path
+	Session        string                `json:"session,omitempty"`       // Tools with the same session share cookies
+	Tags           []string              `json:"tags,omitempty"`          // Labels used to pick the tools tools/list shows
+	Enabled        *bool                 `json:"enabled,omitempty"`       // false hides the tool from tools/list; it stays callable
+	Retry          *RetryConfig          `json:"retry,omitempty"`
+	CircuitBreaker *CircuitBreakerConfig `json:"circuit_breaker,omitempty"`
+	TLS            *UpstreamTLSConfig    `json:"tls,omitempty"`       // Replaces security.upstream_tls for this tool
+	ProxyURL       string                `json:"proxy_url,omitempty"` // Overrides runtime.proxy_url for this tool
+	Pagination     *PaginationConfig     `json:"pagination,omitempty"`
+	Streaming      bool                  `json:"streaming,omitempty"` // Return the body as raw text in chunks without parsing it
+	Compression    *CompressionConfig    `json:"compression,omitempty"`
+	SaveAs         map[string]string     `json:"save_as,omitempty"`        // Context key -> response path kept per connection for {{.ctx.key}}
+	ExposeHeaders  []string              `json:"expose_headers,omitempty"` // Upstream response headers, e.g. "Location", added to the result
+	GraphQL        *GraphQLConfig        `json:"graphql,omitempty" validate:"required_if=Kind graphql"`
+	GRPC           *GRPCConfig           `json:"grpc,omitempty" validate:"required_if=Protocol grpc"`
+	Exec           *ExecConfig           `json:"exec,omitempty" validate:"required_if=Protocol exec"`
+}
+
+// TimeoutDuration returns the tool's timeout, or zero when it has none
+func (t *ToolConfig) TimeoutDuration() time.Duration {
+	if t.Timeout == nil {
+		return 0
+	}
+	return t.Timeout.ToDuration()
+}
+
+// RetryCount returns how many times a failed call to the tool is retried
+func (t *ToolConfig) RetryCount() int {
+	if t.Retries == nil {
+		return 0
+	}
+	return *t.Retries
+}
+
+// ExecConfig describes a local command run by an exec tool. The command runs directly,
+// never through a shell, and must be listed in security.exec_allowlist.
+type ExecConfig struct {
+	Command string `json:"command" validate:"required"` // Binary name looked up on PATH, or an absolute path
+	// Args are templates such as "{{.namespace}}"; each expands to exactly one argument
+	Args       []string          `json:"args"`
+	WorkingDir string            `json:"working_dir,omitempty"`
+	Env        map[string]string `json:"env,omitempty"` // Added to the server's environment
+}
+
+// GRPCConfig names a unary gRPC method on a server that has reflection enabled
+type GRPCConfig struct {
+	Address   string `json:"address" validate:"required"` // host:port
+	Service   string `json:"service" validate:"required"` // Fully qualified, e.g. "helloworld.Greeter"
+	Method    string `json:"method" validate:"required"`
+	Plaintext bool   `json:"plaintext"` // Connect without TLS; otherwise tls/upstream_tls applies
+	// Fields maps request message fields to tool parameter names. Parameters without an
+	// entry are set on the field of the same name.
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// GraphQLConfig describes the operation a graphql tool sends
+type GraphQLConfig struct {
+	Query         string `json:"query" validate:"required"`
+	OperationName string `json:"operation_name,omitempty"`
+	// Variables maps GraphQL variable names to tool parameter names. When empty, every
+	// tool parameter is passed as a variable of the same name.
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// PaginationConfig describes how to walk a paged API. Pages are fetched until no next
+// page is found or MaxPages is reached, and their items are concatenated into one array.
+type PaginationConfig struct {
+	ItemsPath     string `json:"items_path"`      // Path to the item array in each page; empty when the page is the array
+	NextPath      string `json:"next_path"`       // Path to the next page URL, or to a cursor when CursorParam is set
+	CursorParam   string `json:"cursor_param"`    // Query parameter that carries the cursor found at NextPath
+	UseLinkHeader bool   `json:"use_link_header"` // Follow the Link header's rel="next" URL
+	MaxPages      int    `json:"max_pages" validate:"min=0,max=100"`
+}
+
+// CompressionConfig controls compression of a tool's request and response bodies
+type CompressionConfig struct {
+	RequestGzip     bool `json:"request_gzip"`                       // Gzip request bodies and send Content-Encoding: gzip
+	MinRequestBytes int  `json:"min_request_bytes" validate:"min=0"` // Bodies smaller than this are sent as is (default 1024)
+	// AcceptEncoding is sent as the Accept-Encoding header, e.g. "gzip, deflate", or
+	// "identity" to ask for uncompressed responses. Empty leaves it to the client, which
+	// requests gzip. When set, a response in an encoding that can't be decoded fails the
+	// call; when empty, such a response is passed through as sent.
+	AcceptEncoding string `json:"accept_encoding,omitempty"`
+}
+
+// CircuitBreakerConfig fast-fails calls to an upstream that keeps failing
+type CircuitBreakerConfig struct {
+	FailureThreshold int      `json:"failure_threshold" validate:"min=0"` // Consecutive failed calls before the circuit opens (default 5)
+	Cooldown         Duration `json:"cooldown"`                           // Time the circuit stays open before a probe call is allowed (default 30s)
+}
+
+// MultipartConfig describes a multipart/form-data body with an optional file part
+type MultipartConfig struct {
+	// Fields maps form field names to a parameter name, or to a template such as "{{.caption}}"
+	Fields map[string]string `json:"fields"`
+	// FileField is the form field that carries the file, e.g. "image"
+	FileField string `json:"file_field"`
+	// FileParam names the tool parameter holding the file contents
+	FileParam string `json:"file_param" validate:"required_with=FileField"`
+	// FileSource says how FileParam is interpreted: base64 data (default) or a path on the
+	// server's filesystem. Paths let callers upload any file the server can read, so only
+	// enable them for trusted deployments.
+	FileSource      string `json:"file_source" validate:"omitempty,oneof=base64 path"`
+	FileName        string `json:"file_name"`         // Template for the uploaded file name; defaults to the path's base name or "upload"
+	FileContentType string `json:"file_content_type"` // Defaults to a type sniffed from the contents
+}
+
+// RetryConfig controls backoff between retries and which failures are retried
+type RetryConfig struct {
+	Strategy             string   `json:"strategy" validate:"omitempty,oneof=fixed exponential"`
+	BaseDelay            Duration `json:"base_delay"`             // Delay for fixed, initial delay for exponential (default 1s)
+	MaxDelay             Duration `json:"max_delay"`              // Upper bound for any single wait, including Retry-After (default 30s)
+	RetryableStatusCodes []int    `json:"retryable_status_codes"` // Defaults to 429 and 5xx; network errors are always retried
+}
+
+// EndpointConfig defines one of several equivalent endpoints a tool is balanced across
+type EndpointConfig struct {
+	URL    string `json:"url" validate:"required,url"`
+	Weight int    `json:"weight" validate:"min=0,max=100"` // Relative weight, defaults to 1
 }
 
 // ParameterConfig defines input parameters for tools
@@ -52,6 +197,21 @@ type ParameterConfig struct {
 	Required    bool                 `json:"required"`
 	Default     interface{}          `json:"default"`
 	Validation  *ParameterValidation `json:"validation,omitempty"`
+	Completions []string             `json:"completions,omitempty"` // Suggested values offered via completion/complete
+	// In places the value in the request: a {name} segment of the endpoint path, the
+	// query string, a header of that name, or the JSON body. Empty keeps the default of
+	// query for GET and body otherwise.
+	In string `json:"in,omitempty" validate:"omitempty,oneof=path query header body"`
+	// Style sets how an array or object is written to the query string: "repeat"
+	// (tag=a&tag=b, the default), "comma" (tag=a,b) or "brackets" (tag[]=a&tag[]=b).
+	// Object fields are sent as key[field]=value except in comma style.
+	Style string `json:"style,omitempty" validate:"omitempty,oneof=repeat comma brackets"`
+
+	// Properties describes the fields of an object parameter, keyed by field name. Name
+	// is ignored on the entries.
+	Properties map[string]ParameterConfig `json:"properties,omitempty"`
+	// Items describes the elements of an array parameter
+	Items *ParameterConfig `json:"items,omitempty"`
 }
 
 // ParameterValidation defines validation rules for parameters
@@ -62,11 +222,14 @@ type ParameterValidation struct {
 	MinValue  *float64 `json:"min_value,omitempty"`
 	MaxValue  *float64 `json:"max_value,omitempty"`
 	Enum      []string `json:"enum,omitempty"`
+
+	NumberEnum []float64   `json:"number_enum,omitempty"` // Allowed values of a number parameter
+	Const      interface{} `json:"const,omitempty"`       // The only accepted value, for string, number or boolean parameters
 }
 
 // AuthConfig defines authentication settings for API calls
 type AuthConfig struct {
-	Type     string            `json:"type" validate:"required,oneof=bearer basic api_key custom"`
+	Type     string            `json:"type" validate:"required,oneof=bearer basic api_key custom none"` // none opts a tool out of defaults.auth
 	Token    string            `json:"token,omitempty"`
 	Username string            `json:"username,omitempty"`
 	Password string            `json:"password,omitempty"`
@@ -97,17 +260,30 @@ type ValidationConfig struct {
 
 // PromptConfig defines static prompts for the MCP server
 type PromptConfig struct {
-	Name        string           `json:"name" validate:"required,min=1,max=100"`
-	Description string           `json:"description" validate:"required,min=1,max=500"`
-	Content     string           `json:"content" validate:"required,min=1"`
-	Arguments   []ArgumentConfig `json:"arguments"`
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description" validate:"required,min=1,max=500"`
+	Content     string `json:"content,omitempty"`   // Inline text
+	FilePath    string `json:"file_path,omitempty"` // Text file, e.g. a markdown document
+	URL         string `json:"url,omitempty"`       // Text fetched on each prompts/get
+	// Messages replaces the single user message with an ordered conversation
+	Messages  []PromptMessageConfig `json:"messages,omitempty" validate:"omitempty,dive"`
+	Arguments []ArgumentConfig      `json:"arguments"`
+}
+
+// PromptMessageConfig is one turn of a multi-message prompt: either text, rendered with
+// the prompt's arguments like single-message content, or a resource embedded by URI
+type PromptMessageConfig struct {
+	Role     string `json:"role" validate:"required,oneof=system user assistant"`
+	Content  string `json:"content,omitempty"`
+	Resource string `json:"resource,omitempty"` // URI of a configured resource or resource template
 }
 
 // ArgumentConfig defines prompt arguments
 type ArgumentConfig struct {
-	Name        string `json:"name" validate:"required,min=1,max=50"`
-	Description string `json:"description" validate:"required,min=1,max=200"`
-	Required    bool   `json:"required"`
+	Name        string   `json:"name" validate:"required,min=1,max=50"`
+	Description string   `json:"description" validate:"required,min=1,max=200"`
+	Required    bool     `json:"required"`
+	Completions []string `json:"completions,omitempty"` // Suggested values offered via completion/complete
 }
 
 // ResourceConfig defines static resources served by the MCP server
@@ -119,6 +295,22 @@ type ResourceConfig struct {
 	Content     string `json:"content,omitempty"`   // Inline content
 	FilePath    string `json:"file_path,omitempty"` // Path to file
 	URL         string `json:"url,omitempty"`       // External URL
+	// CacheTTL keeps URL content for this long; once stale it is revalidated with
+	// If-None-Match/If-Modified-Since instead of being downloaded again
+	CacheTTL Duration `json:"cache_ttl,omitempty"`
+}
+
+// ResourceTemplateConfig defines a parameterized resource. Variables written as {name}
+// in URITemplate are extracted from the requested URI and substituted into the same
+// placeholders in FilePath or URL.
+type ResourceTemplateConfig struct {
+	URITemplate string   `json:"uri_template" validate:"required"`
+	Name        string   `json:"name" validate:"required,min=1,max=100"`
+	Description string   `json:"description" validate:"max=500"`
+	MimeType    string   `json:"mime_type" validate:"required"`
+	FilePath    string   `json:"file_path,omitempty"` // e.g. "/var/log/app/{date}.log"
+	URL         string   `json:"url,omitempty"`       // e.g. "https://docs.example.com/{page}"
+	CacheTTL    Duration `json:"cache_ttl,omitempty"`
 }
 
 // SecurityConfig defines security settings for the server
@@ -132,6 +324,26 @@ type SecurityConfig struct {
 	TLSCertPath     string      `json:"tls_cert_path"`
 	TLSKeyPath      string      `json:"tls_key_path"`
 	OAuth           OAuthConfig `json:"oauth"`
+	// UpstreamTLS applies to every tool that does not set its own tls block
+	UpstreamTLS UpstreamTLSConfig `json:"upstream_tls"`
+	// AdminKeys unlock the /admin endpoints, which are not served without them
+	AdminKeys []string `json:"admin_keys"`
+	// ExecAllowlist lists the binaries exec tools may run, by name or absolute path
+	ExecAllowlist []string `json:"exec_allowlist"`
+	// TrustedProxies lists the IPs or CIDRs of reverse proxies whose X-Forwarded-Proto
+	// header is believed
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// UpstreamTLSConfig controls how TLS connections to upstream APIs are verified.
+// InsecureSkipVerify disables certificate and hostname checks entirely, leaving the
+// connection open to interception; prefer CAFile for private or self-signed CAs.
+type UpstreamTLSConfig struct {
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+	CAFile             string `json:"ca_file"`     // PEM bundle trusted in addition to the system roots
+	CertFile           string `json:"cert_file"`   // Client certificate for mTLS
+	KeyFile            string `json:"key_file"`    // Private key for CertFile
+	ServerName         string `json:"server_name"` // Overrides the SNI/verification hostname
 }
 
 // OAuthConfig configures OAuth/OIDC-based authorization for the MCP HTTP transport
@@ -149,6 +361,9 @@ type OAuthConfig struct {
 	JWKSCacheTTL Duration `json:"jwks_cache_ttl"`
 	// Development only: allow HTTP discovery (not recommended in prod)
 	AllowInsecureHTTP bool `json:"allow_insecure_http"`
+	// Fetch each authorization server's discovery document and JWKS at startup:
+	// "off" (default), "warn" to log failures, or "strict" to abort startup
+	VerifyDiscovery string `json:"verify_discovery" validate:"omitempty,oneof=off warn strict"`
 }
 
 // RuntimeConfig defines runtime behavior settings
@@ -159,6 +374,48 @@ type RuntimeConfig struct {
 	MetricsEnabled        bool     `json:"metrics_enabled"`
 	LogLevel              string   `json:"log_level" validate:"oneof=debug info warn error"`
 	Environment           string   `json:"environment" validate:"oneof=development staging production"`
+	// Header used to forward the per-request correlation id to upstream APIs
+	RequestIDHeader string `json:"request_id_header"`
+	// User-Agent sent on upstream calls unless a tool sets its own (default
+	// "<server name>/<server version>")
+	UserAgent string `json:"user_agent"`
+	// Expose the HTTP+SSE transport at /mcp/sse in addition to POST /mcp
+	EnableSSE bool `json:"enable_sse"`
+	// Proxy for upstream calls (http, https, socks5 or socks5h URL). Empty uses the
+	// HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment; "none" connects directly.
+	ProxyURL string `json:"proxy_url"`
+	// Largest JSON-RPC request body, in bytes, accepted from clients (default 4 MiB)
+	MaxRequestBytes int64 `json:"max_request_bytes" validate:"min=0"`
+	// Largest file or URL resource, in bytes, loaded into memory (default 10 MiB)
+	MaxResourceSize int64 `json:"max_resource_size" validate:"min=0"`
+	// Largest upstream response body, in bytes, read for a tool call; longer bodies are
+	// cut and marked as truncated (default 10 MiB)
+	MaxResponseBytes int64 `json:"max_response_bytes" validate:"min=0"`
+	// Fail to load when a ${VAR} placeholder has no value instead of keeping it literally
+	StrictEnv bool `json:"strict_env"`
+	// Longest upstream error body, in characters, quoted in a tool error (default 1000)
+	ErrorBodyLimit int `json:"error_body_limit" validate:"min=0"`
+	// Quote upstream error bodies in full instead of summarizing them
+	VerboseErrors bool `json:"verbose_errors"`
+	// Keep cookies from upstream responses and send them on later calls. Tools without a
+	// session share one cookie jar.
+	Cookies bool `json:"cookies"`
+	// When set, tools/list only shows tools tagged with at least one of these tags
+	ToolTags []string `json:"tool_tags"`
+	// Connection pooling and timeouts for upstream HTTP calls
+	HTTPClient HTTPClientConfig `json:"http_client"`
+}
+
+// HTTPClientConfig tunes the HTTP transport shared by upstream calls. Zero timeouts
+// other than Timeout mean no limit.
+type HTTPClientConfig struct {
+	Timeout               Duration `json:"timeout"`                                  // Cap on a whole request, including redirects and reading the body (default 30s)
+	MaxIdleConns          int      `json:"max_idle_conns" validate:"min=0"`          // Idle connections kept across all hosts (default 100)
+	MaxIdleConnsPerHost   int      `json:"max_idle_conns_per_host" validate:"min=0"` // Idle connections kept per host (default 2)
+	IdleConnTimeout       Duration `json:"idle_conn_timeout"`                        // How long an idle connection is kept (default 90s)
+	DialTimeout           Duration `json:"dial_timeout"`                             // Time allowed to open a TCP connection
+	TLSHandshakeTimeout   Duration `json:"tls_handshake_timeout"`                    // Time allowed for the TLS handshake
+	ResponseHeaderTimeout Duration `json:"response_header_timeout"`                  // Time allowed between sending the request and the response headers
 }
 
 // Duration is a wrapper around time.Duration for JSON marshaling
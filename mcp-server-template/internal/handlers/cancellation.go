@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// inflightRequests maps requests being served to the cancel funcs of their contexts, so
+// a notifications/cancelled from the client can stop them. Requests are keyed by the
+// streaming connection they arrived on and their id; plain POST requests aren't tracked.
+type inflightRequests struct {
+	mu       sync.Mutex
+	requests map[inflightKey]*inflightRequest
+}
+
+type inflightKey struct {
+	clientID string
+	id       string
+}
+
+type inflightRequest struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+func newInflightRequests() *inflightRequests {
+	return &inflightRequests{requests: make(map[inflightKey]*inflightRequest)}
+}
+
+// requestKey keys an id by its JSON form so the number 1 and the string "1" stay distinct
+func requestKey(clientID string, id interface{}) inflightKey {
+	data, _ := json.Marshal(id)
+	return inflightKey{clientID: clientID, id: string(data)}
+}
+
+// Track derives a cancellable context for a request and registers it. The returned
+// function must be called once the request finishes; it reports whether the client
+// cancelled the request.
+func (r *inflightRequests) Track(ctx context.Context, clientID string, id interface{}) (context.Context, func() bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	key := requestKey(clientID, id)
+	entry := &inflightRequest{cancel: cancel}
+
+	r.mu.Lock()
+	r.requests[key] = entry
+	r.mu.Unlock()
+
+	return ctx, func() bool {
+		r.mu.Lock()
+		// A later request reusing the id may have replaced this entry
+		if r.requests[key] == entry {
+			delete(r.requests, key)
+		}
+		cancelled := entry.cancelled
+		r.mu.Unlock()
+		cancel()
+		return cancelled
+	}
+}
+
+// Cancel stops the matching request, reporting whether one was in flight
+func (r *inflightRequests) Cancel(clientID string, id interface{}) bool {
+	r.mu.Lock()
+	entry, ok := r.requests[requestKey(clientID, id)]
+	if ok {
+		entry.cancelled = true
+	}
+	r.mu.Unlock()
+
+	if ok {
+		entry.cancel()
+	}
+	return ok
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"mcp-server-template/internal/config"
+)
+
+// ErrCircuitOpen is returned when a tool's circuit breaker rejects a call
+var ErrCircuitOpen = errors.New("circuit open")
+
+// Circuit breaker states, also used as the value of the state gauge in /metrics
+const (
+	circuitClosed   = 0
+	circuitHalfOpen = 1
+	circuitOpen     = 2
+)
+
+// BreakerStats reports the state of a single tool's circuit breaker
+type BreakerStats struct {
+	Tool                string `json:"tool"`
+	State               string `json:"state"`
+	StateValue          int    `json:"state_value"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	Opens               uint64 `json:"opens"`
+}
+
+// circuitBreakers tracks one breaker per tool name
+type circuitBreakers struct {
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+type circuitBreaker struct {
+	state               int
+	consecutiveFailures int
+	openUntil           time.Time
+	probeInFlight       bool
+	opens               uint64
+}
+
+func newCircuitBreakers() *circuitBreakers {
+	return &circuitBreakers{breakers: make(map[string]*circuitBreaker)}
+}
+
+// Allow reports whether a call to the tool may proceed. Once the cooldown of an
+// open circuit elapses, a single probe call is let through to test the upstream.
+func (c *circuitBreakers) Allow(tool *config.ToolConfig) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := c.breaker(tool.Name)
+	switch b.state {
+	case circuitOpen:
+		if wait := time.Until(b.openUntil); wait > 0 {
+			return fmt.Errorf("%w: %s is failing, retry in %s", ErrCircuitOpen, tool.Name, wait.Round(time.Second))
+		}
+		b.state = circuitHalfOpen
+		b.probeInFlight = true
+		return nil
+	case circuitHalfOpen:
+		if b.probeInFlight {
+			return fmt.Errorf("%w: %s is being probed after repeated failures", ErrCircuitOpen, tool.Name)
+		}
+		b.probeInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Record reports the outcome of a call that Allow let through
+func (c *circuitBreakers) Record(tool *config.ToolConfig, success bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := c.breaker(tool.Name)
+	b.probeInFlight = false
+
+	if success {
+		b.state = circuitClosed
+		b.consecutiveFailures = 0
+		return
+	}
+
+	b.consecutiveFailures++
+	if b.state == circuitHalfOpen || b.consecutiveFailures >= tool.CircuitBreaker.FailureThreshold {
+		b.state = circuitOpen
+		b.openUntil = time.Now().Add(tool.CircuitBreaker.Cooldown.ToDuration())
+		b.opens++
+	}
+}
+
+// Release returns a probe slot without recording an outcome, e.g. when the client
+// went away before the upstream answered
+func (c *circuitBreakers) Release(tool *config.ToolConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.breaker(tool.Name).probeInFlight = false
+}
+
+// Reset closes the breaker for toolName, or all breakers when empty, and returns
+// how many were not already closed
+func (c *circuitBreakers) Reset(toolName string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reset := 0
+	for name, b := range c.breakers {
+		if toolName != "" && name != toolName {
+			continue
+		}
+		if b.state != circuitClosed {
+			reset++
+		}
+		b.state = circuitClosed
+		b.consecutiveFailures = 0
+		b.openUntil = time.Time{}
+		b.probeInFlight = false
+	}
+	return reset
+}
+
+// Stats returns a snapshot of all breakers
+func (c *circuitBreakers) Stats() []BreakerStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]BreakerStats, 0, len(c.breakers))
+	for name, b := range c.breakers {
+		stats = append(stats, BreakerStats{
+			Tool:                name,
+			State:               circuitStateName(b.state),
+			StateValue:          b.state,
+			ConsecutiveFailures: b.consecutiveFailures,
+			Opens:               b.opens,
+		})
+	}
+	return stats
+}
+
+// breaker returns the breaker for a tool, creating it on first use
+func (c *circuitBreakers) breaker(toolName string) *circuitBreaker {
+	b, ok := c.breakers[toolName]
+	if !ok {
+		b = &circuitBreaker{}
+		c.breakers[toolName] = b
+	}
+	return b
+}
+
+func circuitStateName(state int) string {
+	switch state {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"mcp-server-template/internal/config"
+)
+
+// coerceArguments converts string arguments to the number or boolean their parameter
+// declares, such as "42" or "true", which models often send in place of the bare value.
+// Nested fields and array items are converted too. Strings that don't parse are left for
+// validation to reject. It returns the paths of the arguments it changed.
+func coerceArguments(tool *config.ToolConfig, arguments map[string]interface{}) []string {
+	var coerced []string
+	for i := range tool.Parameters {
+		param := &tool.Parameters[i]
+		if value, exists := arguments[param.Name]; exists {
+			arguments[param.Name] = coerceValue(param, value, param.Name, &coerced)
+		}
+	}
+	return coerced
+}
+
+func coerceValue(param *config.ParameterConfig, value interface{}, path string, coerced *[]string) interface{} {
+	switch param.Type {
+	case "number":
+		if s, ok := value.(string); ok {
+			if num, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil && !math.IsInf(num, 0) && !math.IsNaN(num) {
+				*coerced = append(*coerced, path)
+				return num
+			}
+		}
+	case "boolean":
+		if s, ok := value.(string); ok {
+			switch strings.ToLower(strings.TrimSpace(s)) {
+			case "true":
+				*coerced = append(*coerced, path)
+				return true
+			case "false":
+				*coerced = append(*coerced, path)
+				return false
+			}
+		}
+	case "object":
+		if obj, ok := value.(map[string]interface{}); ok {
+			for _, name := range sortedFieldNames(param.Properties) {
+				field := param.Properties[name]
+				if fieldValue, exists := obj[name]; exists {
+					obj[name] = coerceValue(&field, fieldValue, path+"."+name, coerced)
+				}
+			}
+		}
+	case "array":
+		if items, ok := value.([]interface{}); ok && param.Items != nil {
+			for i, item := range items {
+				items[i] = coerceValue(param.Items, item, path+"["+strconv.Itoa(i)+"]", coerced)
+			}
+		}
+	}
+	return value
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxCompletionValues is the most values a completion result may carry per the MCP spec
+const maxCompletionValues = 100
+
+// completionRequest is the params object of completion/complete
+type completionRequest struct {
+	Ref struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+		URI  string `json:"uri"`
+	} `json:"ref"`
+	Argument struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"argument"`
+}
+
+func (h *JSONRPCHandler) handleCompletionComplete(req *JSONRPCRequest) *JSONRPCResponse {
+	var params completionRequest
+	if req.Params != nil {
+		paramBytes, _ := json.Marshal(req.Params)
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+		}
+	}
+
+	candidates, err := h.completionCandidates(&params)
+	if err != nil {
+		return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+	}
+
+	values := filterCompletions(candidates, params.Argument.Value)
+	total := len(values)
+	if total > maxCompletionValues {
+		values = values[:maxCompletionValues]
+	}
+
+	return h.successResponse(req.ID, map[string]interface{}{
+		"completion": map[string]interface{}{
+			"values":  values,
+			"total":   total,
+			"hasMore": total > len(values),
+		},
+	})
+}
+
+// completionCandidates returns every value configured for the referenced argument.
+// Prompts use "ref/prompt"; tools are addressed with "ref/tool" by name. Resource
+// templates have no configured values, so "ref/resource" always completes to nothing.
+func (h *JSONRPCHandler) completionCandidates(params *completionRequest) ([]string, error) {
+	switch params.Ref.Type {
+	case "ref/prompt":
+		for _, prompt := range h.config.Prompts {
+			if prompt.Name != params.Ref.Name {
+				continue
+			}
+			for _, arg := range prompt.Arguments {
+				if arg.Name == params.Argument.Name {
+					return arg.Completions, nil
+				}
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("prompt '%s' not found", params.Ref.Name)
+
+	case "ref/tool":
+		for _, tool := range h.config.Tools {
+			if tool.Name != params.Ref.Name {
+				continue
+			}
+			for _, param := range tool.Parameters {
+				if param.Name != params.Argument.Name {
+					continue
+				}
+				// Enum values are the only accepted inputs, so they take precedence
+				if param.Validation != nil && len(param.Validation.Enum) > 0 {
+					return param.Validation.Enum, nil
+				}
+				if param.Validation != nil && len(param.Validation.NumberEnum) > 0 {
+					values := make([]string, len(param.Validation.NumberEnum))
+					for i, n := range param.Validation.NumberEnum {
+						values[i] = strconv.FormatFloat(n, 'f', -1, 64)
+					}
+					return values, nil
+				}
+				return param.Completions, nil
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("tool '%s' not found", params.Ref.Name)
+
+	case "ref/resource":
+		return nil, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported reference type '%s'", params.Ref.Type)
+	}
+}
+
+// filterCompletions keeps the candidates that start with the partial input, ignoring
+// case, in their configured order
+func filterCompletions(candidates []string, partial string) []string {
+	prefix := strings.ToLower(partial)
+	values := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), prefix) {
+			values = append(values, candidate)
+		}
+	}
+	return values
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"mcp-server-template/internal/config"
+)
+
+// defaultMinGzipBytes is the smallest request body compressed when the tool's
+// compression config leaves min_request_bytes unset; smaller bodies would barely shrink
+const defaultMinGzipBytes = 1024
+
+// gzipRequestBody compresses body when the tool asks for gzip requests and the body is
+// large enough. It reports whether the returned body is compressed.
+func gzipRequestBody(body io.Reader, compression *config.CompressionConfig) (io.Reader, bool, error) {
+	if body == nil || compression == nil || !compression.RequestGzip {
+		return body, false, nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, false, err
+	}
+	minBytes := compression.MinRequestBytes
+	if minBytes == 0 {
+		minBytes = defaultMinGzipBytes
+	}
+	if len(data) < minBytes {
+		return bytes.NewReader(data), false, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, false, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, false, err
+	}
+	return bytes.NewReader(buf.Bytes()), true, nil
+}
+
+// decodedBody undoes the Content-Encoding of a response. The transport only decodes
+// gzip on its own when it chose Accept-Encoding itself, so tools that set the header
+// need their responses decoded here. Empty bodies, as in 204 and HEAD responses, are
+// left alone. An encoding that can't be decoded is an error only when strict, i.e. when
+// the tool asked for encodings itself; otherwise the body is passed through as sent.
+func decodedBody(resp *http.Response, strict bool) (io.Reader, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return resp.Body, nil
+	}
+
+	br := bufio.NewReader(resp.Body)
+	if _, err := br.Peek(1); err == io.EOF {
+		return br, nil
+	}
+
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(br)
+	case "deflate":
+		// HTTP deflate is meant to be zlib-wrapped, but some servers send raw deflate
+		header, err := br.Peek(2)
+		if err == nil && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 && header[0]&0x0f == 8 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	}
+	if strict {
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", resp.Header.Get("Content-Encoding"))
+	}
+	return br, nil
+}
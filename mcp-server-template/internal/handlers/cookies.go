@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"sync"
+
+	"mcp-server-template/internal/config"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// defaultSession is the jar shared by tools without a session name when runtime.cookies is on
+const defaultSession = "default"
+
+// cookieSessions holds one cookie jar per session name, so cookies set by one tool call
+// are sent on later calls by tools in the same session
+type cookieSessions struct {
+	mu   sync.Mutex
+	jars map[string]http.CookieJar
+}
+
+func newCookieSessions() *cookieSessions {
+	return &cookieSessions{jars: make(map[string]http.CookieJar)}
+}
+
+// sessionName returns the session a tool's cookies belong to, or "" when the tool
+// doesn't keep cookies
+func sessionName(tool *config.ToolConfig, cfg *config.Config) string {
+	if tool.Session != "" {
+		return tool.Session
+	}
+	if cfg.Runtime.Cookies {
+		return defaultSession
+	}
+	return ""
+}
+
+// jar returns the cookie jar for a session, creating it on first use
+func (s *cookieSessions) jar(name string) http.CookieJar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if jar, ok := s.jars[name]; ok {
+		return jar
+	}
+	// cookiejar.New only fails on invalid options
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	s.jars[name] = jar
+	return jar
+}
+
+// Reset drops the cookies of one session, or of every session when name is empty,
+// returning how many jars were cleared
+func (s *cookieSessions) Reset(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if name == "" {
+		n := len(s.jars)
+		s.jars = make(map[string]http.CookieJar)
+		return n
+	}
+	if _, ok := s.jars[name]; !ok {
+		return 0
+	}
+	delete(s.jars, name)
+	return 1
+}
+
+// withCookies returns client set up to read and store the tool's session cookies. The
+// copy shares the original's transport, so connections are still pooled.
+func (h *HTTPClient) withCookies(client *http.Client, tool *config.ToolConfig) *http.Client {
+	name := sessionName(tool, h.config)
+	if name == "" {
+		return client
+	}
+	withJar := *client
+	withJar.Jar = h.sessions.jar(name)
+	return &withJar
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"time"
+)
+
+// drainAbortMargin is how long before the shutdown deadline running tool calls are
+// cancelled, leaving them time to unwind and answer their clients
+const drainAbortMargin = 500 * time.Millisecond
+
+// trackToolCall registers a tool execution for graceful shutdown. The returned context
+// is also cancelled when the handler aborts outstanding calls. ok is false once
+// draining has started, in which case the call must be refused.
+func (h *JSONRPCHandler) trackToolCall(ctx context.Context) (context.Context, func(), bool) {
+	h.drainMu.Lock()
+	defer h.drainMu.Unlock()
+
+	if h.draining {
+		return ctx, func() {}, false
+	}
+	h.active.Add(1)
+	h.activeCount.Add(1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(h.baseCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		h.activeCount.Add(-1)
+		h.active.Done()
+	}, true
+}
+
+// Draining reports whether Drain has been called, i.e. the server is shutting down
+func (h *JSONRPCHandler) Draining() bool {
+	h.drainMu.Lock()
+	defer h.drainMu.Unlock()
+	return h.draining
+}
+
+// Drain stops accepting tool calls and waits for running ones to finish. Shortly
+// before ctx's deadline the remaining calls are cancelled. It returns how many calls
+// were still running when ctx ended.
+func (h *JSONRPCHandler) Drain(ctx context.Context) int {
+	h.drainMu.Lock()
+	h.draining = true
+	h.drainMu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		h.active.Wait()
+		close(finished)
+	}()
+
+	var abort <-chan time.Time
+	if deadline, ok := ctx.Deadline(); ok {
+		timer := time.NewTimer(time.Until(deadline) - drainAbortMargin)
+		defer timer.Stop()
+		abort = timer.C
+	}
+
+	for {
+		select {
+		case <-finished:
+			return 0
+		case <-abort:
+			h.logger.WithField("active_tool_calls", h.activeCount.Load()).Warn("Shutdown deadline near, cancelling running tool calls")
+			h.cancelBase()
+			abort = nil
+		case <-ctx.Done():
+			h.cancelBase()
+			return int(h.activeCount.Load())
+		}
+	}
+}
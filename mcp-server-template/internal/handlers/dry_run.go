@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// dryRunArgument is a reserved tool argument that renders the upstream request instead
+// of sending it
+const dryRunArgument = "__dry_run"
+
+// redactedValue replaces credentials in logs and dry-run output
+const redactedValue = "***REDACTED***"
+
+// RenderedRequest is the request a tool call would send, as reported by a dry run
+type RenderedRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// DryRun builds the request for a tool call without sending it. Credentials in headers
+// and query parameters are redacted. Balanced tools render against their first endpoint.
+func (h *HTTPClient) DryRun(ctx context.Context, tool *config.ToolConfig, params map[string]interface{}) (*RenderedRequest, error) {
+	params, _ = popNoCache(params)
+
+	target := tool
+	if len(tool.Endpoints) > 0 {
+		first := *tool
+		first.Endpoint = tool.Endpoints[0].URL
+		target = &first
+	}
+
+	req, err := h.buildRequest(ctx, target, params)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := &RenderedRequest{
+		Method:  req.Method,
+		Headers: make(map[string]string, len(req.Header)),
+	}
+
+	query := req.URL.Query()
+	for key := range query {
+		if isSensitiveKey(key) {
+			query.Set(key, redactedValue)
+		}
+	}
+	redactedURL := *req.URL
+	redactedURL.RawQuery = query.Encode()
+	rendered.URL = redactedURL.String()
+
+	for key, values := range req.Header {
+		value := strings.Join(values, ", ")
+		if sensitiveHeader(key, tool.Auth) {
+			value = redactedValue
+		}
+		rendered.Headers[key] = value
+	}
+
+	if req.Body != nil {
+		var reader io.Reader = req.Body
+		if req.Header.Get("Content-Encoding") == "gzip" {
+			// Show what would be compressed rather than the gzip bytes
+			if reader, err = gzip.NewReader(req.Body); err != nil {
+				return nil, err
+			}
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		rendered.Body = string(body)
+	}
+	return rendered, nil
+}
+
+// sensitiveHeader reports whether a header may carry credentials, either by its name or
+// because the tool's auth config sets it
+func sensitiveHeader(name string, auth *config.AuthConfig) bool {
+	switch http.CanonicalHeaderKey(name) {
+	case "Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key":
+		return true
+	}
+	if auth != nil {
+		for key := range auth.Headers {
+			if http.CanonicalHeaderKey(key) == http.CanonicalHeaderKey(name) {
+				return true
+			}
+		}
+	}
+	return isSensitiveKey(name)
+}
+
+// dryRunResult reports the request a tool call would send as the tool result
+func (h *ToolHandler) dryRunResult(ctx context.Context, tool *config.ToolConfig, arguments map[string]interface{}) *mcp.CallToolResult {
+	if tool.Protocol == "grpc" || tool.Protocol == "exec" {
+		return toolErrorResult(ToolError{
+			Code:    errorCodeUnsupported,
+			Message: fmt.Sprintf("dry run is not supported for %s tools", tool.Protocol),
+		})
+	}
+
+	rendered, err := h.httpClient.DryRun(ctx, tool, arguments)
+	if err != nil {
+		return toolErrorResult(classifyCallError(err, fmt.Sprintf("failed to build request: %s", err)))
+	}
+	data, err := json.MarshalIndent(rendered, "", "  ")
+	if err != nil {
+		return toolErrorResult(classifyCallError(err, fmt.Sprintf("failed to encode request: %s", err)))
+	}
+	return mcp.NewToolResultText(string(data))
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// defaultErrorBodyLimit applies when the runtime config leaves error_body_limit unset
+const defaultErrorBodyLimit = 1000
+
+// errorMessageFields are the keys APIs conventionally put an error description under,
+// in the order they are tried
+var errorMessageFields = []string{"message", "error_description", "error", "detail", "title", "errors"}
+
+var (
+	htmlHiddenPattern = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	htmlTagPattern    = regexp.MustCompile(`(?s)<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// errorBody condenses an upstream error body for the tool error message. A JSON body is
+// reduced to its error message, HTML is reduced to its text, and anything longer than
+// the configured limit is cut. With runtime.verbose_errors the body is kept as is.
+func (h *ToolHandler) errorBody(response *APIResponse) string {
+	limit := defaultErrorBodyLimit
+	if h.config != nil {
+		if h.config.Runtime.VerboseErrors {
+			return response.Body
+		}
+		if h.config.Runtime.ErrorBodyLimit > 0 {
+			limit = h.config.Runtime.ErrorBodyLimit
+		}
+	}
+
+	body := strings.TrimSpace(response.Body)
+	data := response.Data
+	if data == nil && (strings.HasPrefix(body, "{") || strings.HasPrefix(body, "[")) {
+		_ = json.Unmarshal([]byte(body), &data)
+	}
+
+	switch {
+	case data != nil:
+		if message := jsonErrorMessage(data); message != "" {
+			body = message
+		}
+	case isHTMLBody(response.Headers["Content-Type"], body):
+		body = htmlText(body)
+	}
+	return truncateErrorBody(body, limit)
+}
+
+// jsonErrorMessage finds the error description in a parsed JSON error body. Nested
+// objects such as {"error": {"message": "..."}} and arrays of errors are followed.
+func jsonErrorMessage(data interface{}) string {
+	switch v := data.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case []interface{}:
+		var messages []string
+		for _, item := range v {
+			if message := jsonErrorMessage(item); message != "" {
+				messages = append(messages, message)
+			}
+		}
+		return strings.Join(messages, "; ")
+	case map[string]interface{}:
+		for _, field := range errorMessageFields {
+			if value, ok := v[field]; ok {
+				if message := jsonErrorMessage(value); message != "" {
+					return message
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func isHTMLBody(contentType, body string) bool {
+	if strings.Contains(strings.ToLower(contentType), "html") {
+		return true
+	}
+	lower := strings.ToLower(body)
+	return strings.HasPrefix(lower, "<!doctype html") || strings.HasPrefix(lower, "<html")
+}
+
+// htmlText strips markup from an HTML page, leaving its visible text
+func htmlText(body string) string {
+	text := htmlHiddenPattern.ReplaceAllString(body, " ")
+	text = htmlTagPattern.ReplaceAllString(text, " ")
+	text = html.UnescapeString(text)
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+}
+
+func truncateErrorBody(body string, limit int) string {
+	runes := []rune(body)
+	if len(runes) <= limit {
+		return body
+	}
+	return fmt.Sprintf("%s... (%d more characters)", string(runes[:limit]), len(runes)-limit)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxExecOutput caps how much of each output stream is kept from a command
+const maxExecOutput = 1 << 20
+
+// ExecRunner runs allowlisted local commands for exec tools
+type ExecRunner struct {
+	config *config.Config
+	logger *logrus.Logger
+}
+
+// NewExecRunner creates a new command runner
+func NewExecRunner(cfg *config.Config, logger *logrus.Logger) *ExecRunner {
+	return &ExecRunner{
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Run executes the tool's command with arguments expanded from params. Arguments are
+// passed to the process as-is, with no shell, so parameter values cannot inject extra
+// commands. A non-zero exit status is returned as an error that includes stderr.
+func (r *ExecRunner) Run(ctx context.Context, tool *config.ToolConfig, params map[string]interface{}) (*APIResponse, error) {
+	if tool.Exec == nil {
+		return nil, fmt.Errorf("tool %s has no exec configuration", tool.Name)
+	}
+
+	// The config was checked at load time; check again in case it was changed since
+	if !config.ExecAllowed(tool.Exec.Command, r.config.Security.ExecAllowlist) {
+		return nil, fmt.Errorf("command %s is not in the exec allowlist", tool.Exec.Command)
+	}
+
+	args, err := expandExecArgs(tool.Exec.Args, templateData(ctx, params))
+	if err != nil {
+		return nil, err
+	}
+
+	if tool.TimeoutDuration() > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tool.TimeoutDuration())
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, tool.Exec.Command, args...)
+	cmd.Dir = tool.Exec.WorkingDir
+	cmd.WaitDelay = 5 * time.Second
+	if len(tool.Exec.Env) > 0 {
+		cmd.Env = os.Environ()
+		for key, value := range tool.Exec.Env {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
+	}
+
+	stdout := &limitedBuffer{limit: maxExecOutput}
+	stderr := &limitedBuffer{limit: maxExecOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	log := requestLogger(ctx, r.logger).WithFields(logrus.Fields{
+		"tool_name": tool.Name,
+		"command":   tool.Exec.Command,
+		"args":      redactExecArgs(args, params),
+	})
+	log.Debug("Running command")
+
+	startTime := time.Now()
+	runErr := cmd.Run()
+	exitCode := cmd.ProcessState.ExitCode()
+
+	log.WithFields(logrus.Fields{
+		"exit_code": exitCode,
+		"duration":  time.Since(startTime),
+	}).Debug("Command finished")
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("command did not finish: %w", ctx.Err())
+	}
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) {
+		return nil, fmt.Errorf("failed to run command: %w", runErr)
+	}
+	if exitCode != 0 {
+		return nil, fmt.Errorf("exit status %d: %s", exitCode, strings.TrimSpace(stderr.String()))
+	}
+
+	body := stdout.String()
+	if stderr.Len() > 0 {
+		body += "\n[stderr]\n" + stderr.String()
+	}
+
+	return &APIResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{},
+		Body:       body,
+		Data: map[string]interface{}{
+			"exit_code": exitCode,
+			"stdout":    stdout.String(),
+			"stderr":    stderr.String(),
+		},
+	}, nil
+}
+
+// expandExecArgs renders each argument template. A value that would turn a positional
+// argument into an option (a leading "-") is rejected so callers cannot pass flags
+// such as --kubeconfig that the tool author did not intend.
+func expandExecArgs(templates []string, params map[string]interface{}) ([]string, error) {
+	args := make([]string, 0, len(templates))
+	for i, tmplStr := range templates {
+		if err := missingSessionValue(tmplStr, params); err != nil {
+			return nil, fmt.Errorf("failed to expand argument %d: %w", i, err)
+		}
+		tmpl, err := parseToolTemplate("arg", tmplStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for argument %d: %w", i, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Option("missingkey=error").Execute(&buf, params); err != nil {
+			return nil, fmt.Errorf("failed to expand argument %d: %w", i, err)
+		}
+
+		arg := buf.String()
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(tmplStr, "-") {
+			return nil, fmt.Errorf("argument %d (%q) would be read as an option", i, arg)
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// redactExecArgs masks arguments that contain the value of a sensitive parameter
+func redactExecArgs(args []string, params map[string]interface{}) []string {
+	var secrets []string
+	for key, value := range params {
+		if isSensitiveKey(key) {
+			if s := fmt.Sprintf("%v", value); s != "" {
+				secrets = append(secrets, s)
+			}
+		}
+	}
+
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = arg
+		for _, secret := range secrets {
+			if strings.Contains(arg, secret) {
+				redacted[i] = redactedValue
+				break
+			}
+		}
+	}
+	return redacted
+}
+
+// limitedBuffer keeps the first limit bytes written and silently discards the rest,
+// so a chatty command cannot exhaust memory or block on a full pipe
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Buffer.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"strconv"
+)
+
+const formContentType = "application/x-www-form-urlencoded"
+
+// isFormContentType reports whether a Content-Type names a urlencoded form, whatever
+// its parameters, e.g. "application/x-www-form-urlencoded; charset=utf-8"
+func isFormContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == formContentType
+}
+
+// encodeFormBody encodes arguments as an application/x-www-form-urlencoded body. Array
+// arguments repeat their key once per element, and objects are sent as JSON text.
+func encodeFormBody(args map[string]interface{}) (string, error) {
+	form := url.Values{}
+	for name, arg := range args {
+		values, ok := arg.([]interface{})
+		if !ok {
+			values = []interface{}{arg}
+		}
+		for _, value := range values {
+			encoded, err := formValue(value)
+			if err != nil {
+				return "", fmt.Errorf("field %s: %w", name, err)
+			}
+			form.Add(name, encoded)
+		}
+	}
+	return form.Encode(), nil
+}
+
+func formValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		// Avoid exponent notation such as 1e+06 for whole numbers
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
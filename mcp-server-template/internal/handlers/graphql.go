@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"mcp-server-template/internal/config"
+)
+
+// graphQLRequest is the standard GraphQL-over-HTTP POST body
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// buildGraphQLBody encodes the tool's query with variables taken from the call parameters
+func buildGraphQLBody(tool *config.ToolConfig, params map[string]interface{}) ([]byte, error) {
+	if tool.GraphQL == nil {
+		return nil, fmt.Errorf("tool %s has no graphql configuration", tool.Name)
+	}
+
+	variables := make(map[string]interface{})
+	if len(tool.GraphQL.Variables) == 0 {
+		for name, value := range params {
+			variables[name] = value
+		}
+	} else {
+		for variable, param := range tool.GraphQL.Variables {
+			// Leave unset parameters out so the query's own variable defaults apply
+			if value, ok := params[param]; ok {
+				variables[variable] = value
+			}
+		}
+	}
+
+	return json.Marshal(graphQLRequest{
+		Query:         tool.GraphQL.Query,
+		OperationName: tool.GraphQL.OperationName,
+		Variables:     variables,
+	})
+}
+
+// graphQLErrors returns the messages of a response's top-level "errors" array joined
+// into one string, or "" when the response reports no errors
+func graphQLErrors(data interface{}) string {
+	body, ok := data.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	errs, ok := body["errors"].([]interface{})
+	if !ok || len(errs) == 0 {
+		return ""
+	}
+
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		if entry, ok := e.(map[string]interface{}); ok {
+			if message, ok := entry["message"].(string); ok {
+				messages = append(messages, message)
+				continue
+			}
+		}
+		encoded, _ := json.Marshal(e)
+		messages = append(messages, string(encoded))
+	}
+	return strings.Join(messages, "; ")
+}
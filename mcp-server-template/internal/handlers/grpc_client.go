@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPCClient calls unary gRPC methods without generated stubs. Method descriptors are
+// discovered through server reflection and requests are built as dynamic messages.
+type GRPCClient struct {
+	config *config.Config
+	logger *logrus.Logger
+
+	mu      sync.Mutex
+	conns   map[string]*grpc.ClientConn
+	methods map[string]protoreflect.MethodDescriptor
+}
+
+// NewGRPCClient creates a new gRPC client
+func NewGRPCClient(cfg *config.Config, logger *logrus.Logger) *GRPCClient {
+	return &GRPCClient{
+		config:  cfg,
+		logger:  logger,
+		conns:   make(map[string]*grpc.ClientConn),
+		methods: make(map[string]protoreflect.MethodDescriptor),
+	}
+}
+
+// Invoke calls the tool's gRPC method with the given parameters and returns the reply
+// as a JSON API response, so it is formatted the same way as HTTP results
+func (g *GRPCClient) Invoke(ctx context.Context, tool *config.ToolConfig, params map[string]interface{}) (*APIResponse, error) {
+	if tool.GRPC == nil {
+		return nil, fmt.Errorf("tool %s has no grpc configuration", tool.Name)
+	}
+
+	if tool.TimeoutDuration() > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tool.TimeoutDuration())
+		defer cancel()
+	}
+
+	conn, err := g.connFor(tool)
+	if err != nil {
+		return nil, err
+	}
+
+	method, err := g.methodFor(ctx, conn, tool)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := buildGRPCRequest(method.Input(), tool.GRPC, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tool.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(tool.Headers))
+	}
+
+	reply := dynamicpb.NewMessage(method.Output())
+	fullMethod := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+
+	requestLogger(ctx, g.logger).WithFields(logrus.Fields{
+		"tool_name": tool.Name,
+		"address":   tool.GRPC.Address,
+		"method":    fullMethod,
+	}).Debug("Invoking gRPC method")
+
+	if err := conn.Invoke(ctx, fullMethod, request, reply); err != nil {
+		if st, ok := status.FromError(err); ok {
+			return nil, fmt.Errorf("%s: %s", st.Code(), st.Message())
+		}
+		return nil, err
+	}
+
+	body, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(reply)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode reply: %w", err)
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode reply: %w", err)
+	}
+
+	return &APIResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+		Data:       data,
+	}, nil
+}
+
+// Close releases all connections
+func (g *GRPCClient) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for key, conn := range g.conns {
+		conn.Close()
+		delete(g.conns, key)
+	}
+}
+
+// connFor returns the tool's connection, creating it on first use. grpc.NewClient
+// connects lazily, so this never blocks on the network.
+func (g *GRPCClient) connFor(tool *config.ToolConfig) (*grpc.ClientConn, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if conn, ok := g.conns[tool.Name]; ok {
+		return conn, nil
+	}
+
+	creds := insecure.NewCredentials()
+	if !tool.GRPC.Plaintext {
+		tlsCfg := &g.config.Security.UpstreamTLS
+		if tool.TLS != nil {
+			tlsCfg = tool.TLS
+		}
+		tlsConfig, err := buildTLSConfig(tlsCfg)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(tool.GRPC.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client for %s: %w", tool.GRPC.Address, err)
+	}
+	g.conns[tool.Name] = conn
+	return conn, nil
+}
+
+// methodFor resolves the tool's method descriptor through reflection, once per tool
+func (g *GRPCClient) methodFor(ctx context.Context, conn *grpc.ClientConn, tool *config.ToolConfig) (protoreflect.MethodDescriptor, error) {
+	g.mu.Lock()
+	method, ok := g.methods[tool.Name]
+	g.mu.Unlock()
+	if ok {
+		return method, nil
+	}
+
+	method, err := resolveGRPCMethod(ctx, conn, tool.GRPC.Service, tool.GRPC.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	g.methods[tool.Name] = method
+	g.mu.Unlock()
+	return method, nil
+}
+
+// resolveGRPCMethod downloads the file defining service, plus everything it imports,
+// from the server's reflection service and looks up the named method
+func resolveGRPCMethod(ctx context.Context, conn *grpc.ClientConn, service, methodName string) (protoreflect.MethodDescriptor, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("server reflection unavailable: %w", err)
+	}
+	defer stream.CloseSend()
+
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	fetch := func(req *reflectionpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); err != nil {
+			return fmt.Errorf("server reflection failed: %w", err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("server reflection failed: %w", err)
+		}
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return fmt.Errorf("server reflection error: %s", errResp.GetErrorMessage())
+		}
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, file); err != nil {
+				return fmt.Errorf("invalid file descriptor from server: %w", err)
+			}
+			files[file.GetName()] = file
+		}
+		return nil
+	}
+
+	if err := fetch(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	}); err != nil {
+		return nil, err
+	}
+
+	// Servers usually send dependencies along with the file, but are not required to
+	for missing := missingGRPCDependency(files); missing != ""; missing = missingGRPCDependency(files) {
+		if err := fetch(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: missing},
+		}); err != nil {
+			return nil, err
+		}
+		if _, ok := files[missing]; !ok {
+			return nil, fmt.Errorf("server did not return %s", missing)
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range files {
+		set.File = append(set.File, file)
+	}
+	registry, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build descriptors: %w", err)
+	}
+
+	desc, err := registry.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", service, err)
+	}
+	serviceDesc, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+
+	method := serviceDesc.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("method %s not found on %s", methodName, service)
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, fmt.Errorf("method %s/%s is streaming; only unary methods are supported", service, methodName)
+	}
+	return method, nil
+}
+
+// missingGRPCDependency returns an import not yet downloaded, or "" when none remain
+func missingGRPCDependency(files map[string]*descriptorpb.FileDescriptorProto) string {
+	for _, file := range files {
+		for _, dep := range file.GetDependency() {
+			if _, ok := files[dep]; !ok {
+				return dep
+			}
+		}
+	}
+	return ""
+}
+
+// buildGRPCRequest fills a request message from tool parameters using the proto JSON
+// mapping, so nested messages, enums by name and well-known types all work
+func buildGRPCRequest(input protoreflect.MessageDescriptor, grpcCfg *config.GRPCConfig, params map[string]interface{}) (proto.Message, error) {
+	mapped := make(map[string]bool, len(grpcCfg.Fields))
+	fields := make(map[string]interface{}, len(params))
+	for field, param := range grpcCfg.Fields {
+		mapped[param] = true
+		if value, ok := params[param]; ok {
+			fields[field] = value
+		}
+	}
+	for name, value := range params {
+		if !mapped[name] {
+			fields[name] = value
+		}
+	}
+
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request fields: %w", err)
+	}
+
+	request := dynamicpb.NewMessage(input)
+	if err := protojson.Unmarshal(encoded, request); err != nil {
+		return nil, fmt.Errorf("parameters do not match %s: %s", input.FullName(), strings.TrimSpace(err.Error()))
+	}
+	return request, nil
+}
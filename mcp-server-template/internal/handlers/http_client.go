@@ -3,108 +3,230 @@ package handlers
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
-	"text/template"
+	"sync"
 	"time"
 
 	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/metrics"
 
 	"github.com/sirupsen/logrus"
 )
 
+// defaultUserAgent is sent when the runtime config leaves user_agent unset
+const defaultUserAgent = "MCP-Server/1.0.0"
+
 // HTTPClient handles HTTP requests for tool execution
 type HTTPClient struct {
-	client *http.Client
-	logger *logrus.Logger
+	client    *http.Client
+	clientErr error // set when the shared TLS or proxy settings could not be loaded
+	config    *config.Config
+	cache     *responseCache
+	balancer  *endpointBalancer
+	breakers  *circuitBreakers
+	sessions  *cookieSessions
+	logger    *logrus.Logger
+
+	mu          sync.Mutex
+	toolClients map[string]*http.Client // tools with their own TLS settings
 }
 
 // NewHTTPClient creates a new HTTP client with appropriate configuration
-func NewHTTPClient() *HTTPClient {
-	// Create HTTP client with reasonable defaults
+func NewHTTPClient(cfg *config.Config, logger *logrus.Logger) *HTTPClient {
+	tlsConfig, err := buildTLSConfig(&cfg.Security.UpstreamTLS)
+	if err != nil {
+		// Surfaced on every call instead of silently falling back to default verification
+		logger.WithError(err).Error("Failed to load upstream TLS settings")
+	}
+
+	proxy, proxyErr := proxyFunc(cfg.Runtime.ProxyURL)
+	if proxyErr != nil {
+		logger.WithError(proxyErr).Error("Failed to configure upstream proxy")
+		err = errors.Join(err, proxyErr)
+	}
+
 	client := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:       100,
-			IdleConnTimeout:    90 * time.Second,
-			DisableCompression: false,
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: false, // Should be configurable in production
-			},
-		},
+		Timeout:   httpTimeout(&cfg.Runtime.HTTPClient),
+		Transport: newHTTPTransport(tlsConfig, proxy, &cfg.Runtime.HTTPClient),
 	}
 
 	return &HTTPClient{
-		client: client,
-		logger: logrus.New(),
+		client:      client,
+		clientErr:   err,
+		config:      cfg,
+		cache:       newResponseCache(),
+		balancer:    newEndpointBalancer(),
+		breakers:    newCircuitBreakers(),
+		sessions:    newCookieSessions(),
+		logger:      logger,
+		toolClients: make(map[string]*http.Client),
 	}
 }
 
 // ExecuteRequest executes an HTTP request based on tool configuration
 func (h *HTTPClient) ExecuteRequest(ctx context.Context, tool *config.ToolConfig, params map[string]interface{}) (*APIResponse, error) {
+	if tool.CircuitBreaker == nil {
+		return h.execute(ctx, tool, params)
+	}
+
+	if err := h.breakers.Allow(tool); err != nil {
+		return nil, err
+	}
+
+	apiResp, err := h.execute(ctx, tool, params)
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		// The caller hung up; that says nothing about the upstream's health
+		h.breakers.Release(tool)
+	case err != nil || apiResp.StatusCode >= 500:
+		h.breakers.Record(tool, false)
+	default:
+		h.breakers.Record(tool, true)
+	}
+	return apiResp, err
+}
+
+// execute performs the request, consulting the cache and retrying per the tool's settings
+func (h *HTTPClient) execute(ctx context.Context, tool *config.ToolConfig, params map[string]interface{}) (*APIResponse, error) {
 	// Set timeout for this request
-	if tool.Timeout > 0 {
+	if tool.TimeoutDuration() > 0 {
 		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, tool.Timeout.ToDuration())
+		ctx, cancel = context.WithTimeout(ctx, tool.TimeoutDuration())
 		defer cancel()
 	}
 	startTime := time.Now()
+	log := requestLogger(ctx, h.logger)
 
-	h.logger.WithFields(logrus.Fields{
+	log.WithFields(logrus.Fields{
 		"tool_name": tool.Name,
 		"endpoint":  tool.Endpoint,
 		"method":    tool.Method,
 	}).Debug("Executing HTTP request")
 
-	// Execute request with retries
-	var resp *http.Response
-	var lastErr error
+	params, noCache := popNoCache(params)
 
-	for attempt := 0; attempt <= tool.Retries; attempt++ {
-		// Rebuild request each attempt to avoid issues with consumed bodies
+	// Serve idempotent requests from the response cache when configured
+	var cacheKey string
+	if tool.CacheTTL > 0 && isCacheableMethod(tool.Method) {
 		req, err := h.buildRequest(ctx, tool, params)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build request: %w", err)
 		}
+		cacheKey = responseCacheKey(tool.Method, req.URL.String(), params)
+
+		if !noCache {
+			if cached, ok := h.cache.Get(cacheKey); ok {
+				log.WithField("tool_name", tool.Name).Debug("Serving response from cache")
+				return cached, nil
+			}
+		}
+	}
+
+	client, err := h.clientFor(tool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure HTTP client: %w", err)
+	}
+	client = h.withCookies(client, tool)
+
+	// Execute request with retries
+	var resp *http.Response
+	var lastErr error
+	var delay time.Duration
+
+	for attempt := 0; attempt <= tool.RetryCount(); attempt++ {
 		if attempt > 0 {
-			h.logger.WithFields(logrus.Fields{
+			log.WithFields(logrus.Fields{
 				"tool_name": tool.Name,
 				"attempt":   attempt,
+				"delay_ms":  delay.Milliseconds(),
 			}).Warn("Retrying request")
 
-			// Exponential backoff
-			backoff := time.Duration(attempt) * time.Second
-			time.Sleep(backoff)
+			// Back off, abandoning the wait as soon as the caller gives up
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, fmt.Errorf("request cancelled: %w", ctx.Err())
+			}
+		}
+
+		// Pick a replica per attempt when the tool is balanced across endpoints
+		target := tool
+		if len(tool.Endpoints) > 0 {
+			balanced := *tool
+			balanced.Endpoint = h.balancer.Next(tool)
+			target = &balanced
+		}
+
+		// Rebuild request each attempt to avoid issues with consumed bodies
+		req, err := h.buildRequest(ctx, target, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build request: %w", err)
 		}
 
-		resp, lastErr = h.client.Do(req)
+		attemptStart := time.Now()
+		resp, lastErr = client.Do(req)
+		metrics.UpstreamDuration.WithLabelValues(tool.Name).Observe(time.Since(attemptStart).Seconds())
+		if lastErr != nil {
+			metrics.UpstreamErrors.WithLabelValues(tool.Name, upstreamErrorReason(ctx, lastErr)).Inc()
+		} else {
+			metrics.UpstreamResponses.WithLabelValues(tool.Name, strconv.Itoa(resp.StatusCode)).Inc()
+		}
+		if len(tool.Endpoints) > 0 {
+			h.balancer.Record(tool, target.Endpoint, lastErr == nil && resp.StatusCode < 500)
+		}
 		if lastErr == nil && h.isSuccessStatusCode(resp.StatusCode, tool.Validation) {
 			break
 		}
 
+		// Non-retryable responses (e.g. most 4xx) are returned as-is without burning retries
+		if lastErr == nil && !isRetryableStatus(resp.StatusCode, tool.Retry) {
+			break
+		}
+
+		// Keep the final response for processing once retries are exhausted or the
+		// client disconnected / the deadline passed
+		if attempt == tool.RetryCount() || ctx.Err() != nil {
+			break
+		}
+
+		delay = retryDelay(tool.Retry, attempt+1, resp)
 		if resp != nil {
 			resp.Body.Close()
 		}
 	}
 
 	if lastErr != nil {
-		return nil, fmt.Errorf("request failed after %d attempts: %w", tool.Retries+1, lastErr)
+		return nil, fmt.Errorf("request failed after %d attempts: %w", tool.RetryCount()+1, lastErr)
 	}
 
 	// Process response
-	apiResp, err := h.processResponse(resp, tool)
+	apiResp, err := h.processResponse(ctx, resp, tool)
 	if err != nil {
 		return nil, fmt.Errorf("failed to process response: %w", err)
 	}
 
+	if tool.Pagination != nil && h.isSuccessStatusCode(apiResp.StatusCode, tool.Validation) {
+		apiResp, err = h.followPages(ctx, client, tool, params, apiResp, resp.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("pagination failed: %w", err)
+		}
+	}
+
+	if cacheKey != "" && h.isSuccessStatusCode(apiResp.StatusCode, tool.Validation) {
+		h.cache.Set(tool.Name, cacheKey, apiResp, tool.CacheTTL.ToDuration())
+	}
+
 	duration := time.Since(startTime)
-	h.logger.WithFields(logrus.Fields{
+	log.WithFields(logrus.Fields{
 		"tool_name":   tool.Name,
 		"status_code": resp.StatusCode,
 		"duration_ms": duration.Milliseconds(),
@@ -113,17 +235,67 @@ func (h *HTTPClient) ExecuteRequest(ctx context.Context, tool *config.ToolConfig
 	return apiResp, nil
 }
 
+// EndpointStats returns per-endpoint outcome counts for tools balanced across replicas
+func (h *HTTPClient) EndpointStats() []EndpointStats {
+	return h.balancer.Stats()
+}
+
+// upstreamErrorReason classifies a transport error for the upstream error counter
+func upstreamErrorReason(ctx context.Context, err error) string {
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return "cancelled"
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "network"
+	}
+}
+
+// BreakerStats returns the state of every circuit breaker that has seen traffic
+func (h *HTTPClient) BreakerStats() []BreakerStats {
+	return h.breakers.Stats()
+}
+
+// FlushState clears cached responses, endpoint cooldowns, circuit breakers and session
+// cookies for toolName, or for every tool when toolName is empty
+func (h *HTTPClient) FlushState(toolName string) FlushResult {
+	result := FlushResult{
+		CacheEntries:   h.cache.Flush(toolName),
+		EndpointsReset: h.balancer.Reset(toolName),
+		BreakersReset:  h.breakers.Reset(toolName),
+	}
+	if toolName == "" {
+		result.SessionsReset = h.sessions.Reset("")
+	} else {
+		for i := range h.config.Tools {
+			if tool := &h.config.Tools[i]; tool.Name == toolName {
+				if name := sessionName(tool, h.config); name != "" {
+					result.SessionsReset = h.sessions.Reset(name)
+				}
+			}
+		}
+	}
+	return result
+}
+
 // buildRequest constructs an HTTP request from tool configuration and parameters
 func (h *HTTPClient) buildRequest(ctx context.Context, tool *config.ToolConfig, params map[string]interface{}) (*http.Request, error) {
+	data := templateData(ctx, params)
+
 	// Expand endpoint template with params first (e.g., /users/{{.username}})
 	expandedEndpoint := tool.Endpoint
 	if strings.Contains(expandedEndpoint, "{{") {
 		var err error
-		expandedEndpoint, err = h.expandTemplate(expandedEndpoint, params)
+		expandedEndpoint, err = h.expandTemplate(expandedEndpoint, data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to expand endpoint template: %w", err)
 		}
 	}
+	expandedEndpoint, err := expandPathParams(expandedEndpoint, tool, params)
+	if err != nil {
+		return nil, err
+	}
 
 	// Parse and build URL with query parameters
 	parsedURL, err := url.Parse(expandedEndpoint)
@@ -134,19 +306,20 @@ func (h *HTTPClient) buildRequest(ctx context.Context, tool *config.ToolConfig,
 	// Add configured query parameters
 	query := parsedURL.Query()
 	for key, value := range tool.QueryParams {
-		expandedValue, err := h.expandTemplate(value, params)
+		expandedValue, err := h.expandTemplate(value, data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to expand query param %s: %w", key, err)
 		}
 		query.Set(key, expandedValue)
 	}
 
-	// Add parameter-based query parameters for GET requests
-	if strings.ToUpper(tool.Method) == "GET" {
-		for _, param := range tool.Parameters {
-			if value, exists := params[param.Name]; exists {
-				query.Set(param.Name, fmt.Sprintf("%v", value))
-			}
+	// Add parameters that belong in the query: all of them for GET, unless placed elsewhere
+	for _, param := range tool.Parameters {
+		if !sentInQuery(&param, tool.Method) {
+			continue
+		}
+		if value, exists := params[param.Name]; exists {
+			setQueryValue(query, &param, value)
 		}
 	}
 
@@ -154,39 +327,75 @@ func (h *HTTPClient) buildRequest(ctx context.Context, tool *config.ToolConfig,
 
 	// Build request body
 	var body io.Reader
-	if tool.BodyTemplate != "" && (strings.ToUpper(tool.Method) != "GET") {
-		bodyContent, err := h.expandTemplate(tool.BodyTemplate, params)
+	contentType := requestContentType(tool)
+	if tool.Kind == "graphql" {
+		graphQLBody, err := buildGraphQLBody(tool, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build graphql body: %w", err)
+		}
+		body = bytes.NewReader(graphQLBody)
+		contentType = "application/json"
+	} else if tool.BodyType == "multipart" && strings.ToUpper(tool.Method) != "GET" {
+		multipartBody, multipartType, err := h.buildMultipartBody(tool, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build multipart body: %w", err)
+		}
+		body = multipartBody
+		contentType = multipartType
+	} else if tool.BodyTemplate != "" && (strings.ToUpper(tool.Method) != "GET") {
+		bodyContent, err := h.expandTemplate(tool.BodyTemplate, data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to expand body template: %w", err)
 		}
 		body = strings.NewReader(bodyContent)
-	} else if strings.ToUpper(tool.Method) != "GET" && len(params) > 0 {
-		// Default JSON body for non-GET requests
-		jsonBody, err := json.Marshal(params)
+	} else if bodyArgs := bodyArguments(tool, params); len(bodyArgs) > 0 && isFormContentType(contentType) {
+		formBody, err := encodeFormBody(bodyArgs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode form body: %w", err)
+		}
+		body = strings.NewReader(formBody)
+	} else if len(bodyArgs) > 0 {
+		// Default JSON body of the arguments not placed elsewhere
+		jsonBody, err := json.Marshal(bodyArgs)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal parameters to JSON: %w", err)
 		}
 		body = bytes.NewReader(jsonBody)
 	}
 
+	body, gzipped, err := gzipRequestBody(body, tool.Compression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(tool.Method), parsedURL.String(), body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set content type
-	if tool.ContentType != "" && body != nil {
-		req.Header.Set("Content-Type", tool.ContentType)
+	// Headers are set from least to most specific: the generated body's content type and
+	// the defaults, then header parameters, then configured headers, then authentication
+	if contentType != "" && body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
 	}
 
-	// Set default headers for better API compatibility
-	req.Header.Set("User-Agent", "MCP-Server/1.0.0")
+	// Default headers for better API compatibility
+	req.Header.Set("User-Agent", h.userAgent())
 	req.Header.Set("Accept", "application/json, text/plain, */*")
+	if tool.Compression != nil && tool.Compression.AcceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", tool.Compression.AcceptEncoding)
+	}
+
+	// Parameters placed in headers
+	setParamHeaders(req.Header, tool, params)
 
 	// Add configured headers
 	for key, value := range tool.Headers {
-		expandedValue, err := h.expandTemplate(value, params)
+		expandedValue, err := h.expandTemplate(value, data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to expand header %s: %w", key, err)
 		}
@@ -200,9 +409,38 @@ func (h *HTTPClient) buildRequest(ctx context.Context, tool *config.ToolConfig,
 		}
 	}
 
+	// Forward the correlation id unless the tool already configured that header
+	if header := h.config.Runtime.RequestIDHeader; header != "" && req.Header.Get(header) == "" {
+		if requestID := RequestIDFromContext(ctx); requestID != "" {
+			req.Header.Set(header, requestID)
+		}
+	}
+
 	return req, nil
 }
 
+// requestContentType is the content type a tool's body is encoded for: a Content-Type in
+// its configured headers, which is what will be sent, or else its content_type
+func requestContentType(tool *config.ToolConfig) string {
+	if value, ok := configuredHeader(tool.Headers, "Content-Type"); ok {
+		if mediaType, _, err := mime.ParseMediaType(value); err == nil {
+			return mediaType
+		}
+		return value
+	}
+	return tool.ContentType
+}
+
+// configuredHeader looks up a header in a tool's headers, ignoring case as HTTP does
+func configuredHeader(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
 // applyAuthentication applies authentication configuration to the request
 func (h *HTTPClient) applyAuthentication(req *http.Request, auth *config.AuthConfig) error {
 	switch auth.Type {
@@ -251,9 +489,20 @@ func (h *HTTPClient) applyAuthentication(req *http.Request, auth *config.AuthCon
 	return nil
 }
 
+// userAgent is the User-Agent sent when a tool doesn't set one
+func (h *HTTPClient) userAgent() string {
+	if h.config != nil && h.config.Runtime.UserAgent != "" {
+		return h.config.Runtime.UserAgent
+	}
+	return defaultUserAgent
+}
+
 // expandTemplate expands a template string with parameter values
 func (h *HTTPClient) expandTemplate(templateStr string, params map[string]interface{}) (string, error) {
-	tmpl, err := template.New("expand").Parse(templateStr)
+	if err := missingSessionValue(templateStr, params); err != nil {
+		return "", err
+	}
+	tmpl, err := parseToolTemplate("expand", templateStr)
 	if err != nil {
 		return "", fmt.Errorf("invalid template: %w", err)
 	}
@@ -267,11 +516,20 @@ func (h *HTTPClient) expandTemplate(templateStr string, params map[string]interf
 }
 
 // processResponse processes the HTTP response and extracts data
-func (h *HTTPClient) processResponse(resp *http.Response, tool *config.ToolConfig) (*APIResponse, error) {
+func (h *HTTPClient) processResponse(ctx context.Context, resp *http.Response, tool *config.ToolConfig) (*APIResponse, error) {
 	defer resp.Body.Close()
 
-	// Read response body
-	bodyBytes, err := io.ReadAll(resp.Body)
+	// Read response body, cutting off bodies too large to hold in memory
+	limit := h.maxResponseBytes()
+	body, err := decodedBody(resp, tool.Compression != nil && tool.Compression.AcceptEncoding != "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
+	size := resp.ContentLength
+	if body != io.Reader(resp.Body) {
+		size = -1 // the length is of the encoded body
+	}
+	bodyBytes, truncated, err := readBody(ctx, body, limit, size, tool.Streaming)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
@@ -281,6 +539,14 @@ func (h *HTTPClient) processResponse(resp *http.Response, tool *config.ToolConfi
 		StatusCode: resp.StatusCode,
 		Headers:    make(map[string]string),
 		Body:       string(bodyBytes),
+		Truncated:  truncated,
+	}
+	if truncated {
+		apiResp.Body += truncationMarker(limit)
+		requestLogger(ctx, h.logger).WithFields(logrus.Fields{
+			"tool_name": tool.Name,
+			"limit":     limit,
+		}).Warn("Upstream response exceeded max_response_bytes and was truncated")
 	}
 
 	// Copy response headers
@@ -290,15 +556,25 @@ func (h *HTTPClient) processResponse(resp *http.Response, tool *config.ToolConfi
 		}
 	}
 
-	// Parse JSON response if applicable
+	// Parse JSON or XML responses so Data can be navigated; Body keeps the raw text
 	contentType := resp.Header.Get("Content-Type")
-	if strings.Contains(contentType, "application/json") && len(bodyBytes) > 0 {
+	switch {
+	case tool.Streaming || truncated:
+		// Passed on as text: streaming tools skip parsing, and a cut-off body won't parse
+	case strings.Contains(contentType, "application/json") && len(bodyBytes) > 0:
 		var jsonData interface{}
 		if err := json.Unmarshal(bodyBytes, &jsonData); err != nil {
 			h.logger.WithError(err).Warn("Failed to parse JSON response, returning raw body")
 		} else {
 			apiResp.Data = jsonData
 		}
+	case isXMLContentType(contentType) && len(bodyBytes) > 0:
+		xmlData, err := decodeXML(bodyBytes)
+		if err != nil {
+			h.logger.WithError(err).Warn("Failed to parse XML response, returning raw body")
+		} else {
+			apiResp.Data = xmlData
+		}
 	}
 
 	// Validate response if validation rules are configured
@@ -359,10 +635,20 @@ func (h *HTTPClient) validateResponse(resp *APIResponse, validation *config.Vali
 	return nil
 }
 
+// FlushResult reports what an administrative flush cleared
+type FlushResult struct {
+	CacheEntries    int `json:"cache_entries"`
+	EndpointsReset  int `json:"endpoints_reset"`
+	BreakersReset   int `json:"breakers_reset"`
+	SessionsReset   int `json:"sessions_reset"`   // Cookie jars cleared
+	ResourceEntries int `json:"resource_entries"` // Cached URL resources dropped
+}
+
 // APIResponse represents the response from an API call
 type APIResponse struct {
 	StatusCode int               `json:"status_code"`
 	Headers    map[string]string `json:"headers"`
 	Body       string            `json:"body"`
 	Data       interface{}       `json:"data,omitempty"`
+	Truncated  bool              `json:"truncated,omitempty"` // Body was cut at max_response_bytes
 }
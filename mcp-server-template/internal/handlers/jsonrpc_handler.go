@@ -1,25 +1,53 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	"mcp-server-template/internal/config"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/semaphore"
 )
 
+// protocolVersion is the MCP revision this server implements
+const protocolVersion = "2024-11-05"
+
+// defaultMaxRequestBytes caps request bodies when the runtime config leaves
+// max_request_bytes unset
+const defaultMaxRequestBytes = 4 << 20
+
 // JSONRPCHandler handles MCP JSON-RPC requests over HTTP
 type JSONRPCHandler struct {
-	config      *config.Config
-	toolHandler *ToolHandler
-	logger      *logrus.Logger
-	mcpServer   interface{} // Store reference to MCP server if needed
+	config        *config.Config
+	toolHandler   *ToolHandler
+	resources     *ResourceLoader
+	logger        *logrus.Logger
+	hub           *notificationHub
+	logs          *logNotifier
+	subscriptions *resourceSubscriptions
+	requests      *inflightRequests
+	inflight      *semaphore.Weighted // Bounds concurrent tools/call; nil means unlimited
+	mcpServer     interface{}         // Store reference to MCP server if needed
+
+	// Tool call tracking for graceful shutdown
+	baseCtx     context.Context
+	cancelBase  context.CancelFunc
+	drainMu     sync.Mutex
+	draining    bool
+	active      sync.WaitGroup
+	activeCount atomic.Int64
 }
 
 // JSONRPCRequest represents a JSON-RPC 2.0 request
@@ -28,6 +56,28 @@ type JSONRPCRequest struct {
 	ID      interface{} `json:"id"`
 	Method  string      `json:"method"`
 	Params  interface{} `json:"params,omitempty"`
+
+	hasID bool
+}
+
+// UnmarshalJSON decodes the request and records whether an id member was present,
+// since a missing id (notification) differs from an explicit null id
+func (r *JSONRPCRequest) UnmarshalJSON(data []byte) error {
+	type plain JSONRPCRequest
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	_, r.hasID = members["id"]
+	return nil
+}
+
+// IsNotification reports whether the request carried no id and expects no response
+func (r *JSONRPCRequest) IsNotification() bool {
+	return !r.hasID
 }
 
 // JSONRPCResponse represents a JSON-RPC 2.0 response
@@ -45,71 +95,241 @@ type JSONRPCError struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
-// NewJSONRPCHandler creates a new JSON-RPC handler
-func NewJSONRPCHandler(cfg *config.Config, toolHandler *ToolHandler) *JSONRPCHandler {
-	return &JSONRPCHandler{
+// Error formats the error with its code and, when present, its data
+func (e *JSONRPCError) Error() string {
+	if e.Data != nil {
+		return fmt.Sprintf("JSON-RPC error %d: %s: %v", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// NewJSONRPCHandler creates a new JSON-RPC handler. logger's level is the level of the
+// log notifications a connection receives until it calls logging/setLevel.
+func NewJSONRPCHandler(cfg *config.Config, toolHandler *ToolHandler, resources *ResourceLoader, logger *logrus.Logger) *JSONRPCHandler {
+	hub := newNotificationHub()
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	h := &JSONRPCHandler{
 		config:      cfg,
 		toolHandler: toolHandler,
-		logger:      logrus.New(),
+		resources:   resources,
+		logger:      logger,
+		hub:         hub,
+		logs:        newLogNotifier(hub, logger.GetLevel()),
+		requests:    newInflightRequests(),
+		baseCtx:     baseCtx,
+		cancelBase:  cancelBase,
+	}
+	h.subscriptions = newResourceSubscriptions(logger, func(clientID, uri string) {
+		hub.Send(clientID, "notifications/resources/updated", map[string]interface{}{"uri": uri})
+	})
+	if cfg.Runtime.MaxConcurrentRequests > 0 {
+		h.inflight = semaphore.NewWeighted(int64(cfg.Runtime.MaxConcurrentRequests))
+	}
+
+	// Hook every handler's logger so records from a connection's requests reach it. They
+	// normally share one logger, which Attach only hooks once.
+	h.logs.Attach(h.logger)
+	h.logs.Attach(toolHandler.logger)
+	h.logs.Attach(toolHandler.httpClient.logger)
+	h.logs.Attach(toolHandler.grpcClient.logger)
+	h.logs.Attach(toolHandler.execRunner.logger)
+	if resources != nil {
+		h.logs.Attach(resources.logger)
+	}
+
+	return h
+}
+
+// Notifications subscribes a streaming connection to server-initiated notifications.
+// clientID identifies the connection, as set on its requests' contexts. The returned
+// function must be called when the client disconnects; it also drops the client's
+// resource subscriptions and the values its tool calls saved. resources/subscribe is
+// only accepted between the two, so a request still running when the client leaves
+// can't leave a watch behind.
+func (h *JSONRPCHandler) Notifications(clientID string) (<-chan *JSONRPCNotification, func()) {
+	ch, unsubscribe := h.hub.Subscribe(clientID)
+	h.subscriptions.Open(clientID)
+	return ch, func() {
+		unsubscribe()
+		h.subscriptions.DropClient(clientID)
+		h.toolHandler.contexts.Drop(clientID)
+		h.logs.Drop(clientID)
 	}
 }
 
 // ServeHTTP implements http.Handler for JSON-RPC requests
 func (h *JSONRPCHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Always set CORS headers for web clients like Cursor
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-	w.Header().Set("Access-Control-Max-Age", "86400")
-
-	// Handle preflight OPTIONS request
+	// Preflights normally stop at the server's CORS middleware, which sets the headers
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
+	// Echo the correlation id so clients can match their request to server logs
+	requestID := requestIDFromHTTP(r)
+	w.Header().Set(RequestIDHeader, requestID)
+
 	if r.Method != http.MethodPost {
-		h.writeError(w, nil, -32600, "Invalid Request", "Only POST method is allowed")
+		h.writeResponse(w, h.errorResponse(nil, -32600, "Invalid Request", "Only POST method is allowed"))
 		return
 	}
 
-	var req JSONRPCRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, nil, -32700, "Parse error", err.Error())
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.MaxRequestBytes()))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.writeResponseStatus(w, http.StatusRequestEntityTooLarge, h.errorResponse(nil, -32600, "Invalid Request", fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit)))
+			return
+		}
+		h.writeResponse(w, h.errorResponse(nil, -32700, "Parse error", err.Error()))
 		return
 	}
 
-	h.logger.WithFields(logrus.Fields{
+	ctx := WithRequestID(r.Context(), requestID)
+
+	response := h.HandleMessage(ctx, body)
+	if response == nil {
+		// Nothing to return, e.g. a batch made up solely of notifications
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.writeResponse(w, response)
+}
+
+// MaxRequestBytes returns the largest request body, in bytes, any transport accepts
+func (h *JSONRPCHandler) MaxRequestBytes() int64 {
+	if h.config.Runtime.MaxRequestBytes > 0 {
+		return h.config.Runtime.MaxRequestBytes
+	}
+	return defaultMaxRequestBytes
+}
+
+// HandleMessage processes a raw JSON-RPC message (a single request or a batch) and
+// returns the response payload to send back, or nil when no response is due. It is
+// shared by every transport so method handling stays identical across them.
+func (h *JSONRPCHandler) HandleMessage(ctx context.Context, body []byte) interface{} {
+	// A JSON array is a batch of requests processed in order
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		return h.handleBatch(ctx, trimmed)
+	}
+
+	var req JSONRPCRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return h.errorResponse(nil, -32700, "Parse error", err.Error())
+	}
+
+	resp := h.dispatch(ctx, &req)
+	if req.IsNotification() || resp == nil {
+		// Notifications are processed for their side effects but never answered
+		return nil
+	}
+	return resp
+}
+
+// handleBatch handles a JSON-RPC batch, returning responses in request order and
+// omitting entries for notifications
+func (h *JSONRPCHandler) handleBatch(ctx context.Context, body []byte) interface{} {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(body, &elements); err != nil {
+		return h.errorResponse(nil, -32700, "Parse error", err.Error())
+	}
+
+	if len(elements) == 0 {
+		return h.errorResponse(nil, -32600, "Invalid Request", "Empty batch")
+	}
+
+	requestLogger(ctx, h.logger).WithField("batch_size", len(elements)).Debug("Handling JSON-RPC batch")
+
+	responses := make([]*JSONRPCResponse, 0, len(elements))
+	for _, element := range elements {
+		var req JSONRPCRequest
+		if err := json.Unmarshal(element, &req); err != nil {
+			responses = append(responses, h.errorResponse(nil, -32600, "Invalid Request", "Batch element is not a valid request object"))
+			continue
+		}
+
+		resp := h.dispatch(ctx, &req)
+		if req.IsNotification() || resp == nil {
+			continue
+		}
+		responses = append(responses, resp)
+	}
+
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+// dispatch handles a single JSON-RPC request. It returns nil when the client cancelled
+// the request before it finished.
+func (h *JSONRPCHandler) dispatch(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	requestLogger(ctx, h.logger).WithFields(logrus.Fields{
 		"method": req.Method,
 		"id":     req.ID,
 	}).Debug("Handling JSON-RPC request")
 
-	// Handle different MCP methods
+	// Only requests on a streaming connection can be cancelled. Plain POST requests have
+	// no connection to scope their ids to, so any caller could cancel anyone's call.
+	clientID := clientIDFromContext(ctx)
+	if req.IsNotification() || clientID == "" {
+		return h.route(ctx, req)
+	}
+
+	// Track the request so a notifications/cancelled from the client can abort it
+	ctx, finish := h.requests.Track(ctx, clientID, req.ID)
+	resp := h.route(ctx, req)
+	if finish() {
+		// The client has abandoned the request and expects no response to it
+		requestLogger(ctx, h.logger).WithFields(logrus.Fields{
+			"method": req.Method,
+			"id":     req.ID,
+		}).Info("Request cancelled by client")
+		return nil
+	}
+	return resp
+}
+
+// route calls the method handler for a request
+func (h *JSONRPCHandler) route(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	switch req.Method {
 	case "initialize":
-		h.handleInitialize(w, &req)
-	case "initialized":
-		h.handleInitialized(w, &req)
+		return h.handleInitialize(ctx, req)
+	case "initialized", "notifications/initialized":
+		return h.handleInitialized(req)
+	case "notifications/cancelled":
+		return h.handleCancelled(ctx, req)
 	case "tools/list":
-		h.handleToolsList(w, &req)
+		return h.handleToolsList(req)
 	case "tools/call":
-		h.handleToolsCall(w, &req)
+		return h.handleToolsCall(ctx, req)
 	case "prompts/list":
-		h.handlePromptsList(w, &req)
+		return h.handlePromptsList(req)
 	case "prompts/get":
-		h.handlePromptsGet(w, &req)
+		return h.handlePromptsGet(ctx, req)
 	case "resources/list":
-		h.handleResourcesList(w, &req)
+		return h.handleResourcesList(req)
+	case "resources/templates/list":
+		return h.handleResourceTemplatesList(req)
 	case "resources/read":
-		h.handleResourcesRead(w, &req)
+		return h.handleResourcesRead(ctx, req)
+	case "resources/subscribe":
+		return h.handleResourcesSubscribe(ctx, req)
+	case "resources/unsubscribe":
+		return h.handleResourcesUnsubscribe(ctx, req)
+	case "completion/complete":
+		return h.handleCompletionComplete(req)
+	case "logging/setLevel":
+		return h.handleLoggingSetLevel(ctx, req)
 	case "ping":
-		h.handlePing(w, &req)
+		return h.handlePing(req)
 	default:
-		h.writeError(w, req.ID, -32601, "Method not found", fmt.Sprintf("Unknown method: %s", req.Method))
+		return h.errorResponse(req.ID, -32601, "Method not found", fmt.Sprintf("Unknown method: %s", req.Method))
 	}
 }
 
-func (h *JSONRPCHandler) handleInitialize(w http.ResponseWriter, req *JSONRPCRequest) {
+func (h *JSONRPCHandler) handleInitialize(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	// Parse initialize params
 	var params struct {
 		ProtocolVersion string `json:"protocolVersion"`
@@ -126,7 +346,9 @@ func (h *JSONRPCHandler) handleInitialize(w http.ResponseWriter, req *JSONRPCReq
 
 	if req.Params != nil {
 		paramBytes, _ := json.Marshal(req.Params)
-		json.Unmarshal(paramBytes, &params)
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+		}
 	}
 
 	h.logger.WithFields(logrus.Fields{
@@ -135,9 +357,15 @@ func (h *JSONRPCHandler) handleInitialize(w http.ResponseWriter, req *JSONRPCReq
 		"protocol_version": params.ProtocolVersion,
 	}).Info("MCP client initializing")
 
-	// Return server capabilities
-	result := map[string]interface{}{
-		"protocolVersion": "2024-11-05",
+	return h.successResponse(req.ID, h.InitializeResult(clientIDFromContext(ctx) != ""))
+}
+
+// InitializeResult returns the protocol version, capabilities and server info sent in
+// reply to initialize. Resource subscriptions are only offered to streaming clients,
+// since plain POST requests have nowhere to deliver updates.
+func (h *JSONRPCHandler) InitializeResult(streaming bool) map[string]interface{} {
+	return map[string]interface{}{
+		"protocolVersion": protocolVersion,
 		"capabilities": map[string]interface{}{
 			"tools": map[string]interface{}{
 				"listChanged": true,
@@ -146,8 +374,11 @@ func (h *JSONRPCHandler) handleInitialize(w http.ResponseWriter, req *JSONRPCReq
 				"listChanged": true,
 			},
 			"resources": map[string]interface{}{
+				"subscribe":   streaming,
 				"listChanged": true,
 			},
+			"logging":     map[string]interface{}{},
+			"completions": map[string]interface{}{},
 		},
 		"serverInfo": map[string]interface{}{
 			"name":    h.config.Server.Name,
@@ -155,61 +386,74 @@ func (h *JSONRPCHandler) handleInitialize(w http.ResponseWriter, req *JSONRPCReq
 		},
 		"instructions": "MCP Server ready for tool, prompt, and resource operations",
 	}
-
-	h.writeSuccess(w, req.ID, result)
 }
 
-func (h *JSONRPCHandler) handleInitialized(w http.ResponseWriter, req *JSONRPCRequest) {
+func (h *JSONRPCHandler) handleInitialized(req *JSONRPCRequest) *JSONRPCResponse {
 	h.logger.Info("MCP client initialized")
-	// Return empty success response for initialized notification
-	h.writeSuccess(w, req.ID, map[string]interface{}{})
+	// Sent as a notification, so this only reaches clients that gave it an id
+	return h.successResponse(req.ID, map[string]interface{}{})
+}
+
+// handleCancelled aborts an in-flight request from the same connection, so its
+// upstream call stops too. Unknown or finished requests are ignored, as the client
+// may cancel just as a response is sent.
+func (h *JSONRPCHandler) handleCancelled(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	var params struct {
+		RequestID interface{} `json:"requestId"`
+		Reason    string      `json:"reason"`
+	}
+
+	if req.Params != nil {
+		paramBytes, _ := json.Marshal(req.Params)
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+		}
+	}
+	if params.RequestID == nil {
+		return h.errorResponse(req.ID, -32602, "Invalid params", "requestId is required")
+	}
+
+	// Plain POST requests aren't tracked, so there is nothing a POST could cancel
+	cancelled := false
+	if clientID := clientIDFromContext(ctx); clientID != "" {
+		cancelled = h.requests.Cancel(clientID, params.RequestID)
+	}
+	requestLogger(ctx, h.logger).WithFields(logrus.Fields{
+		"request_id_cancelled": params.RequestID,
+		"reason":               params.Reason,
+		"in_flight":            cancelled,
+	}).Debug("Received cancellation")
+
+	return h.successResponse(req.ID, map[string]interface{}{})
 }
 
-func (h *JSONRPCHandler) handleToolsList(w http.ResponseWriter, req *JSONRPCRequest) {
-	h.logger.Debug("Listing available tools")
+func (h *JSONRPCHandler) handleToolsList(req *JSONRPCRequest) *JSONRPCResponse {
+	var params struct {
+		// Tags is a server extension that narrows the list to tools with one of these tags
+		Tags []string `json:"tags,omitempty"`
+	}
+
+	if req.Params != nil {
+		paramBytes, _ := json.Marshal(req.Params)
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+		}
+	}
+
+	h.logger.WithField("tags", params.Tags).Debug("Listing available tools")
 
 	tools := make([]map[string]interface{}, 0, len(h.config.Tools))
 	for _, tool := range h.config.Tools {
+		if !toolListed(&tool, h.config.Runtime.ToolTags, params.Tags) {
+			continue
+		}
+
 		// Build input schema
 		properties := make(map[string]interface{})
 		required := make([]string, 0)
 
 		for _, param := range tool.Parameters {
-			propSchema := map[string]interface{}{
-				"type":        param.Type,
-				"description": param.Description,
-			}
-
-			if param.Default != nil {
-				propSchema["default"] = param.Default
-			}
-
-			// Add validation constraints
-			if param.Validation != nil {
-				if param.Type == "string" {
-					if param.Validation.MinLength != nil {
-						propSchema["minLength"] = *param.Validation.MinLength
-					}
-					if param.Validation.MaxLength != nil {
-						propSchema["maxLength"] = *param.Validation.MaxLength
-					}
-					if param.Validation.Pattern != nil {
-						propSchema["pattern"] = *param.Validation.Pattern
-					}
-					if len(param.Validation.Enum) > 0 {
-						propSchema["enum"] = param.Validation.Enum
-					}
-				} else if param.Type == "number" {
-					if param.Validation.MinValue != nil {
-						propSchema["minimum"] = *param.Validation.MinValue
-					}
-					if param.Validation.MaxValue != nil {
-						propSchema["maximum"] = *param.Validation.MaxValue
-					}
-				}
-			}
-
-			properties[param.Name] = propSchema
+			properties[param.Name] = parameterSchema(&param)
 			if param.Required {
 				required = append(required, param.Name)
 			}
@@ -236,60 +480,77 @@ func (h *JSONRPCHandler) handleToolsList(w http.ResponseWriter, req *JSONRPCRequ
 		"tools": tools,
 	}
 
-	h.writeSuccess(w, req.ID, result)
+	return h.successResponse(req.ID, result)
 }
 
-func (h *JSONRPCHandler) handleToolsCall(w http.ResponseWriter, req *JSONRPCRequest) {
+func (h *JSONRPCHandler) handleToolsCall(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	var params struct {
 		Name      string                 `json:"name"`
 		Arguments map[string]interface{} `json:"arguments"`
+		Meta      struct {
+			ProgressToken interface{} `json:"progressToken"`
+		} `json:"_meta"`
 	}
 
 	if req.Params != nil {
 		paramBytes, _ := json.Marshal(req.Params)
 		if err := json.Unmarshal(paramBytes, &params); err != nil {
-			h.writeError(w, req.ID, -32602, "Invalid params", err.Error())
-			return
+			return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
 		}
 	}
 
-	h.logger.WithFields(logrus.Fields{
+	log := requestLogger(ctx, h.logger)
+	log.WithFields(logrus.Fields{
 		"tool_name": params.Name,
 		"arguments": params.Arguments,
 	}).Info("Executing tool")
 
-	// Execute the tool using our tool handler with shorter timeout for testing
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Progress can only be pushed to clients holding a stream open for notifications
+	if token := params.Meta.ProgressToken; token != nil {
+		if clientID := clientIDFromContext(ctx); clientID != "" {
+			ctx = withProgress(ctx, &progressReporter{
+				token: token,
+				send: func(progress map[string]interface{}) {
+					h.hub.Send(clientID, "notifications/progress", progress)
+				},
+			})
+		}
+	}
 
-	result, err := h.toolHandler.ExecuteTool(ctx, params.Name, params.Arguments)
-	if err != nil {
-		h.logger.WithError(err).WithField("tool_name", params.Name).Error("Tool execution failed")
-		// Return a more user-friendly error for testing
-		h.writeError(w, req.ID, -32000, "Tool execution error", fmt.Sprintf("Failed to execute tool '%s': %s", params.Name, err.Error()))
-		return
+	ctx, done, ok := h.trackToolCall(ctx)
+	if !ok {
+		return h.errorResponse(req.ID, -32000, "Server shutting down", "The server is no longer accepting tool calls")
 	}
+	defer done()
 
-	// Convert mcp.CallToolResult to JSON-RPC format
-	if result.IsError {
-		// Extract error message from content
-		errorMsg := "Tool execution failed"
-		if len(result.Content) > 0 {
-			if textContent, ok := result.Content[0].(*mcp.TextContent); ok {
-				errorMsg = textContent.Text
-			} else if textVal, ok := result.Content[0].(mcp.TextContent); ok {
-				errorMsg = textVal.Text
-			} else if m, ok := result.Content[0].(map[string]interface{}); ok {
-				if t, ok := m["text"].(string); ok {
-					errorMsg = t
-				}
+	// Bound execution by the tool's timeout while still aborting if the client goes away
+	ctx, cancel := context.WithTimeout(ctx, h.toolTimeout(params.Name))
+	defer cancel()
+
+	// Wait for a free slot when saturated; time spent queued counts against the timeout.
+	// Calls holding a slot are counted by mcp_tool_calls_in_flight.
+	if h.inflight != nil {
+		if err := h.inflight.Acquire(ctx, 1); err != nil {
+			if errors.Is(ctx.Err(), context.Canceled) {
+				// The client gave up while queued; a notifications/cancelled gets no reply
+				log.WithField("tool_name", params.Name).Info("Tool call cancelled while waiting for a slot")
+				return h.errorResponse(req.ID, -32800, "Request cancelled", "The request was cancelled before it started")
 			}
+			log.WithField("tool_name", params.Name).Warn("Concurrency limit reached, rejecting tool call")
+			return h.errorResponse(req.ID, -32000, "Server busy", fmt.Sprintf("Too many concurrent requests (limit %d)", h.config.Runtime.MaxConcurrentRequests))
 		}
-		h.writeError(w, req.ID, -32000, "Tool execution error", errorMsg)
-		return
+		defer h.inflight.Release(1)
+	}
+
+	result, err := h.toolHandler.ExecuteTool(ctx, params.Name, params.Arguments)
+	if err != nil {
+		log.WithError(err).WithField("tool_name", params.Name).Error("Tool execution failed")
+		// Return a more user-friendly error for testing
+		return h.errorResponse(req.ID, -32000, "Tool execution error", fmt.Sprintf("Failed to execute tool '%s': %s", params.Name, err.Error()))
 	}
 
-	// Convert successful result
+	// Convert mcp.CallToolResult to JSON-RPC format. Failed calls are still results,
+	// flagged with isError, so the client sees the structured error content.
 	h.logger.WithField("content_len", len(result.Content)).Debug("Converting tool result content")
 	// Be lenient about content element types. Different SDK versions may use
 	// pointer or value receivers, or even maps for content. We normalize to
@@ -338,11 +599,27 @@ func (h *JSONRPCHandler) handleToolsCall(w http.ResponseWriter, req *JSONRPCRequ
 	response := map[string]interface{}{
 		"content": content,
 	}
+	if result.IsError {
+		response["isError"] = true
+	}
 
-	h.writeSuccess(w, req.ID, response)
+	return h.successResponse(req.ID, response)
 }
 
-func (h *JSONRPCHandler) handlePromptsList(w http.ResponseWriter, req *JSONRPCRequest) {
+// toolTimeout returns the configured timeout for a tool, falling back to the runtime default
+func (h *JSONRPCHandler) toolTimeout(toolName string) time.Duration {
+	for _, tool := range h.config.Tools {
+		if tool.Name == toolName && tool.TimeoutDuration() > 0 {
+			return tool.TimeoutDuration()
+		}
+	}
+	if h.config.Runtime.DefaultTimeout > 0 {
+		return h.config.Runtime.DefaultTimeout.ToDuration()
+	}
+	return 30 * time.Second
+}
+
+func (h *JSONRPCHandler) handlePromptsList(req *JSONRPCRequest) *JSONRPCResponse {
 	h.logger.Debug("Listing available prompts")
 
 	prompts := make([]map[string]interface{}, 0, len(h.config.Prompts))
@@ -369,10 +646,10 @@ func (h *JSONRPCHandler) handlePromptsList(w http.ResponseWriter, req *JSONRPCRe
 		"prompts": prompts,
 	}
 
-	h.writeSuccess(w, req.ID, result)
+	return h.successResponse(req.ID, result)
 }
 
-func (h *JSONRPCHandler) handlePromptsGet(w http.ResponseWriter, req *JSONRPCRequest) {
+func (h *JSONRPCHandler) handlePromptsGet(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	var params struct {
 		Name      string            `json:"name"`
 		Arguments map[string]string `json:"arguments"`
@@ -380,7 +657,9 @@ func (h *JSONRPCHandler) handlePromptsGet(w http.ResponseWriter, req *JSONRPCReq
 
 	if req.Params != nil {
 		paramBytes, _ := json.Marshal(req.Params)
-		json.Unmarshal(paramBytes, &params)
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+		}
 	}
 
 	h.logger.WithFields(logrus.Fields{
@@ -398,34 +677,32 @@ func (h *JSONRPCHandler) handlePromptsGet(w http.ResponseWriter, req *JSONRPCReq
 	}
 
 	if promptConfig == nil {
-		h.writeError(w, req.ID, -32602, "Invalid params", fmt.Sprintf("Prompt '%s' not found", params.Name))
-		return
+		return h.errorResponse(req.ID, -32602, "Invalid params", fmt.Sprintf("Prompt '%s' not found", params.Name))
+	}
+
+	if missing := MissingPromptArguments(promptConfig, params.Arguments); len(missing) > 0 {
+		return h.errorResponse(req.ID, -32602, "Invalid params", fmt.Sprintf("Prompt '%s' is missing required arguments: %s", params.Name, strings.Join(missing, ", ")))
 	}
 
-	// Substitute arguments in the prompt content
-	content := promptConfig.Content
-	for key, value := range params.Arguments {
-		placeholder := fmt.Sprintf("{%s}", key)
-		content = strings.ReplaceAll(content, placeholder, value)
+	messages, err := h.resources.PromptMessages(ctx, promptConfig, params.Arguments)
+	if err != nil {
+		var execErr template.ExecError
+		if errors.As(err, &execErr) {
+			return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+		}
+		requestLogger(ctx, h.logger).WithError(err).WithField("prompt_name", params.Name).Error("Failed to render prompt")
+		return h.errorResponse(req.ID, -32603, "Internal error", err.Error())
 	}
 
 	result := map[string]interface{}{
 		"description": promptConfig.Description,
-		"messages": []map[string]interface{}{
-			{
-				"role": "user",
-				"content": map[string]interface{}{
-					"type": "text",
-					"text": content,
-				},
-			},
-		},
+		"messages":    messages,
 	}
 
-	h.writeSuccess(w, req.ID, result)
+	return h.successResponse(req.ID, result)
 }
 
-func (h *JSONRPCHandler) handleResourcesList(w http.ResponseWriter, req *JSONRPCRequest) {
+func (h *JSONRPCHandler) handleResourcesList(req *JSONRPCRequest) *JSONRPCResponse {
 	h.logger.Debug("Listing available resources")
 
 	resources := make([]map[string]interface{}, 0, len(h.config.Resources))
@@ -444,20 +721,42 @@ func (h *JSONRPCHandler) handleResourcesList(w http.ResponseWriter, req *JSONRPC
 		"resources": resources,
 	}
 
-	h.writeSuccess(w, req.ID, result)
+	return h.successResponse(req.ID, result)
 }
 
-func (h *JSONRPCHandler) handleResourcesRead(w http.ResponseWriter, req *JSONRPCRequest) {
+func (h *JSONRPCHandler) handleResourceTemplatesList(req *JSONRPCRequest) *JSONRPCResponse {
+	h.logger.Debug("Listing resource templates")
+
+	templates := make([]map[string]interface{}, 0, len(h.config.ResourceTemplates))
+	for _, tmpl := range h.config.ResourceTemplates {
+		templates = append(templates, map[string]interface{}{
+			"uriTemplate": tmpl.URITemplate,
+			"name":        tmpl.Name,
+			"description": tmpl.Description,
+			"mimeType":    tmpl.MimeType,
+		})
+	}
+
+	return h.successResponse(req.ID, map[string]interface{}{
+		"resourceTemplates": templates,
+	})
+}
+
+func (h *JSONRPCHandler) handleResourcesRead(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	var params struct {
 		URI string `json:"uri"`
+		// Refresh is a server extension that discards any cached copy before reading
+		Refresh bool `json:"refresh,omitempty"`
 	}
 
 	if req.Params != nil {
 		paramBytes, _ := json.Marshal(req.Params)
-		json.Unmarshal(paramBytes, &params)
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+		}
 	}
 
-	h.logger.WithField("uri", params.URI).Info("Reading resource")
+	requestLogger(ctx, h.logger).WithField("uri", params.URI).Info("Reading resource")
 
 	// Find the resource
 	var resourceConfig *config.ResourceConfig
@@ -469,52 +768,131 @@ func (h *JSONRPCHandler) handleResourcesRead(w http.ResponseWriter, req *JSONRPC
 	}
 
 	if resourceConfig == nil {
-		h.writeError(w, req.ID, -32602, "Invalid params", fmt.Sprintf("Resource '%s' not found", params.URI))
-		return
+		resolved, err := h.resources.ResolveTemplate(params.URI)
+		if err != nil {
+			return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+		}
+		resourceConfig = resolved
 	}
 
-	// Get resource content
-	content := resourceConfig.Content
-	if content == "" && resourceConfig.FilePath != "" {
-		// Could read from file here if needed
-		content = "File content would be loaded here"
+	if resourceConfig == nil {
+		return h.errorResponse(req.ID, -32602, "Invalid params", fmt.Sprintf("Resource '%s' not found", params.URI))
 	}
-	if content == "" && resourceConfig.URL != "" {
-		// Could fetch from URL here if needed
-		content = "URL content would be fetched here"
+
+	if params.Refresh {
+		h.resources.Invalidate(resourceConfig.URI)
+	}
+
+	// Get resource content
+	content, err := h.resources.Load(ctx, resourceConfig)
+	if err != nil {
+		requestLogger(ctx, h.logger).WithError(err).WithField("uri", params.URI).Error("Failed to load resource")
+		return h.errorResponse(req.ID, -32603, "Internal error", fmt.Sprintf("Failed to load resource '%s': %s", params.URI, err.Error()))
 	}
 
 	result := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{
-				"uri":      resourceConfig.URI,
-				"mimeType": resourceConfig.MimeType,
-				"text":     content,
-			},
-		},
+		"contents": []interface{}{content.MCPContents()},
 	}
 
-	h.writeSuccess(w, req.ID, result)
+	return h.successResponse(req.ID, result)
 }
 
-func (h *JSONRPCHandler) handlePing(w http.ResponseWriter, req *JSONRPCRequest) {
-	h.writeSuccess(w, req.ID, map[string]interface{}{})
+// handleResourcesSubscribe starts notifications/resources/updated for a resource on the
+// caller's stream. Only file-backed resources change; others are accepted but stay quiet.
+func (h *JSONRPCHandler) handleResourcesSubscribe(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if req.Params != nil {
+		paramBytes, _ := json.Marshal(req.Params)
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+		}
+	}
+
+	clientID := clientIDFromContext(ctx)
+	if clientID == "" {
+		return h.errorResponse(req.ID, -32600, "Invalid Request", "Resource subscriptions need a streaming transport (SSE or WebSocket)")
+	}
+
+	resource, err := h.resources.Resolve(params.URI)
+	if err != nil {
+		return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+	}
+	if resource == nil {
+		return h.errorResponse(req.ID, -32602, "Invalid params", fmt.Sprintf("Resource '%s' not found", params.URI))
+	}
+
+	err = h.subscriptions.Subscribe(clientID, params.URI, resource.FilePath)
+	if errors.Is(err, errSubscriberClosed) {
+		return h.errorResponse(req.ID, -32600, "Invalid Request", "The connection has closed")
+	}
+	if err != nil {
+		requestLogger(ctx, h.logger).WithError(err).WithField("uri", params.URI).Error("Failed to watch resource")
+		return h.errorResponse(req.ID, -32603, "Internal error", fmt.Sprintf("Failed to watch resource '%s': %s", params.URI, err.Error()))
+	}
+	requestLogger(ctx, h.logger).WithField("uri", params.URI).Debug("Resource subscribed")
+	return h.successResponse(req.ID, map[string]interface{}{})
 }
 
-func (h *JSONRPCHandler) writeSuccess(w http.ResponseWriter, id interface{}, result interface{}) {
-	response := JSONRPCResponse{
+// handleResourcesUnsubscribe stops update notifications for a resource on the caller's stream
+func (h *JSONRPCHandler) handleResourcesUnsubscribe(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if req.Params != nil {
+		paramBytes, _ := json.Marshal(req.Params)
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+		}
+	}
+
+	if clientID := clientIDFromContext(ctx); clientID != "" {
+		h.subscriptions.Unsubscribe(clientID, params.URI)
+	}
+	return h.successResponse(req.ID, map[string]interface{}{})
+}
+
+func (h *JSONRPCHandler) handleLoggingSetLevel(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	var params struct {
+		Level string `json:"level"`
+	}
+
+	if req.Params != nil {
+		paramBytes, _ := json.Marshal(req.Params)
+		if err := json.Unmarshal(paramBytes, &params); err != nil {
+			return h.errorResponse(req.ID, -32602, "Invalid params", err.Error())
+		}
+	}
+
+	level, ok := mcpLogLevels[params.Level]
+	if !ok {
+		return h.errorResponse(req.ID, -32602, "Invalid params", fmt.Sprintf("unknown log level %q", params.Level))
+	}
+
+	// Log notifications only reach streaming connections; over plain POST there is
+	// nothing to apply the level to
+	if clientID := clientIDFromContext(ctx); clientID != "" {
+		h.logs.SetLevel(clientID, level)
+	}
+	requestLogger(ctx, h.logger).WithField("log_level", params.Level).Debug("Client changed its log notification level")
+	return h.successResponse(req.ID, map[string]interface{}{})
+}
+
+func (h *JSONRPCHandler) handlePing(req *JSONRPCRequest) *JSONRPCResponse {
+	return h.successResponse(req.ID, map[string]interface{}{})
+}
+
+func (h *JSONRPCHandler) successResponse(id interface{}, result interface{}) *JSONRPCResponse {
+	return &JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      id,
 		Result:  result,
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
 }
 
-func (h *JSONRPCHandler) writeError(w http.ResponseWriter, id interface{}, code int, message string, data interface{}) {
-	response := JSONRPCResponse{
+func (h *JSONRPCHandler) errorResponse(id interface{}, code int, message string, data interface{}) *JSONRPCResponse {
+	return &JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      id,
 		Error: &JSONRPCError{
@@ -523,8 +901,14 @@ func (h *JSONRPCHandler) writeError(w http.ResponseWriter, id interface{}, code
 			Data:    data,
 		},
 	}
+}
+
+func (h *JSONRPCHandler) writeResponse(w http.ResponseWriter, response interface{}) {
+	h.writeResponseStatus(w, http.StatusOK, response) // JSON-RPC errors still use 200 OK
+}
 
+func (h *JSONRPCHandler) writeResponseStatus(w http.ResponseWriter, status int, response interface{}) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK) // JSON-RPC errors still use 200 OK
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"mcp-server-template/internal/config"
+)
+
+const (
+	// unhealthyAfterFailures is the number of consecutive failures that marks an endpoint unhealthy
+	unhealthyAfterFailures = 3
+	// unhealthyCooldown is how long an unhealthy endpoint is skipped before being retried
+	unhealthyCooldown = 30 * time.Second
+)
+
+// EndpointStats reports request outcomes for a single balanced endpoint
+type EndpointStats struct {
+	Tool      string `json:"tool"`
+	Endpoint  string `json:"endpoint"`
+	Successes uint64 `json:"successes"`
+	Failures  uint64 `json:"failures"`
+	Healthy   bool   `json:"healthy"`
+}
+
+// endpointBalancer picks endpoints for tools configured with multiple replicas
+type endpointBalancer struct {
+	mu    sync.Mutex
+	pools map[string][]*balancedEndpoint
+}
+
+type balancedEndpoint struct {
+	url                 string
+	weight              int
+	currentWeight       int
+	successes           uint64
+	failures            uint64
+	consecutiveFailures int
+	unhealthyUntil      time.Time
+}
+
+func newEndpointBalancer() *endpointBalancer {
+	return &endpointBalancer{pools: make(map[string][]*balancedEndpoint)}
+}
+
+// Next selects an endpoint for the tool using smooth weighted round-robin,
+// skipping endpoints that recently failed unless none are healthy
+func (b *endpointBalancer) Next(tool *config.ToolConfig) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pool := b.pool(tool)
+	now := time.Now()
+
+	candidates := make([]*balancedEndpoint, 0, len(pool))
+	for _, ep := range pool {
+		if now.After(ep.unhealthyUntil) {
+			candidates = append(candidates, ep)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = pool
+	}
+
+	var selected *balancedEndpoint
+	total := 0
+	for _, ep := range candidates {
+		ep.currentWeight += ep.weight
+		total += ep.weight
+		if selected == nil || ep.currentWeight > selected.currentWeight {
+			selected = ep
+		}
+	}
+	selected.currentWeight -= total
+
+	return selected.url
+}
+
+// Record updates the outcome counters and health of an endpoint
+func (b *endpointBalancer) Record(tool *config.ToolConfig, endpoint string, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ep := range b.pool(tool) {
+		if ep.url != endpoint {
+			continue
+		}
+		if success {
+			ep.successes++
+			ep.consecutiveFailures = 0
+			ep.unhealthyUntil = time.Time{}
+		} else {
+			ep.failures++
+			ep.consecutiveFailures++
+			if ep.consecutiveFailures >= unhealthyAfterFailures {
+				ep.unhealthyUntil = time.Now().Add(unhealthyCooldown)
+			}
+		}
+		return
+	}
+}
+
+// Reset clears the cooldown of unhealthy endpoints for toolName, or all tools when empty
+func (b *endpointBalancer) Reset(toolName string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	reset := 0
+	for name, pool := range b.pools {
+		if toolName != "" && name != toolName {
+			continue
+		}
+		for _, ep := range pool {
+			if now.Before(ep.unhealthyUntil) {
+				reset++
+			}
+			ep.consecutiveFailures = 0
+			ep.unhealthyUntil = time.Time{}
+		}
+	}
+	return reset
+}
+
+// Stats returns a snapshot of all balanced endpoints
+func (b *endpointBalancer) Stats() []EndpointStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	var stats []EndpointStats
+	for toolName, pool := range b.pools {
+		for _, ep := range pool {
+			stats = append(stats, EndpointStats{
+				Tool:      toolName,
+				Endpoint:  ep.url,
+				Successes: ep.successes,
+				Failures:  ep.failures,
+				Healthy:   now.After(ep.unhealthyUntil),
+			})
+		}
+	}
+	return stats
+}
+
+// pool returns the endpoint pool for a tool, creating it on first use
+func (b *endpointBalancer) pool(tool *config.ToolConfig) []*balancedEndpoint {
+	if pool, ok := b.pools[tool.Name]; ok {
+		return pool
+	}
+
+	pool := make([]*balancedEndpoint, 0, len(tool.Endpoints))
+	for _, ep := range tool.Endpoints {
+		weight := ep.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		pool = append(pool, &balancedEndpoint{url: ep.URL, weight: weight})
+	}
+	b.pools[tool.Name] = pool
+	return pool
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// mcpLogLevels maps MCP (RFC 5424) log levels to logrus. logrus has no notice or
+// levels above error that don't exit the process, so those collapse onto info/error.
+var mcpLogLevels = map[string]logrus.Level{
+	"debug":     logrus.DebugLevel,
+	"info":      logrus.InfoLevel,
+	"notice":    logrus.InfoLevel,
+	"warning":   logrus.WarnLevel,
+	"error":     logrus.ErrorLevel,
+	"critical":  logrus.ErrorLevel,
+	"alert":     logrus.ErrorLevel,
+	"emergency": logrus.ErrorLevel,
+}
+
+// mcpLogLevel maps a logrus level to its MCP name
+func mcpLogLevel(level logrus.Level) string {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return "debug"
+	case logrus.InfoLevel:
+		return "info"
+	case logrus.WarnLevel:
+		return "warning"
+	case logrus.ErrorLevel:
+		return "error"
+	case logrus.FatalLevel:
+		return "critical"
+	default:
+		return "emergency"
+	}
+}
+
+// logNotifier is a logrus hook that forwards log records to the streaming client whose
+// request produced them, as notifications/message. Records are matched to a connection
+// through the context requestLogger attaches, so server logs and other clients' requests
+// are never forwarded. Each connection picks its own threshold with logging/setLevel;
+// the loggers' own levels are left alone, so a client can't silence or flood the
+// server's logs, nor receive records below the level the server was started with.
+type logNotifier struct {
+	hub          *notificationHub
+	defaultLevel logrus.Level
+
+	mu      sync.Mutex
+	levels  map[string]logrus.Level // client id -> threshold set with logging/setLevel
+	loggers []*logrus.Logger
+}
+
+func newLogNotifier(hub *notificationHub, defaultLevel logrus.Level) *logNotifier {
+	return &logNotifier{hub: hub, defaultLevel: defaultLevel, levels: make(map[string]logrus.Level)}
+}
+
+// Attach adds the hook to logger, once
+func (n *logNotifier) Attach(logger *logrus.Logger) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, existing := range n.loggers {
+		if existing == logger {
+			return
+		}
+	}
+	logger.AddHook(n)
+	n.loggers = append(n.loggers, logger)
+}
+
+// SetLevel sets the threshold of the records forwarded to one connection
+func (n *logNotifier) SetLevel(clientID string, level logrus.Level) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.levels[clientID] = level
+}
+
+// Drop forgets a connection's threshold once it disconnects
+func (n *logNotifier) Drop(clientID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.levels, clientID)
+}
+
+func (n *logNotifier) level(clientID string) logrus.Level {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if level, ok := n.levels[clientID]; ok {
+		return level
+	}
+	return n.defaultLevel
+}
+
+// Levels implements logrus.Hook
+func (n *logNotifier) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (n *logNotifier) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	clientID := clientIDFromContext(entry.Context)
+	if clientID == "" || entry.Level > n.level(clientID) {
+		return nil
+	}
+
+	data := make(map[string]interface{}, len(entry.Data)+1)
+	for key, value := range entry.Data {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		data[key] = value
+	}
+	data["message"] = entry.Message
+
+	n.hub.Send(clientID, "notifications/message", map[string]interface{}{
+		"level":  mcpLogLevel(entry.Level),
+		"logger": "mcp-server",
+		"data":   data,
+	})
+	return nil
+}
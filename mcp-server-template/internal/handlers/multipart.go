@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"mcp-server-template/internal/config"
+)
+
+// buildMultipartBody encodes the tool's form fields and optional file part as
+// multipart/form-data, returning the body and its Content-Type with boundary
+func (h *HTTPClient) buildMultipartBody(tool *config.ToolConfig, params map[string]interface{}) (*bytes.Buffer, string, error) {
+	spec := tool.Multipart
+	if spec == nil {
+		return nil, "", fmt.Errorf("body_type multipart requires a multipart block")
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	// Sort field names so the encoded body is deterministic
+	names := make([]string, 0, len(spec.Fields))
+	for name := range spec.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, ok, err := h.multipartFieldValue(spec.Fields[name], params)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to expand form field %s: %w", name, err)
+		}
+		if !ok {
+			continue
+		}
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, "", fmt.Errorf("failed to write form field %s: %w", name, err)
+		}
+	}
+
+	if spec.FileField != "" {
+		if err := h.writeMultipartFile(writer, spec, params); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to finalize multipart body: %w", err)
+	}
+
+	return body, writer.FormDataContentType(), nil
+}
+
+// multipartFieldValue resolves a field mapping: templates are expanded, anything else
+// names a parameter. Fields whose parameter was not supplied are skipped.
+func (h *HTTPClient) multipartFieldValue(mapping string, params map[string]interface{}) (string, bool, error) {
+	if strings.Contains(mapping, "{{") {
+		value, err := h.expandTemplate(mapping, params)
+		return value, err == nil, err
+	}
+
+	value, exists := params[mapping]
+	if !exists || value == nil {
+		return "", false, nil
+	}
+	return fmt.Sprintf("%v", value), true, nil
+}
+
+// writeMultipartFile adds the file part from either base64 data or a server-side path
+func (h *HTTPClient) writeMultipartFile(writer *multipart.Writer, spec *config.MultipartConfig, params map[string]interface{}) error {
+	raw, ok := params[spec.FileParam].(string)
+	if !ok || raw == "" {
+		return fmt.Errorf("file parameter %s is required for multipart upload", spec.FileParam)
+	}
+
+	var data []byte
+	fileName := "upload"
+
+	switch spec.FileSource {
+	case "path":
+		content, err := os.ReadFile(raw)
+		if err != nil {
+			return fmt.Errorf("failed to read file for %s: %w", spec.FileField, err)
+		}
+		data = content
+		fileName = filepath.Base(raw)
+	default:
+		decoded, err := decodeBase64File(raw)
+		if err != nil {
+			return fmt.Errorf("file parameter %s is not valid base64: %w", spec.FileParam, err)
+		}
+		data = decoded
+	}
+
+	if spec.FileName != "" {
+		name, err := h.expandTemplate(spec.FileName, params)
+		if err != nil {
+			return fmt.Errorf("failed to expand file name: %w", err)
+		}
+		fileName = name
+	}
+
+	contentType := spec.FileContentType
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(spec.FileField), escapeQuotes(fileName)))
+	header.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("failed to create file part: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return fmt.Errorf("failed to write file part: %w", err)
+	}
+	return nil
+}
+
+// decodeBase64File accepts plain base64 or a data URI ("data:image/png;base64,...")
+func decodeBase64File(value string) ([]byte, error) {
+	if strings.HasPrefix(value, "data:") {
+		if comma := strings.Index(value, ","); comma >= 0 {
+			value = value[comma+1:]
+		}
+	}
+	value = strings.TrimSpace(value)
+
+	if data, err := base64.StdEncoding.DecodeString(value); err == nil {
+		return data, nil
+	}
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes mirrors mime/multipart's escaping of Content-Disposition parameters
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"sync"
+)
+
+// notificationBuffer is how many notifications may queue per subscriber before new
+// ones are dropped for that subscriber
+const notificationBuffer = 256
+
+// JSONRPCNotification is a server-initiated JSON-RPC message that expects no response
+type JSONRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// notificationHub fans server notifications out to connected streaming clients, either
+// to all of them or to one client id. Delivery never blocks the sender: a subscriber
+// that falls behind loses notifications.
+type notificationHub struct {
+	mu          sync.RWMutex
+	subscribers map[chan *JSONRPCNotification]string // channel -> client id
+}
+
+func newNotificationHub() *notificationHub {
+	return &notificationHub{subscribers: make(map[chan *JSONRPCNotification]string)}
+}
+
+// Subscribe registers a new listener for a client and returns its channel and a
+// function that unregisters it
+func (n *notificationHub) Subscribe(clientID string) (<-chan *JSONRPCNotification, func()) {
+	ch := make(chan *JSONRPCNotification, notificationBuffer)
+
+	n.mu.Lock()
+	n.subscribers[ch] = clientID
+	n.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			n.mu.Lock()
+			delete(n.subscribers, ch)
+			n.mu.Unlock()
+		})
+	}
+}
+
+// Broadcast sends a notification to all subscribers
+func (n *notificationHub) Broadcast(method string, params interface{}) {
+	notification := &JSONRPCNotification{JSONRPC: "2.0", Method: method, Params: params}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for ch := range n.subscribers {
+		select {
+		case ch <- notification:
+		default:
+		}
+	}
+}
+
+// Send delivers a notification to one client's listeners only
+func (n *notificationHub) Send(clientID, method string, params interface{}) {
+	notification := &JSONRPCNotification{JSONRPC: "2.0", Method: method, Params: params}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for ch, id := range n.subscribers {
+		if id != clientID {
+			continue
+		}
+		select {
+		case ch <- notification:
+		default:
+		}
+	}
+}
+
+// hasSubscribers reports whether anyone is listening, letting callers skip building
+// notifications nobody will receive
+func (n *notificationHub) hasSubscribers() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return len(n.subscribers) > 0
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"strings"
+
+	"mcp-server-template/internal/config"
+)
+
+// OriginAllowed reports whether a browser origin is in the security config's
+// allowed_origins. Every origin is allowed when enable_cors is off.
+func OriginAllowed(security *config.SecurityConfig, origin string) bool {
+	if !security.EnableCORS {
+		return true
+	}
+	for _, allowed := range security.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// followPages walks the remaining pages of a paged API after the first response and
+// returns a response whose Data is every page's items concatenated. All pages share
+// ctx, so the tool timeout bounds the whole sequence rather than each page.
+func (h *HTTPClient) followPages(ctx context.Context, client *http.Client, tool *config.ToolConfig, params map[string]interface{}, first *APIResponse, firstURL *url.URL) (*APIResponse, error) {
+	spec := tool.Pagination
+
+	items, err := pageItems(first.Data, spec.ItemsPath)
+	if err != nil {
+		return nil, fmt.Errorf("page 1: %w", err)
+	}
+
+	maxPages := spec.MaxPages
+	if maxPages <= 0 {
+		maxPages = 10
+	}
+
+	page := first
+	current := firstURL
+	pages := 1
+	reportProgress(ctx, 1, 0, "Fetched page 1")
+	for {
+		next, ok := nextPageURL(spec, page, firstURL, current)
+		if !ok {
+			break
+		}
+		// Auth headers are re-sent with every page, so never follow links off the original host
+		if next.Host != firstURL.Host {
+			requestLogger(ctx, h.logger).WithFields(logrus.Fields{
+				"tool_name": tool.Name,
+				"next_host": next.Host,
+			}).Warn("Not following next page on a different host")
+			break
+		}
+		if pages >= maxPages {
+			requestLogger(ctx, h.logger).WithFields(logrus.Fields{
+				"tool_name": tool.Name,
+				"max_pages": maxPages,
+			}).Warn("Stopped following pages at max_pages")
+			break
+		}
+
+		req, err := h.buildRequest(ctx, tool, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build request for page %d: %w", pages+1, err)
+		}
+		req.URL = next
+		req.Host = next.Host
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", pages+1, err)
+		}
+
+		page, err = h.processResponse(ctx, resp, tool)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", pages+1, err)
+		}
+		if !h.isSuccessStatusCode(page.StatusCode, tool.Validation) {
+			return nil, fmt.Errorf("page %d returned HTTP %d", pages+1, page.StatusCode)
+		}
+
+		pageData, err := pageItems(page.Data, spec.ItemsPath)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", pages+1, err)
+		}
+		items = append(items, pageData...)
+		current = next
+		pages++
+		reportProgress(ctx, float64(pages), 0, fmt.Sprintf("Fetched page %d", pages))
+	}
+
+	body, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode aggregated pages: %w", err)
+	}
+
+	requestLogger(ctx, h.logger).WithFields(logrus.Fields{
+		"tool_name": tool.Name,
+		"pages":     pages,
+		"items":     len(items),
+	}).Debug("Aggregated paged response")
+
+	return &APIResponse{
+		StatusCode: first.StatusCode,
+		Headers:    first.Headers,
+		Body:       string(body),
+		Data:       items,
+	}, nil
+}
+
+// pageItems extracts the item array from a page
+func pageItems(data interface{}, itemsPath string) ([]interface{}, error) {
+	if itemsPath != "" {
+		extracted, err := extractResponsePath(data, itemsPath)
+		if err != nil {
+			return nil, fmt.Errorf("items_path: %w", err)
+		}
+		data = extracted
+	}
+
+	items, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array of items, got %T", data)
+	}
+	return items, nil
+}
+
+// nextPageURL finds the next page from the Link header or the configured body field
+func nextPageURL(spec *config.PaginationConfig, page *APIResponse, firstURL, current *url.URL) (*url.URL, bool) {
+	var raw string
+
+	if spec.UseLinkHeader {
+		raw = linkNext(page.Headers["Link"])
+	}
+
+	if raw == "" && spec.NextPath != "" {
+		value, err := extractResponsePath(page.Data, spec.NextPath)
+		if err != nil || value == nil {
+			return nil, false
+		}
+		raw = strings.TrimSpace(fmt.Sprintf("%v", value))
+		if raw == "" {
+			return nil, false
+		}
+
+		// A cursor is sent back against the original request URL
+		if spec.CursorParam != "" {
+			next := *firstURL
+			query := next.Query()
+			query.Set(spec.CursorParam, raw)
+			next.RawQuery = query.Encode()
+			if next.String() == current.String() {
+				return nil, false
+			}
+			return &next, true
+		}
+	}
+
+	if raw == "" {
+		return nil, false
+	}
+
+	next, err := current.Parse(raw)
+	if err != nil || next.String() == current.String() {
+		return nil, false
+	}
+	return next, true
+}
+
+// linkNext returns the rel="next" target of an RFC 8288 Link header
+func linkNext(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.Split(link, ";")
+		target := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range parts[1:] {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || !strings.EqualFold(key, "rel") {
+				continue
+			}
+			for _, rel := range strings.Fields(strings.Trim(value, `"`)) {
+				if strings.EqualFold(rel, "next") {
+					return target[1 : len(target)-1]
+				}
+			}
+		}
+	}
+	return ""
+}
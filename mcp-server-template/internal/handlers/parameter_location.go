@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"mcp-server-template/internal/config"
+)
+
+// expandPathParams fills the {name} segments of an endpoint from parameters declared
+// "in": "path", escaping each value as a single path segment
+func expandPathParams(endpoint string, tool *config.ToolConfig, params map[string]interface{}) (string, error) {
+	for _, param := range tool.Parameters {
+		if param.In != "path" {
+			continue
+		}
+		value, ok := params[param.Name]
+		if !ok || value == nil {
+			return "", fmt.Errorf("path parameter %s is missing", param.Name)
+		}
+		endpoint = strings.ReplaceAll(endpoint, "{"+param.Name+"}", url.PathEscape(fmt.Sprintf("%v", value)))
+	}
+	return endpoint, nil
+}
+
+// sentInQuery reports whether a parameter goes in the query string. Parameters without
+// a location are only sent there on GET requests.
+func sentInQuery(param *config.ParameterConfig, method string) bool {
+	if param.In == "" {
+		return strings.EqualFold(method, http.MethodGet)
+	}
+	return param.In == "query"
+}
+
+// setQueryValue writes a parameter to the query string. Scalars are set as text, and
+// arrays and objects are written in the parameter's style.
+func setQueryValue(query url.Values, param *config.ParameterConfig, value interface{}) {
+	query.Del(param.Name)
+	switch v := value.(type) {
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, queryText(item))
+		}
+		switch param.Style {
+		case "comma":
+			query.Set(param.Name, strings.Join(values, ","))
+		case "brackets":
+			query[param.Name+"[]"] = values
+		default:
+			query[param.Name] = values
+		}
+	case map[string]interface{}:
+		fields := make([]string, 0, len(v))
+		for field := range v {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		if param.Style == "comma" {
+			pairs := make([]string, 0, 2*len(fields))
+			for _, field := range fields {
+				pairs = append(pairs, field, queryText(v[field]))
+			}
+			query.Set(param.Name, strings.Join(pairs, ","))
+			return
+		}
+		for _, field := range fields {
+			query.Set(param.Name+"["+field+"]", queryText(v[field]))
+		}
+	default:
+		query.Set(param.Name, queryText(value))
+	}
+}
+
+// queryText renders a single value for a query string, as for a form field
+func queryText(value interface{}) string {
+	text, err := formValue(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return text
+}
+
+// setParamHeaders sets a header for each parameter declared "in": "header"
+func setParamHeaders(header http.Header, tool *config.ToolConfig, params map[string]interface{}) {
+	for _, param := range tool.Parameters {
+		if param.In != "header" {
+			continue
+		}
+		if value, ok := params[param.Name]; ok && value != nil {
+			header.Set(param.Name, fmt.Sprintf("%v", value))
+		}
+	}
+}
+
+// bodyArguments returns the arguments for the default JSON body. GET requests only carry
+// parameters declared "in": "body"; other methods carry every argument that isn't placed
+// in the path, query or headers.
+func bodyArguments(tool *config.ToolConfig, params map[string]interface{}) map[string]interface{} {
+	isGet := strings.EqualFold(tool.Method, http.MethodGet)
+	placed := make(map[string]bool, len(tool.Parameters))
+	inBody := make(map[string]bool, len(tool.Parameters))
+	for _, param := range tool.Parameters {
+		switch param.In {
+		case "path", "query", "header":
+			placed[param.Name] = true
+		case "body":
+			inBody[param.Name] = true
+		}
+	}
+
+	body := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		if placed[name] || (isGet && !inBody[name]) {
+			continue
+		}
+		body[name] = value
+	}
+	return body
+}
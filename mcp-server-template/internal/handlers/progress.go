@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+)
+
+// progressReporter sends notifications/progress for one tool call to the streaming
+// connection that made it, tagged with the progressToken the client supplied
+type progressReporter struct {
+	token interface{}
+	send  func(params map[string]interface{})
+
+	mu   sync.Mutex
+	last float64
+}
+
+type progressKey struct{}
+
+// withProgress returns a copy of ctx whose tool work reports progress through r
+func withProgress(ctx context.Context, r *progressReporter) context.Context {
+	return context.WithValue(ctx, progressKey{}, r)
+}
+
+// reportProgress notifies the caller of ctx that the call has reached progress, out of
+// total if total is positive. It does nothing when the client didn't ask for progress.
+// Values that don't move forward are dropped, since clients expect progress to increase.
+func reportProgress(ctx context.Context, progress, total float64, message string) {
+	r, ok := ctx.Value(progressKey{}).(*progressReporter)
+	if !ok || r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	if progress <= r.last {
+		r.mu.Unlock()
+		return
+	}
+	r.last = progress
+	r.mu.Unlock()
+
+	params := map[string]interface{}{
+		"progressToken": r.token,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	r.send(params)
+}
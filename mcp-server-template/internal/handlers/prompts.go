@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// PromptMessages renders a prompt for prompts/get. A prompt with messages yields each of
+// them in order with its role, text messages rendered and resource messages embedded;
+// otherwise its content, file or URL becomes a single user message. Failures caused by
+// the arguments are template.ExecErrors.
+func (l *ResourceLoader) PromptMessages(ctx context.Context, prompt *config.PromptConfig, arguments map[string]string) ([]mcp.PromptMessage, error) {
+	if len(prompt.Messages) > 0 {
+		messages := make([]mcp.PromptMessage, 0, len(prompt.Messages))
+		for i, message := range prompt.Messages {
+			if message.Resource != "" {
+				content, err := l.embedResource(ctx, message.Resource)
+				if err != nil {
+					return nil, fmt.Errorf("prompt %s message %d: %w", prompt.Name, i, err)
+				}
+				messages = append(messages, mcp.NewPromptMessage(mcp.Role(message.Role), content))
+				continue
+			}
+			text, err := renderPrompt(fmt.Sprintf("%s.messages[%d]", prompt.Name, i), prompt, message.Content, arguments)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, mcp.NewPromptMessage(mcp.Role(message.Role), mcp.NewTextContent(text)))
+		}
+		return messages, nil
+	}
+
+	text, err := l.promptText(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	text, err = renderPrompt(prompt.Name, prompt, text, arguments)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text))}, nil
+}
+
+// embeddedResource is MCP's "resource" content block. mcp.EmbeddedResource has no room
+// for the resource's text or blob, so prompts use this instead.
+type embeddedResource struct {
+	Type     string      `json:"type"`
+	Resource interface{} `json:"resource"`
+}
+
+// embedResource loads a resource through the shared loader, so caching and size limits
+// apply, and wraps it as an embedded resource content block
+func (l *ResourceLoader) embedResource(ctx context.Context, uri string) (*embeddedResource, error) {
+	resource, err := l.Resolve(uri)
+	if err != nil {
+		return nil, err
+	}
+	if resource == nil {
+		return nil, fmt.Errorf("resource %s not found", uri)
+	}
+	content, err := l.Load(ctx, resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load resource %s: %w", uri, err)
+	}
+	return &embeddedResource{Type: "resource", Resource: content.MCPContents()}, nil
+}
+
+// promptText loads a prompt's body from its inline content, file or URL. File and URL
+// prompts go through the same loader, and size limit, as resources.
+func (l *ResourceLoader) promptText(ctx context.Context, prompt *config.PromptConfig) (string, error) {
+	if prompt.FilePath == "" && prompt.URL == "" {
+		return prompt.Content, nil
+	}
+	content, err := l.Load(ctx, &config.ResourceConfig{
+		URI:      "prompt://" + prompt.Name,
+		MimeType: "text/plain",
+		FilePath: prompt.FilePath,
+		URL:      prompt.URL,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to load prompt %s: %w", prompt.Name, err)
+	}
+	return string(content.Data), nil
+}
+
+// MissingPromptArguments lists the prompt's required arguments absent from arguments,
+// in declaration order
+func MissingPromptArguments(prompt *config.PromptConfig, arguments map[string]string) []string {
+	var missing []string
+	for _, arg := range prompt.Arguments {
+		if _, ok := arguments[arg.Name]; arg.Required && !ok {
+			missing = append(missing, arg.Name)
+		}
+	}
+	return missing
+}
+
+// renderPrompt fills prompt text with its arguments. Text containing {{ is a Go
+// template over the arguments, with promptFuncs; declared arguments that weren't given
+// are empty strings, so {{if .verbose}} and {{.name | default "there"}} work, while a
+// reference to an unknown name fails with a template.ExecError. Other text keeps the
+// original {name} placeholders.
+func renderPrompt(name string, prompt *config.PromptConfig, text string, arguments map[string]string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		for key, value := range arguments {
+			text = strings.ReplaceAll(text, "{"+key+"}", value)
+		}
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).Funcs(promptFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("prompt %s has an invalid template: %w", name, err)
+	}
+
+	data := make(map[string]interface{}, len(prompt.Arguments)+len(arguments))
+	for _, arg := range prompt.Arguments {
+		data[arg.Name] = ""
+	}
+	for key, value := range arguments {
+		data[key] = value
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
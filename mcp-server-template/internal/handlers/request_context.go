@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader is the header clients may use to supply a correlation id; responses echo it
+const RequestIDHeader = "X-Request-ID"
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	clientIDKey
+)
+
+// WithRequestID returns a copy of ctx carrying the given request id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request id stored in ctx, if any
+func RequestIDFromContext(ctx context.Context) string {
+	if requestID, ok := ctx.Value(requestIDKey).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
+// withClientID returns a copy of ctx identifying the streaming connection (SSE session
+// or WebSocket) a request arrived on
+func withClientID(ctx context.Context, clientID string) context.Context {
+	return context.WithValue(ctx, clientIDKey, clientID)
+}
+
+// clientIDFromContext returns the connection id stored in ctx, or "" for requests that
+// didn't arrive over a stream
+func clientIDFromContext(ctx context.Context) string {
+	clientID, _ := ctx.Value(clientIDKey).(string)
+	return clientID
+}
+
+// requestIDFromHTTP reads the client supplied request id or generates a new one
+func requestIDFromHTTP(r *http.Request) string {
+	if requestID := r.Header.Get(RequestIDHeader); requestID != "" {
+		return requestID
+	}
+	return uuid.NewString()
+}
+
+// requestLogger returns a log entry tagged with the request id from ctx so every
+// line written while serving a request can be correlated. The entry carries ctx, which
+// is how log notifications find the connection a record belongs to.
+func requestLogger(ctx context.Context, logger *logrus.Logger) *logrus.Entry {
+	entry := logrus.NewEntry(logger).WithContext(ctx)
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		entry = entry.WithField("request_id", requestID)
+	}
+	return entry
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"mcp-server-template/internal/config"
+)
+
+// maxResourceCacheEntries bounds the URL resource cache. Resource templates let clients
+// pick the URI, so without a bound the cache would grow with every distinct read.
+const maxResourceCacheEntries = 128
+
+// urlResourceCache holds downloaded URL resources keyed by resource URI. Once full, the
+// least recently used entry makes room for a new one.
+type urlResourceCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element // values are *cachedResource
+	order   *list.List               // most recently used first
+}
+
+type cachedResource struct {
+	uri          string
+	url          string
+	data         []byte
+	contentType  string
+	etag         string
+	lastModified string
+	fetchedAt    time.Time
+}
+
+func newURLResourceCache() *urlResourceCache {
+	return &urlResourceCache{entries: make(map[string]*list.Element), order: list.New()}
+}
+
+func (c *urlResourceCache) get(uri string) *cachedResource {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[uri]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cachedResource)
+}
+
+func (c *urlResourceCache) set(uri string, entry *cachedResource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.uri = uri
+	if elem, ok := c.entries[uri]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	if c.order.Len() >= maxResourceCacheEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResource).uri)
+	}
+	c.entries[uri] = c.order.PushFront(entry)
+}
+
+// flush removes the entry for uri, or every entry when uri is empty
+func (c *urlResourceCache) flush(uri string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if uri == "" {
+		n := len(c.entries)
+		c.entries = make(map[string]*list.Element)
+		c.order.Init()
+		return n
+	}
+	if elem, ok := c.entries[uri]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, uri)
+		return 1
+	}
+	return 0
+}
+
+// Invalidate drops cached content for a resource URI, or for all resources when uri is
+// empty, so the next read downloads it again. It returns the number of entries removed.
+func (l *ResourceLoader) Invalidate(uri string) int {
+	return l.cache.flush(uri)
+}
+
+// loadURL returns a URL resource, serving it from the cache while fresh and
+// revalidating stale copies with a conditional GET
+func (l *ResourceLoader) loadURL(ctx context.Context, resource *config.ResourceConfig) (*cachedResource, error) {
+	ttl := resource.CacheTTL.ToDuration()
+	if ttl <= 0 {
+		return l.fetchURL(ctx, resource.URL, nil)
+	}
+
+	cached := l.cache.get(resource.URI)
+	if cached != nil && cached.url != resource.URL {
+		cached = nil
+	}
+	if cached != nil && time.Since(cached.fetchedAt) < ttl {
+		return cached, nil
+	}
+
+	entry, err := l.fetchURL(ctx, resource.URL, cached)
+	if err != nil {
+		return nil, err
+	}
+	l.cache.set(resource.URI, entry)
+	return entry, nil
+}
+
+// fetchURL downloads rawURL. When a previous copy is given its validators are sent and
+// a 304 Not Modified response returns that copy with a renewed fetch time.
+func (l *ResourceLoader) fetchURL(ctx context.Context, rawURL string, previous *cachedResource) (*cachedResource, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource URL %s: %w", rawURL, err)
+	}
+	if previous != nil {
+		if previous.etag != "" {
+			req.Header.Set("If-None-Match", previous.etag)
+		}
+		if previous.lastModified != "" {
+			req.Header.Set("If-Modified-Since", previous.lastModified)
+		}
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && previous != nil {
+		renewed := *previous
+		renewed.fetchedAt = time.Now()
+		return &renewed, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error %d when fetching %s", resp.StatusCode, rawURL)
+	}
+
+	if resp.ContentLength > l.maxSize {
+		return nil, fmt.Errorf("%s is %d bytes, over the %d byte resource limit", rawURL, resp.ContentLength, l.maxSize)
+	}
+
+	data, err := l.readLimited(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", rawURL, err)
+	}
+
+	return &cachedResource{
+		url:          rawURL,
+		data:         data,
+		contentType:  resp.Header.Get("Content-Type"),
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		fetchedAt:    time.Now(),
+	}, nil
+}
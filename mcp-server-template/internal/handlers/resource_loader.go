@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// ResourceLoader reads resource content from inline config, local files or URLs. It
+// backs both the mcp-go resource handlers and the JSON-RPC resources/read method.
+type ResourceLoader struct {
+	client    *http.Client
+	logger    *logrus.Logger
+	resources map[string]*config.ResourceConfig // Static resources by URI
+	templates []*resourceTemplate
+	maxSize   int64
+	cache     *urlResourceCache
+}
+
+// defaultMaxResourceSize applies when the runtime config leaves the limit unset
+const defaultMaxResourceSize = 10 << 20
+
+// ResourceContent is the loaded body of a resource
+type ResourceContent struct {
+	URI      string
+	MimeType string
+	Data     []byte
+}
+
+// NewResourceLoader creates a resource loader
+func NewResourceLoader(cfg *config.Config, logger *logrus.Logger) *ResourceLoader {
+	loader := &ResourceLoader{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		logger:    logger,
+		maxSize:   cfg.Runtime.MaxResourceSize,
+		cache:     newURLResourceCache(),
+		resources: make(map[string]*config.ResourceConfig, len(cfg.Resources)),
+	}
+	for i := range cfg.Resources {
+		loader.resources[cfg.Resources[i].URI] = &cfg.Resources[i]
+	}
+	if loader.maxSize <= 0 {
+		loader.maxSize = defaultMaxResourceSize
+	}
+
+	for i := range cfg.ResourceTemplates {
+		tmpl, err := compileResourceTemplate(&cfg.ResourceTemplates[i])
+		if err != nil {
+			loader.logger.WithError(err).Error("Skipping resource template")
+			continue
+		}
+		loader.templates = append(loader.templates, tmpl)
+	}
+
+	return loader
+}
+
+// Load retrieves the content of a resource from its configured source
+func (l *ResourceLoader) Load(ctx context.Context, resource *config.ResourceConfig) (*ResourceContent, error) {
+	content := &ResourceContent{URI: resource.URI, MimeType: resource.MimeType}
+
+	switch {
+	case resource.Content != "":
+		content.Data = []byte(resource.Content)
+		// Binary inline content can only be written in JSON config as base64
+		if isBinaryMimeType(resource.MimeType) {
+			if decoded, err := base64.StdEncoding.DecodeString(resource.Content); err == nil {
+				content.Data = decoded
+			}
+		}
+	case resource.FilePath != "":
+		data, err := l.loadFile(resource.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		content.Data = data
+	case resource.URL != "":
+		entry, err := l.loadURL(ctx, resource)
+		if err != nil {
+			return nil, err
+		}
+		content.Data = entry.data
+		if content.MimeType == "" {
+			content.MimeType = entry.contentType
+		}
+	default:
+		return nil, fmt.Errorf("no content source specified for resource %s", resource.URI)
+	}
+
+	return content, nil
+}
+
+// loadFile reads a file, resolving relative paths against the working directory
+func (l *ResourceLoader) loadFile(path string) ([]byte, error) {
+	if !filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		path = filepath.Join(wd, path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err == nil && info.Size() > l.maxSize {
+		return nil, fmt.Errorf("file %s is %d bytes, over the %d byte resource limit", path, info.Size(), l.maxSize)
+	}
+
+	data, err := l.readLimited(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// readLimited reads r fully, failing once more than the size limit has been read so a
+// growing file or a server that omits Content-Length cannot exhaust memory
+func (l *ResourceLoader) readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, l.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > l.maxSize {
+		return nil, fmt.Errorf("content exceeds the %d byte resource limit", l.maxSize)
+	}
+	return data, nil
+}
+
+// MCPContents converts the content to the MCP resource contents shape: text for
+// textual mime types, base64 "blob" for binary ones
+func (c *ResourceContent) MCPContents() interface{} {
+	contents := mcp.ResourceContents{URI: c.URI, MIMEType: c.MimeType}
+	if isBinaryMimeType(c.MimeType) {
+		return mcp.BlobResourceContents{
+			ResourceContents: contents,
+			Blob:             base64.StdEncoding.EncodeToString(c.Data),
+		}
+	}
+	return mcp.TextResourceContents{
+		ResourceContents: contents,
+		Text:             string(c.Data),
+	}
+}
+
+// isBinaryMimeType reports whether content of this type cannot be carried as text
+func isBinaryMimeType(mimeType string) bool {
+	mimeType = strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]))
+	switch {
+	case strings.HasPrefix(mimeType, "text/"):
+		return false
+	case strings.HasPrefix(mimeType, "image/svg"):
+		return false
+	case strings.HasPrefix(mimeType, "image/"),
+		strings.HasPrefix(mimeType, "audio/"),
+		strings.HasPrefix(mimeType, "video/"),
+		strings.HasPrefix(mimeType, "font/"):
+		return true
+	}
+
+	switch mimeType {
+	case "application/pdf", "application/octet-stream", "application/zip", "application/gzip":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// errSubscriberClosed is returned for subscriptions from a client that isn't connected
+var errSubscriberClosed = errors.New("client is not connected")
+
+// resourceSubscriptions tracks which streaming clients subscribed to which resource
+// URIs, and watches the files behind file-backed ones. Each file's directory is watched
+// rather than the file itself, so editors that save by replacing the file are still seen.
+// The watcher runs only while at least one file is subscribed.
+type resourceSubscriptions struct {
+	logger *logrus.Logger
+	notify func(clientID, uri string)
+
+	mu      sync.Mutex
+	clients map[string]map[string]string // open client id -> subscribed URI -> watched file ("" if none)
+	files   map[string]map[string]int    // watched file -> URI -> subscriber count
+	dirs    map[string]int               // watched directory -> files watched in it
+	watcher *fsnotify.Watcher
+}
+
+func newResourceSubscriptions(logger *logrus.Logger, notify func(clientID, uri string)) *resourceSubscriptions {
+	return &resourceSubscriptions{
+		logger:  logger,
+		notify:  notify,
+		clients: make(map[string]map[string]string),
+		files:   make(map[string]map[string]int),
+		dirs:    make(map[string]int),
+	}
+}
+
+// Open registers a connected client, which may then subscribe until DropClient
+func (s *resourceSubscriptions) Open(clientID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clients[clientID] == nil {
+		s.clients[clientID] = make(map[string]string)
+	}
+}
+
+// Subscribe records that a client wants updates for uri. path is the resource's file,
+// or "" for resources that aren't file-backed, which are tracked but never change.
+// Clients that aren't open get errSubscriberClosed.
+func (s *resourceSubscriptions) Subscribe(clientID, uri, path string) error {
+	if path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		path = filepath.Clean(abs)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.clients[clientID]
+	if subs == nil {
+		return errSubscriberClosed
+	}
+	if _, ok := subs[uri]; ok {
+		return nil
+	}
+	if path != "" {
+		if err := s.watchLocked(uri, path); err != nil {
+			return err
+		}
+	}
+	subs[uri] = path
+	return nil
+}
+
+// Unsubscribe drops one of a client's subscriptions
+func (s *resourceSubscriptions) Unsubscribe(clientID, uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if path, ok := s.clients[clientID][uri]; ok {
+		delete(s.clients[clientID], uri)
+		s.unwatchLocked(uri, path)
+	}
+}
+
+// DropClient removes every subscription of a client that disconnected and closes it to
+// new ones
+func (s *resourceSubscriptions) DropClient(clientID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for uri, path := range s.clients[clientID] {
+		s.unwatchLocked(uri, path)
+	}
+	delete(s.clients, clientID)
+}
+
+func (s *resourceSubscriptions) watchLocked(uri, path string) error {
+	if s.watcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		s.watcher = watcher
+		go s.run(watcher)
+	}
+
+	if s.files[path] == nil {
+		dir := filepath.Dir(path)
+		if s.dirs[dir] == 0 {
+			if err := s.watcher.Add(dir); err != nil {
+				s.closeIfIdleLocked()
+				return err
+			}
+		}
+		s.dirs[dir]++
+		s.files[path] = make(map[string]int)
+	}
+	s.files[path][uri]++
+	return nil
+}
+
+func (s *resourceSubscriptions) unwatchLocked(uri, path string) {
+	if path == "" || s.files[path] == nil {
+		return
+	}
+	if s.files[path][uri]--; s.files[path][uri] <= 0 {
+		delete(s.files[path], uri)
+	}
+	if len(s.files[path]) > 0 {
+		return
+	}
+	delete(s.files, path)
+
+	dir := filepath.Dir(path)
+	if s.dirs[dir]--; s.dirs[dir] <= 0 {
+		delete(s.dirs, dir)
+		if s.watcher != nil {
+			s.watcher.Remove(dir)
+		}
+	}
+	s.closeIfIdleLocked()
+}
+
+// closeIfIdleLocked stops the watcher once no file is watched
+func (s *resourceSubscriptions) closeIfIdleLocked() {
+	if len(s.files) == 0 && s.watcher != nil {
+		s.watcher.Close()
+		s.watcher = nil
+	}
+}
+
+// run turns file events into update notifications until the watcher is closed
+func (s *resourceSubscriptions) run(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			s.changed(filepath.Clean(event.Name))
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			s.logger.WithError(err).Warn("Resource file watcher error")
+		}
+	}
+}
+
+// changed notifies every client subscribed to a resource backed by path
+func (s *resourceSubscriptions) changed(path string) {
+	type target struct{ clientID, uri string }
+
+	s.mu.Lock()
+	var targets []target
+	if s.files[path] != nil {
+		for clientID, subs := range s.clients {
+			for uri, file := range subs {
+				if file == path {
+					targets = append(targets, target{clientID, uri})
+				}
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	for _, t := range targets {
+		s.notify(t.clientID, t.uri)
+	}
+}
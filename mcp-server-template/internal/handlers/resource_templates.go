@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"mcp-server-template/internal/config"
+)
+
+// resourceTemplate is a compiled resource template that matches concrete URIs
+type resourceTemplate struct {
+	config  *config.ResourceTemplateConfig
+	pattern *regexp.Regexp
+	names   []string
+}
+
+// compileResourceTemplate turns "file:///logs/{date}.log" into a regexp in which each
+// variable matches a single path segment
+func compileResourceTemplate(tmpl *config.ResourceTemplateConfig) (*resourceTemplate, error) {
+	var pattern strings.Builder
+	var names []string
+
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range config.TemplateVariablePattern.FindAllStringSubmatchIndex(tmpl.URITemplate, -1) {
+		pattern.WriteString(regexp.QuoteMeta(tmpl.URITemplate[last:loc[0]]))
+		pattern.WriteString(`([^/]+)`)
+		names = append(names, tmpl.URITemplate[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(tmpl.URITemplate[last:]))
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource template %s: %w", tmpl.URITemplate, err)
+	}
+	return &resourceTemplate{config: tmpl, pattern: re, names: names}, nil
+}
+
+// match extracts the template variables from uri
+func (t *resourceTemplate) match(uri string) (map[string]string, bool) {
+	groups := t.pattern.FindStringSubmatch(uri)
+	if groups == nil {
+		return nil, false
+	}
+
+	vars := make(map[string]string, len(t.names))
+	for i, name := range t.names {
+		vars[name] = groups[i+1]
+	}
+	return vars, true
+}
+
+// resolve builds the concrete resource for uri by substituting vars into the template's
+// file path or URL
+func (t *resourceTemplate) resolve(uri string, vars map[string]string) (*config.ResourceConfig, error) {
+	for name, value := range vars {
+		// Variables never span segments, but "." and ".." would still escape the directory
+		if value == "." || value == ".." || strings.ContainsAny(value, `\`) {
+			return nil, fmt.Errorf("invalid value %q for {%s}", value, name)
+		}
+	}
+
+	resource := &config.ResourceConfig{
+		URI:         uri,
+		Name:        t.config.Name,
+		Description: t.config.Description,
+		MimeType:    t.config.MimeType,
+		CacheTTL:    t.config.CacheTTL,
+	}
+
+	substitute := func(s string, escape func(string) string) string {
+		return config.TemplateVariablePattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+			return escape(vars[placeholder[1:len(placeholder)-1]])
+		})
+	}
+
+	if t.config.FilePath != "" {
+		resource.FilePath = substitute(t.config.FilePath, func(v string) string { return v })
+	}
+	if t.config.URL != "" {
+		resource.URL = substitute(t.config.URL, url.PathEscape)
+	}
+	return resource, nil
+}
+
+// ResolveTemplate matches uri against the configured resource templates and returns the
+// concrete resource it names, or nil when no template matches
+func (l *ResourceLoader) ResolveTemplate(uri string) (*config.ResourceConfig, error) {
+	for _, tmpl := range l.templates {
+		if vars, ok := tmpl.match(uri); ok {
+			return tmpl.resolve(uri, vars)
+		}
+	}
+	return nil, nil
+}
+
+// Resolve returns the configured resource for uri, trying static resources before
+// templates, or nil when none matches
+func (l *ResourceLoader) Resolve(uri string) (*config.ResourceConfig, error) {
+	if resource, ok := l.resources[uri]; ok {
+		return resource, nil
+	}
+	return l.ResolveTemplate(uri)
+}
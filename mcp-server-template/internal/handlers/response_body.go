@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultMaxResponseBytes applies when the runtime config leaves max_response_bytes unset
+const defaultMaxResponseBytes = 10 << 20
+
+// streamChunkBytes is how much of a streaming tool's body is read between progress
+// reports, and the size of each text item in its result
+const streamChunkBytes = 64 << 10
+
+// maxResponseBytes is the largest upstream body read for a tool call
+func (h *HTTPClient) maxResponseBytes() int64 {
+	if h.config != nil && h.config.Runtime.MaxResponseBytes > 0 {
+		return h.config.Runtime.MaxResponseBytes
+	}
+	return defaultMaxResponseBytes
+}
+
+// readBody reads at most limit bytes of an upstream body and reports whether more was
+// left. When streaming, it reads chunk by chunk and reports the bytes received as
+// progress, out of size when the upstream declared a length.
+func readBody(ctx context.Context, body io.Reader, limit, size int64, streaming bool) ([]byte, bool, error) {
+	var buf bytes.Buffer
+	if size > 0 && size <= limit {
+		buf.Grow(int(size))
+	}
+
+	reader := io.LimitReader(body, limit+1)
+	if !streaming {
+		if _, err := buf.ReadFrom(reader); err != nil {
+			return nil, false, err
+		}
+	} else {
+		chunk := make([]byte, streamChunkBytes)
+		for {
+			n, err := io.ReadFull(reader, chunk)
+			buf.Write(chunk[:n])
+			if n > 0 {
+				reportProgress(ctx, float64(buf.Len()), float64(size), "")
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			if err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	data := buf.Bytes()
+	if int64(len(data)) <= limit {
+		return data, false, nil
+	}
+	return validUTF8Prefix(data[:limit]), true, nil
+}
+
+// truncationMarker is appended to a body cut at limit bytes
+func truncationMarker(limit int64) string {
+	return fmt.Sprintf("\n... [truncated: response exceeded %d bytes]", limit)
+}
+
+// validUTF8Prefix drops a rune split by a byte limit from the end of data
+func validUTF8Prefix(data []byte) []byte {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+// chunkedTextResult returns text as a tool result of several text items no larger than
+// streamChunkBytes, so clients can render a large body incrementally
+func chunkedTextResult(text string) *mcp.CallToolResult {
+	result := &mcp.CallToolResult{}
+	for len(text) > streamChunkBytes {
+		cut := streamChunkBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			cut = streamChunkBytes // not UTF-8 text; split anywhere
+		}
+		result.Content = append(result.Content, mcp.NewTextContent(text[:cut]))
+		text = text[cut:]
+	}
+	result.Content = append(result.Content, mcp.NewTextContent(text))
+	return result
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// noCacheArgument is a reserved tool argument that forces a live request
+const noCacheArgument = "__no_cache"
+
+// maxCacheEntries bounds the cache before expired entries are pruned
+const maxCacheEntries = 1024
+
+// responseCache is an in-memory TTL cache of successful API responses
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	toolName  string
+	response  *APIResponse
+	expiresAt time.Time
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{entries: make(map[string]cacheEntry)}
+}
+
+// Get returns a copy of the cached response for key if it has not expired
+func (c *responseCache) Get(key string) (*APIResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	resp := *entry.response
+	return &resp, true
+}
+
+// Set stores a tool's response under key for the given ttl
+func (c *responseCache) Set(toolName, key string, resp *APIResponse, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= maxCacheEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+
+	stored := *resp
+	c.entries[key] = cacheEntry{toolName: toolName, response: &stored, expiresAt: now.Add(ttl)}
+}
+
+// Flush removes cached entries for toolName, or all entries when toolName is empty
+func (c *responseCache) Flush(toolName string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cleared := 0
+	for key, entry := range c.entries {
+		if toolName == "" || entry.toolName == toolName {
+			delete(c.entries, key)
+			cleared++
+		}
+	}
+	return cleared
+}
+
+// responseCacheKey builds a cache key from the method, expanded URL and sorted params
+func responseCacheKey(method, expandedURL string, params map[string]interface{}) string {
+	// encoding/json sorts map keys, giving a stable representation of params
+	paramBytes, _ := json.Marshal(params)
+	return strings.ToUpper(method) + " " + expandedURL + " " + string(paramBytes)
+}
+
+// isCacheableMethod reports whether responses for method may be cached
+func isCacheableMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD":
+		return true
+	}
+	return false
+}
+
+// popNoCache removes the reserved no-cache argument and reports whether it was set
+func popNoCache(params map[string]interface{}) (map[string]interface{}, bool) {
+	return popFlag(params, noCacheArgument)
+}
+
+// popFlag removes a reserved boolean argument and reports whether it was set. params
+// itself is left untouched.
+func popFlag(params map[string]interface{}, name string) (map[string]interface{}, bool) {
+	value, exists := params[name]
+	if !exists {
+		return params, false
+	}
+
+	cleaned := make(map[string]interface{}, len(params)-1)
+	for k, v := range params {
+		if k != name {
+			cleaned[k] = v
+		}
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return cleaned, v
+	case string:
+		return cleaned, v == "true" || v == "1"
+	}
+	return cleaned, value != nil
+}
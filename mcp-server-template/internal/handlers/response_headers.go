@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// appendResponseHeaders adds the response headers a tool lists in expose_headers to
+// its result, as a separate {"headers": {...}} text item so the body is left untouched.
+// Headers the upstream didn't send are left out.
+func appendResponseHeaders(result *mcp.CallToolResult, names []string, response *APIResponse) {
+	if len(names) == 0 {
+		return
+	}
+
+	headers := make(map[string]string, len(names))
+	for _, name := range names {
+		if value, ok := response.Headers[http.CanonicalHeaderKey(name)]; ok {
+			headers[name] = value
+		}
+	}
+	if len(headers) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"headers": headers})
+	if err != nil {
+		return
+	}
+	result.Content = append(result.Content, mcp.NewTextContent(string(data)))
+}
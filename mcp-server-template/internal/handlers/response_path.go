@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// extractResponsePath resolves a dotted path such as "data.items[0].name" (an
+// optional leading "$." is accepted for JSONPath familiarity) against parsed JSON
+func extractResponsePath(data interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return data, nil
+	}
+
+	current := data
+	for _, segment := range splitPathSegments(path) {
+		if segment.index >= 0 {
+			arr, ok := current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot index non-array at %q", segment.raw)
+			}
+			if segment.index >= len(arr) {
+				return nil, fmt.Errorf("index %d out of range at %q", segment.index, segment.raw)
+			}
+			current = arr[segment.index]
+			continue
+		}
+
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot read field of non-object at %q", segment.raw)
+		}
+		value, exists := obj[segment.key]
+		if !exists {
+			return nil, fmt.Errorf("field %q not found", segment.raw)
+		}
+		current = value
+	}
+
+	return current, nil
+}
+
+type pathSegment struct {
+	raw   string
+	key   string
+	index int
+}
+
+// splitPathSegments turns "a.b[0].c" into field and index segments
+func splitPathSegments(path string) []pathSegment {
+	var segments []pathSegment
+	for _, part := range strings.Split(path, ".") {
+		for part != "" {
+			open := strings.Index(part, "[")
+			if open == -1 {
+				segments = append(segments, pathSegment{raw: part, key: part, index: -1})
+				break
+			}
+			if open > 0 {
+				segments = append(segments, pathSegment{raw: part[:open], key: part[:open], index: -1})
+			}
+			end := strings.Index(part[open:], "]")
+			if end == -1 {
+				segments = append(segments, pathSegment{raw: part, key: part, index: -1})
+				break
+			}
+			raw := part[open : open+end+1]
+			index, err := strconv.Atoi(raw[1 : len(raw)-1])
+			if err != nil || index < 0 {
+				// Non-numeric brackets are treated as field names, e.g. ["name"]
+				key := strings.Trim(raw[1:len(raw)-1], `"'`)
+				segments = append(segments, pathSegment{raw: raw, key: key, index: -1})
+			} else {
+				segments = append(segments, pathSegment{raw: raw, index: index})
+			}
+			part = part[open+end+1:]
+		}
+	}
+	return segments
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mcp-server-template/internal/config"
+)
+
+const (
+	defaultRetryBaseDelay = 1 * time.Second
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// isRetryableStatus reports whether a non-success status code is worth retrying.
+// Without an explicit list, 429 and 5xx are retried and other 4xx fail immediately.
+func isRetryableStatus(statusCode int, retry *config.RetryConfig) bool {
+	if retry != nil && len(retry.RetryableStatusCodes) > 0 {
+		for _, code := range retry.RetryableStatusCodes {
+			if code == statusCode {
+				return true
+			}
+		}
+		return false
+	}
+
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// retryDelay computes the wait before the given retry attempt (1-based). A 429
+// response carrying Retry-After takes precedence over the configured strategy.
+func retryDelay(retry *config.RetryConfig, attempt int, resp *http.Response) time.Duration {
+	maxDelay := defaultRetryMaxDelay
+	if retry != nil && retry.MaxDelay > 0 {
+		maxDelay = retry.MaxDelay.ToDuration()
+	}
+
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(wait, maxDelay)
+		}
+	}
+
+	// Legacy behaviour: linear backoff of one second per attempt
+	if retry == nil {
+		return time.Duration(attempt) * time.Second
+	}
+
+	base := defaultRetryBaseDelay
+	if retry.BaseDelay > 0 {
+		base = retry.BaseDelay.ToDuration()
+	}
+
+	switch retry.Strategy {
+	case "exponential":
+		backoff := float64(base) * math.Pow(2, float64(attempt-1))
+		if backoff > float64(maxDelay) {
+			backoff = float64(maxDelay)
+		}
+		// Equal jitter: half fixed, half random, to spread out synchronized clients
+		half := time.Duration(backoff / 2)
+		return half + time.Duration(rand.Int63n(int64(half)+1))
+	default:
+		return min(base, maxDelay)
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		wait := time.Until(at)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sessionContextKey is the template field, {{.ctx.name}}, holding a connection's saved values
+const sessionContextKey = "ctx"
+
+// maxSessionContextBytes caps the JSON size of the values one connection keeps
+const maxSessionContextBytes = 256 << 10
+
+// sessionReference matches {{.ctx.name}} references in a template
+var sessionReference = regexp.MustCompile(`\.` + sessionContextKey + `\.([A-Za-z0-9_]+)`)
+
+// sessionContexts holds the values tools saved with save_as, per streaming connection,
+// so later tool calls on the same connection can use them in templates
+type sessionContexts struct {
+	mu     sync.Mutex
+	values map[string]*savedValues // client id -> saved values
+}
+
+// savedValues are one connection's values along with their encoded sizes
+type savedValues struct {
+	values map[string]interface{}
+	sizes  map[string]int
+	total  int
+}
+
+func newSessionContexts() *sessionContexts {
+	return &sessionContexts{values: make(map[string]*savedValues)}
+}
+
+// Snapshot returns a copy of a connection's values, safe to use while other calls save
+func (s *sessionContexts) Snapshot(clientID string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := s.values[clientID]
+	if saved == nil {
+		return map[string]interface{}{}
+	}
+	snapshot := make(map[string]interface{}, len(saved.values))
+	for key, value := range saved.values {
+		snapshot[key] = value
+	}
+	return snapshot
+}
+
+// Save stores a value for a connection, replacing any earlier value under key. It
+// refuses a value that would take the connection over maxSessionContextBytes.
+func (s *sessionContexts) Save(clientID, key string, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := s.values[clientID]
+	if saved == nil {
+		saved = &savedValues{values: make(map[string]interface{}), sizes: make(map[string]int)}
+		s.values[clientID] = saved
+	}
+	total := saved.total - saved.sizes[key] + len(encoded)
+	if total > maxSessionContextBytes {
+		return fmt.Errorf("saving %d bytes would exceed the %d bytes a connection may keep", len(encoded), maxSessionContextBytes)
+	}
+	saved.values[key] = value
+	saved.sizes[key] = len(encoded)
+	saved.total = total
+	return nil
+}
+
+// Drop forgets a connection's values once it disconnects
+func (s *sessionContexts) Drop(clientID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, clientID)
+}
+
+type sessionValuesKey struct{}
+
+// withSessionValues returns a copy of ctx carrying a connection's saved values for templates
+func withSessionValues(ctx context.Context, values map[string]interface{}) context.Context {
+	return context.WithValue(ctx, sessionValuesKey{}, values)
+}
+
+// templateData returns the data request templates are rendered with: the call's
+// arguments, plus the connection's saved values under "ctx". Calls without a
+// connection get an empty ctx, so an argument can't stand in for saved values.
+func templateData(ctx context.Context, params map[string]interface{}) map[string]interface{} {
+	values, ok := ctx.Value(sessionValuesKey{}).(map[string]interface{})
+	if !ok {
+		values = map[string]interface{}{}
+	}
+	data := make(map[string]interface{}, len(params)+1)
+	for name, value := range params {
+		data[name] = value
+	}
+	data[sessionContextKey] = values
+	return data
+}
+
+// missingSessionValue returns an error for the first {{.ctx.name}} in a template that
+// data has no saved value for, rather than letting it render as "<no value>"
+func missingSessionValue(text string, data map[string]interface{}) error {
+	values, _ := data[sessionContextKey].(map[string]interface{})
+	for _, match := range sessionReference.FindAllStringSubmatch(text, -1) {
+		if _, ok := values[match[1]]; !ok {
+			return fmt.Errorf("%s.%s not set", sessionContextKey, match[1])
+		}
+	}
+	return nil
+}
+
+// saveResults stores the parts of a successful response a tool's save_as names
+func (h *ToolHandler) saveResults(ctx context.Context, tool *config.ToolConfig, response *APIResponse) {
+	clientID := clientIDFromContext(ctx)
+	if clientID == "" || len(tool.SaveAs) == 0 {
+		return
+	}
+
+	for key, path := range tool.SaveAs {
+		var value interface{} = response.Body
+		if response.Data != nil {
+			value = response.Data
+		}
+		if path != "" {
+			extracted, err := extractResponsePath(response.Data, path)
+			if err != nil {
+				requestLogger(ctx, h.logger).WithError(err).WithFields(logrus.Fields{
+					"tool_name": tool.Name,
+					"key":       key,
+					"path":      path,
+				}).Warn("save_as path did not resolve, nothing saved")
+				continue
+			}
+			value = extracted
+		}
+		if err := h.contexts.Save(clientID, key, value); err != nil {
+			requestLogger(ctx, h.logger).WithError(err).WithFields(logrus.Fields{
+				"tool_name": tool.Name,
+				"key":       key,
+			}).Warn("save_as value not saved")
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// sseKeepAliveInterval is how often a comment is written to keep idle streams open
+const sseKeepAliveInterval = 15 * time.Second
+
+// sseEventBuffer is the number of events queued per session before sends block
+const sseEventBuffer = 64
+
+// SSEHandler serves the MCP HTTP+SSE transport: clients open a long-lived event
+// stream and POST JSON-RPC messages whose responses are delivered over that stream
+type SSEHandler struct {
+	rpc         *JSONRPCHandler
+	logger      *logrus.Logger
+	messagePath string
+
+	mu       sync.RWMutex
+	sessions map[string]*sseSession
+	closed   chan struct{}
+	once     sync.Once
+}
+
+// sseSession is a single connected event stream
+type sseSession struct {
+	id     string
+	ctx    context.Context
+	cancel context.CancelFunc
+	events chan sseEvent
+}
+
+type sseEvent struct {
+	name string
+	data []byte
+}
+
+// NewSSEHandler creates an SSE transport that dispatches through the JSON-RPC handler.
+// messagePath is the URL clients POST messages to, announced in the "endpoint" event.
+func NewSSEHandler(rpc *JSONRPCHandler, messagePath string) *SSEHandler {
+	return &SSEHandler{
+		rpc:         rpc,
+		logger:      rpc.logger,
+		messagePath: messagePath,
+		sessions:    make(map[string]*sseSession),
+		closed:      make(chan struct{}),
+	}
+}
+
+// ServeStream handles GET requests that open the event stream
+func (h *SSEHandler) ServeStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Streams outlive the server's write timeout, so lift the deadline for this connection
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.WithError(err).Debug("Could not clear write deadline for SSE stream")
+	}
+
+	session := h.openSession(r.Context())
+	defer h.closeSession(session)
+
+	// Register before announcing the endpoint, so the first message can subscribe
+	notifications, unsubscribe := h.rpc.Notifications(session.id)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	endpoint := fmt.Sprintf("%s?sessionId=%s", h.messagePath, session.id)
+	writeSSEEvent(w, sseEvent{name: "endpoint", data: []byte(endpoint)})
+	flusher.Flush()
+
+	h.logger.WithField("session_id", session.id).Info("SSE client connected")
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-session.ctx.Done():
+			h.logger.WithField("session_id", session.id).Info("SSE client disconnected")
+			return
+		case <-h.closed:
+			return
+		case event := <-session.events:
+			writeSSEEvent(w, event)
+			flusher.Flush()
+		case notification := <-notifications:
+			data, err := json.Marshal(notification)
+			if err != nil {
+				continue
+			}
+			writeSSEEvent(w, sseEvent{name: "message", data: data})
+			flusher.Flush()
+		case <-keepAlive.C:
+			io.WriteString(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// ServeMessage handles POSTed JSON-RPC messages for an open session. The message is
+// acknowledged with 202 Accepted and its response is pushed over the event stream.
+func (h *SSEHandler) ServeMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := h.session(r.URL.Query().Get("sessionId"))
+	if session == nil {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.rpc.MaxRequestBytes()))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Work is bound to the stream, not to this short-lived POST
+	requestID := requestIDFromHTTP(r)
+	ctx := withClientID(WithRequestID(session.ctx, requestID), session.id)
+	go func() {
+		if response := h.rpc.HandleMessage(ctx, body); response != nil {
+			h.send(session, "message", response)
+		}
+	}()
+
+	w.Header().Set(RequestIDHeader, requestID)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Close terminates all open streams, e.g. during server shutdown
+func (h *SSEHandler) Close() {
+	h.once.Do(func() { close(h.closed) })
+}
+
+func (h *SSEHandler) openSession(parent context.Context) *sseSession {
+	ctx, cancel := context.WithCancel(parent)
+	session := &sseSession{
+		id:     uuid.NewString(),
+		ctx:    ctx,
+		cancel: cancel,
+		events: make(chan sseEvent, sseEventBuffer),
+	}
+
+	h.mu.Lock()
+	h.sessions[session.id] = session
+	h.mu.Unlock()
+
+	return session
+}
+
+func (h *SSEHandler) closeSession(session *sseSession) {
+	h.mu.Lock()
+	delete(h.sessions, session.id)
+	h.mu.Unlock()
+	session.cancel()
+}
+
+func (h *SSEHandler) session(id string) *sseSession {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.sessions[id]
+}
+
+// send queues a JSON payload as an event for the session, dropping it if the stream is gone
+func (h *SSEHandler) send(session *sseSession, name string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to encode SSE event")
+		return
+	}
+
+	select {
+	case session.events <- sseEvent{name: name, data: data}:
+	case <-session.ctx.Done():
+	case <-h.closed:
+	}
+}
+
+// writeSSEEvent writes a single server-sent event
+func writeSSEEvent(w io.Writer, event sseEvent) {
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, event.data)
+}
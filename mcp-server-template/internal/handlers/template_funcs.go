@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/url"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// templateFuncs is available to every tool template: endpoints, query parameters,
+// headers, bodies, multipart fields and exec arguments. It is Sprig's function map,
+// which already provides env, plus pathescape for endpoint segments; text/template
+// itself supplies urlquery.
+var templateFuncs = func() template.FuncMap {
+	funcs := sprig.TxtFuncMap()
+	funcs["pathescape"] = url.PathEscape
+	return funcs
+}()
+
+// promptFuncs is templateFuncs without the functions that read the server's
+// environment. Prompt text can come from a remote URL and is rendered for any client,
+// so it must not be able to print API keys or tokens.
+var promptFuncs = func() template.FuncMap {
+	funcs := make(template.FuncMap, len(templateFuncs))
+	for name, fn := range templateFuncs {
+		funcs[name] = fn
+	}
+	delete(funcs, "env")
+	delete(funcs, "expandenv")
+	return funcs
+}()
+
+// parseToolTemplate parses a tool template with templateFuncs available
+func parseToolTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"mcp-server-template/internal/config"
+)
+
+// buildTLSConfig turns upstream TLS settings into a tls.Config. A CA bundle is added
+// to the system roots rather than replacing them so public APIs keep working.
+func buildTLSConfig(tlsCfg *config.UpstreamTLSConfig) (*tls.Config, error) {
+	result := &tls.Config{MinVersion: tls.VersionTLS12}
+	if tlsCfg == nil {
+		return result, nil
+	}
+
+	result.InsecureSkipVerify = tlsCfg.InsecureSkipVerify
+	result.ServerName = tlsCfg.ServerName
+
+	if tlsCfg.CAFile != "" {
+		pem, err := os.ReadFile(tlsCfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", tlsCfg.CAFile)
+		}
+		result.RootCAs = pool
+	}
+
+	if tlsCfg.CertFile != "" || tlsCfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(tlsCfg.CertFile, tlsCfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		result.Certificates = []tls.Certificate{cert}
+	}
+
+	return result, nil
+}
+
+// Transport settings used when the runtime config leaves them unset
+const (
+	defaultHTTPTimeout     = 30 * time.Second
+	defaultMaxIdleConns    = 100
+	defaultIdleConnTimeout = 90 * time.Second
+)
+
+// newHTTPTransport creates the transport used for upstream calls
+func newHTTPTransport(tlsConfig *tls.Config, proxy func(*http.Request) (*url.URL, error), settings *config.HTTPClientConfig) *http.Transport {
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		MaxIdleConns:          settings.MaxIdleConns,
+		MaxIdleConnsPerHost:   settings.MaxIdleConnsPerHost,
+		IdleConnTimeout:       settings.IdleConnTimeout.ToDuration(),
+		TLSHandshakeTimeout:   settings.TLSHandshakeTimeout.ToDuration(),
+		ResponseHeaderTimeout: settings.ResponseHeaderTimeout.ToDuration(),
+		DisableCompression:    false,
+		TLSClientConfig:       tlsConfig,
+	}
+	if transport.MaxIdleConns == 0 {
+		transport.MaxIdleConns = defaultMaxIdleConns
+	}
+	if transport.IdleConnTimeout == 0 {
+		transport.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	if settings.DialTimeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: settings.DialTimeout.ToDuration()}).DialContext
+	}
+	return transport
+}
+
+// httpTimeout is the overall limit on an upstream request
+func httpTimeout(settings *config.HTTPClientConfig) time.Duration {
+	if settings.Timeout > 0 {
+		return settings.Timeout.ToDuration()
+	}
+	return defaultHTTPTimeout
+}
+
+// proxyFunc resolves a configured proxy URL. An empty value defers to the standard
+// proxy environment variables and "none" disables proxying; socks5 URLs are dialed by
+// the transport itself.
+func proxyFunc(rawURL string) (func(*http.Request) (*url.URL, error), error) {
+	switch rawURL {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case "none":
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// clientFor returns the HTTP client for a tool, building and caching a dedicated one
+// the first time a tool with its own TLS or proxy settings is called
+func (h *HTTPClient) clientFor(tool *config.ToolConfig) (*http.Client, error) {
+	if tool.TLS == nil && tool.ProxyURL == "" {
+		return h.client, h.clientErr
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if client, ok := h.toolClients[tool.Name]; ok {
+		return client, nil
+	}
+
+	tlsSettings := tool.TLS
+	if tlsSettings == nil {
+		tlsSettings = &h.config.Security.UpstreamTLS
+	}
+	tlsConfig, err := buildTLSConfig(tlsSettings)
+	if err != nil {
+		return nil, fmt.Errorf("tool %s: %w", tool.Name, err)
+	}
+
+	proxyURL := tool.ProxyURL
+	if proxyURL == "" {
+		proxyURL = h.config.Runtime.ProxyURL
+	}
+	proxy, err := proxyFunc(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("tool %s: %w", tool.Name, err)
+	}
+
+	client := &http.Client{
+		Timeout:   h.client.Timeout,
+		Transport: newHTTPTransport(tlsConfig, proxy, &h.config.Runtime.HTTPClient),
+	}
+	h.toolClients[tool.Name] = client
+	return client, nil
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Codes reported in ToolError.Code
+const (
+	errorCodeUpstreamStatus = "upstream_status"
+	errorCodeGraphQL        = "graphql_error"
+	errorCodeCircuitOpen    = "circuit_open"
+	errorCodeTimeout        = "timeout"
+	errorCodeUnreachable    = "upstream_unreachable"
+	errorCodeExecution      = "execution_failed"
+	errorCodeUnsupported    = "unsupported"
+	errorCodeTransform      = "transform_failed"
+)
+
+// ToolError is the machine-readable description of a failed tool call, sent alongside
+// the human-readable message so clients can branch on the kind of failure
+type ToolError struct {
+	Code           string `json:"code"`
+	Message        string `json:"message"`
+	Retryable      bool   `json:"retryable"`
+	UpstreamStatus int    `json:"upstream_status,omitempty"`
+}
+
+// toolErrorResult builds an error result whose first content item is the message and
+// whose second is the error encoded as JSON
+func toolErrorResult(toolErr ToolError) *mcp.CallToolResult {
+	result := mcp.NewToolResultError(toolErr.Message)
+	if data, err := json.Marshal(toolErr); err == nil {
+		result.Content = append(result.Content, mcp.TextContent{Type: "text", Text: string(data)})
+	}
+	return result
+}
+
+// classifyCallError maps an error from an upstream call to a ToolError. Timeouts,
+// network failures and open circuits may succeed later; anything else, such as a
+// template that fails to render or a command exiting non-zero, will fail again.
+func classifyCallError(err error, message string) ToolError {
+	toolErr := ToolError{Code: errorCodeExecution, Message: message}
+	var netErr net.Error
+	isNetErr := errors.As(err, &netErr)
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		toolErr.Code, toolErr.Retryable = errorCodeCircuitOpen, true
+	case errors.Is(err, context.DeadlineExceeded), isNetErr && netErr.Timeout():
+		toolErr.Code, toolErr.Retryable = errorCodeTimeout, true
+	case isNetErr:
+		toolErr.Code, toolErr.Retryable = errorCodeUnreachable, true
+	}
+	return toolErr
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import "mcp-server-template/internal/config"
+
+// toolListed reports whether tools/list shows a tool: it must not be disabled, and when
+// tags are given it must carry at least one of them. Each tag set narrows the list on
+// its own, so a tool has to match the runtime tags and the request's tags.
+func toolListed(tool *config.ToolConfig, tagSets ...[]string) bool {
+	if tool.Enabled != nil && !*tool.Enabled {
+		return false
+	}
+	for _, tags := range tagSets {
+		if len(tags) > 0 && !hasAnyTag(tool.Tags, tags) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasAnyTag(toolTags, wanted []string) bool {
+	for _, tag := range toolTags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Listed reports whether tools/list shows the named tool under runtime.tool_tags, for
+// transports that build the list elsewhere
+func (h *ToolHandler) Listed(name string) bool {
+	tool, ok := h.tools[name]
+	return ok && toolListed(tool, h.config.Runtime.ToolTags)
+}
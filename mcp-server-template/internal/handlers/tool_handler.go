@@ -3,13 +3,17 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
+	"time"
 
 	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/metrics"
 	"mcp-server-template/internal/validation"
 
+	"github.com/itchyny/gojq"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/sirupsen/logrus"
@@ -18,18 +22,29 @@ import (
 // ToolHandler manages dynamic tool registration and execution
 type ToolHandler struct {
 	httpClient *HTTPClient
+	grpcClient *GRPCClient
+	execRunner *ExecRunner
 	validator  *validation.Validator
 	logger     *logrus.Logger
+	config     *config.Config
 	tools      map[string]*config.ToolConfig
+	transforms map[string]*gojq.Code
+	contexts   *sessionContexts
 }
 
-// NewToolHandler creates a new tool handler
-func NewToolHandler() *ToolHandler {
+// NewToolHandler creates a new tool handler. logger is shared with the upstream clients
+// it creates.
+func NewToolHandler(cfg *config.Config, logger *logrus.Logger) *ToolHandler {
 	return &ToolHandler{
-		httpClient: NewHTTPClient(),
-		validator:  validation.New(),
-		logger:     logrus.New(),
+		httpClient: NewHTTPClient(cfg, logger),
+		grpcClient: NewGRPCClient(cfg, logger),
+		execRunner: NewExecRunner(cfg, logger),
+		validator:  validation.New(logger),
+		logger:     logger,
+		config:     cfg,
 		tools:      make(map[string]*config.ToolConfig),
+		transforms: make(map[string]*gojq.Code),
+		contexts:   newSessionContexts(),
 	}
 }
 
@@ -40,6 +55,13 @@ func (h *ToolHandler) RegisterTools(mcpServer *server.MCPServer, tools []config.
 	for _, tool := range tools {
 		// Store tool configuration for later use
 		h.tools[tool.Name] = &tool
+		if tool.Transform != "" {
+			code, err := compileTransform(tool.Transform)
+			if err != nil {
+				return fmt.Errorf("invalid transform for tool %s: %w", tool.Name, err)
+			}
+			h.transforms[tool.Name] = code
+		}
 
 		// Create the MCP tool using the builder pattern
 		var toolOpts []mcp.ToolOption
@@ -67,6 +89,9 @@ func (h *ToolHandler) RegisterTools(mcpServer *server.MCPServer, tools []config.
 					if len(param.Validation.Enum) > 0 {
 						opts = append(opts, mcp.Enum(param.Validation.Enum...))
 					}
+					if param.Validation.Const != nil {
+						opts = append(opts, schemaValue("const", param.Validation.Const))
+					}
 				}
 				toolOpts = append(toolOpts, mcp.WithString(param.Name, opts...))
 			case "number":
@@ -82,6 +107,12 @@ func (h *ToolHandler) RegisterTools(mcpServer *server.MCPServer, tools []config.
 					if param.Validation.MaxValue != nil {
 						opts = append(opts, mcp.Max(*param.Validation.MaxValue))
 					}
+					if len(param.Validation.NumberEnum) > 0 {
+						opts = append(opts, schemaValue("enum", param.Validation.NumberEnum))
+					}
+					if param.Validation.Const != nil {
+						opts = append(opts, schemaValue("const", param.Validation.Const))
+					}
 				}
 				toolOpts = append(toolOpts, mcp.WithNumber(param.Name, opts...))
 			case "boolean":
@@ -90,7 +121,23 @@ func (h *ToolHandler) RegisterTools(mcpServer *server.MCPServer, tools []config.
 				if param.Required {
 					opts = append(opts, mcp.Required())
 				}
+				if param.Validation != nil && param.Validation.Const != nil {
+					opts = append(opts, schemaValue("const", param.Validation.Const))
+				}
 				toolOpts = append(toolOpts, mcp.WithBoolean(param.Name, opts...))
+			case "object", "array":
+				var opts []mcp.PropertyOption
+				opts = append(opts, mcp.Description(param.Description))
+				if param.Required {
+					opts = append(opts, mcp.Required())
+				}
+				if param.Default != nil {
+					opts = append(opts, schemaValue("default", param.Default))
+				}
+				for keyword, value := range nestedSchema(&param) {
+					opts = append(opts, schemaValue(keyword, value))
+				}
+				toolOpts = append(toolOpts, withProperty(param.Name, param.Type, opts...))
 			}
 		}
 
@@ -114,9 +161,12 @@ func (h *ToolHandler) RegisterTools(mcpServer *server.MCPServer, tools []config.
 
 // ExecuteTool executes a tool with the given parameters
 func (h *ToolHandler) ExecuteTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
-	h.logger.WithFields(logrus.Fields{
+	log := requestLogger(ctx, h.logger)
+	arguments, dryRun := popFlag(arguments, dryRunArgument)
+	log.WithFields(logrus.Fields{
 		"tool_name": toolName,
 		"arguments": h.sanitizeArguments(arguments),
+		"dry_run":   dryRun,
 	}).Info("Executing tool")
 
 	// Get tool configuration
@@ -125,23 +175,72 @@ func (h *ToolHandler) ExecuteTool(ctx context.Context, toolName string, argument
 		return nil, fmt.Errorf("tool %s not found", toolName)
 	}
 
+	// Calls over a stream can read what earlier calls on it saved
+	if clientID := clientIDFromContext(ctx); clientID != "" {
+		ctx = withSessionValues(ctx, h.contexts.Snapshot(clientID))
+	}
+
+	startTime := time.Now()
+	metrics.ToolCallsInFlight.WithLabelValues(toolName).Inc()
+	defer func() {
+		metrics.ToolCallsInFlight.WithLabelValues(toolName).Dec()
+		metrics.ToolCallDuration.WithLabelValues(toolName).Observe(time.Since(startTime).Seconds())
+	}()
+
+	// Accept "42" and "true" for number and boolean parameters before validating
+	if coerced := coerceArguments(tool, arguments); len(coerced) > 0 {
+		log.WithFields(logrus.Fields{
+			"tool_name": toolName,
+			"coerced":   coerced,
+		}).Debug("Coerced string arguments to declared types")
+	}
+
 	// Validate input parameters
 	if err := h.validateParameters(tool, arguments); err != nil {
+		metrics.ToolCalls.WithLabelValues(toolName, "invalid_params").Inc()
+		log.WithError(err).WithField("tool_name", toolName).Warn("Parameter validation failed")
 		return nil, fmt.Errorf("parameter validation failed: %w", err)
 	}
 
-	// Execute the HTTP request
-	response, err := h.httpClient.ExecuteRequest(ctx, tool, arguments)
+	if dryRun {
+		metrics.ToolCalls.WithLabelValues(toolName, "dry_run").Inc()
+		return h.dryRunResult(ctx, tool, arguments), nil
+	}
+
+	// Execute the upstream call
+	var response *APIResponse
+	var err error
+	switch tool.Protocol {
+	case "grpc":
+		response, err = h.grpcClient.Invoke(ctx, tool, arguments)
+	case "exec":
+		response, err = h.execRunner.Run(ctx, tool, arguments)
+	default:
+		response, err = h.httpClient.ExecuteRequest(ctx, tool, arguments)
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		metrics.ToolCalls.WithLabelValues(toolName, "circuit_open").Inc()
+		log.WithField("tool_name", toolName).Warn("Circuit open, rejecting tool call")
+		return toolErrorResult(classifyCallError(err, err.Error())), nil
+	}
 	if err != nil {
-		h.logger.WithError(err).WithField("tool_name", toolName).Error("Tool execution failed")
+		metrics.ToolCalls.WithLabelValues(toolName, "error").Inc()
+		log.WithError(err).WithField("tool_name", toolName).Error("Tool execution failed")
 		// Return precise, actionable error text for LLMs/clients
-		return mcp.NewToolResultError(fmt.Sprintf("%s %s failed: %s", tool.Method, tool.Endpoint, err.Error())), nil
+		return toolErrorResult(classifyCallError(err, fmt.Sprintf("%s failed: %s", describeTarget(tool), err.Error()))), nil
 	}
 
 	// Convert response to MCP result
-	result := h.convertResponseToMCPResult(response, tool)
+	result := h.convertResponseToMCPResult(ctx, response, tool)
+	appendResponseHeaders(result, tool.ExposeHeaders, response)
+	if result.IsError {
+		metrics.ToolCalls.WithLabelValues(toolName, "error").Inc()
+	} else {
+		metrics.ToolCalls.WithLabelValues(toolName, "success").Inc()
+		h.saveResults(ctx, tool, response)
+	}
 
-	h.logger.WithFields(logrus.Fields{
+	log.WithFields(logrus.Fields{
 		"tool_name":   toolName,
 		"status_code": response.StatusCode,
 	}).Info("Tool executed successfully")
@@ -149,6 +248,42 @@ func (h *ToolHandler) ExecuteTool(ctx context.Context, toolName string, argument
 	return result, nil
 }
 
+// describeTarget names what a tool calls, for error messages
+func describeTarget(tool *config.ToolConfig) string {
+	if tool.Protocol == "grpc" && tool.GRPC != nil {
+		return fmt.Sprintf("gRPC %s/%s at %s", tool.GRPC.Service, tool.GRPC.Method, tool.GRPC.Address)
+	}
+	if tool.Protocol == "exec" && tool.Exec != nil {
+		return fmt.Sprintf("command %s", tool.Exec.Command)
+	}
+	return fmt.Sprintf("%s %s", tool.Method, tool.Endpoint)
+}
+
+// Close releases upstream connections held by the handler
+func (h *ToolHandler) Close() {
+	h.grpcClient.Close()
+}
+
+// EndpointStats returns per-endpoint outcome counts for balanced tools
+func (h *ToolHandler) EndpointStats() []EndpointStats {
+	return h.httpClient.EndpointStats()
+}
+
+// BreakerStats returns the state of each tool's circuit breaker
+func (h *ToolHandler) BreakerStats() []BreakerStats {
+	return h.httpClient.BreakerStats()
+}
+
+// FlushState clears cached responses, endpoint cooldowns, circuit breakers and session cookies for a tool, or all tools
+func (h *ToolHandler) FlushState(toolName string) (FlushResult, error) {
+	if toolName != "" {
+		if _, exists := h.tools[toolName]; !exists {
+			return FlushResult{}, fmt.Errorf("tool %s not found", toolName)
+		}
+	}
+	return h.httpClient.FlushState(toolName), nil
+}
+
 // validateParameters validates input parameters against tool configuration
 func (h *ToolHandler) validateParameters(tool *config.ToolConfig, arguments map[string]interface{}) error {
 	// Check required parameters
@@ -211,6 +346,9 @@ func (h *ToolHandler) validateParameterValue(param *config.ParameterConfig, valu
 					return fmt.Errorf("value must be one of: %v", param.Validation.Enum)
 				}
 			}
+			if param.Validation.Const != nil && str != param.Validation.Const {
+				return fmt.Errorf("value must be %v", param.Validation.Const)
+			}
 		}
 
 	case "number":
@@ -237,88 +375,175 @@ func (h *ToolHandler) validateParameterValue(param *config.ParameterConfig, valu
 			if param.Validation.MaxValue != nil && num > *param.Validation.MaxValue {
 				return fmt.Errorf("number too large, maximum value is %f", *param.Validation.MaxValue)
 			}
+			if len(param.Validation.NumberEnum) > 0 {
+				validValue := false
+				for _, enumValue := range param.Validation.NumberEnum {
+					if num == enumValue {
+						validValue = true
+						break
+					}
+				}
+				if !validValue {
+					return fmt.Errorf("value must be one of: %v", param.Validation.NumberEnum)
+				}
+			}
+			if param.Validation.Const != nil && num != param.Validation.Const {
+				return fmt.Errorf("value must be %v", param.Validation.Const)
+			}
 		}
 
 	case "boolean":
-		_, ok := value.(bool)
+		b, ok := value.(bool)
 		if !ok {
 			return fmt.Errorf("expected boolean, got %T", value)
 		}
+		if param.Validation != nil && param.Validation.Const != nil && b != param.Validation.Const {
+			return fmt.Errorf("value must be %v", param.Validation.Const)
+		}
 
 	case "object":
-		_, ok := value.(map[string]interface{})
+		obj, ok := value.(map[string]interface{})
 		if !ok {
 			return fmt.Errorf("expected object, got %T", value)
 		}
+		for _, name := range sortedFieldNames(param.Properties) {
+			field := param.Properties[name]
+			fieldValue, exists := obj[name]
+			if !exists {
+				if field.Required {
+					return fmt.Errorf("required field %s is missing", name)
+				}
+				continue
+			}
+			if err := h.validateParameterValue(&field, fieldValue); err != nil {
+				return fmt.Errorf("field %s: %w", name, err)
+			}
+		}
 
 	case "array":
-		_, ok := value.([]interface{})
+		items, ok := value.([]interface{})
 		if !ok {
 			return fmt.Errorf("expected array, got %T", value)
 		}
+		if param.Items != nil {
+			for i, item := range items {
+				if err := h.validateParameterValue(param.Items, item); err != nil {
+					return fmt.Errorf("item %d: %w", i, err)
+				}
+			}
+		}
 	}
 
 	return nil
 }
 
 // convertResponseToMCPResult converts an API response to MCP result format
-func (h *ToolHandler) convertResponseToMCPResult(response *APIResponse, tool *config.ToolConfig) *mcp.CallToolResult {
+func (h *ToolHandler) convertResponseToMCPResult(ctx context.Context, response *APIResponse, tool *config.ToolConfig) *mcp.CallToolResult {
 	// Determine if the response indicates an error
 	if response.StatusCode >= 400 {
-		return mcp.NewToolResultError(fmt.Sprintf("HTTP Error %d: %s", response.StatusCode, response.Body))
+		return toolErrorResult(ToolError{
+			Code:           errorCodeUpstreamStatus,
+			Message:        fmt.Sprintf("HTTP Error %d: %s", response.StatusCode, h.errorBody(response)),
+			Retryable:      isRetryableStatus(response.StatusCode, tool.Retry),
+			UpstreamStatus: response.StatusCode,
+		})
+	}
+
+	// GraphQL reports failures in the body of a 200 response
+	if tool.Kind == "graphql" {
+		if message := graphQLErrors(response.Data); message != "" {
+			return toolErrorResult(ToolError{
+				Code:           errorCodeGraphQL,
+				Message:        fmt.Sprintf("GraphQL Error: %s", message),
+				UpstreamStatus: response.StatusCode,
+			})
+		}
+	}
+
+	// Streaming tools hand back the raw body in pieces
+	if tool.Streaming {
+		return chunkedTextResult(response.Body)
+	}
+
+	// Narrow the parsed body to the configured subtree, falling back to the full body
+	data := response.Data
+	extracted := false
+	if tool.ResponsePath != "" && data != nil {
+		value, err := extractResponsePath(data, tool.ResponsePath)
+		if err != nil {
+			h.logger.WithError(err).WithFields(logrus.Fields{
+				"tool_name":     tool.Name,
+				"response_path": tool.ResponsePath,
+			}).Warn("Response path did not resolve, returning full body")
+		} else {
+			data = value
+			extracted = true
+		}
+	}
+
+	// A transform decides the output entirely; bodies that didn't parse are passed in as text
+	if code := h.transforms[tool.Name]; code != nil {
+		if data == nil {
+			data = response.Body
+		}
+		transformed, err := runTransform(ctx, code, data)
+		if err != nil {
+			return toolErrorResult(ToolError{
+				Code:    errorCodeTransform,
+				Message: fmt.Sprintf("transform failed: %s", err),
+			})
+		}
+		return h.formatData(transformed, response.Body)
 	}
 
 	// Format response based on tool configuration
 	switch tool.ReturnType {
 	case "string":
+		if extracted {
+			if str, ok := data.(string); ok {
+				return mcp.NewToolResultText(str)
+			}
+			return h.formatData(data, response.Body)
+		}
 		return mcp.NewToolResultText(response.Body)
 
 	case "object", "array":
-		if response.Data != nil {
+		if data != nil {
 			// Return structured data as JSON
-			jsonBytes, err := json.MarshalIndent(response.Data, "", "  ")
-			if err != nil {
-				return mcp.NewToolResultText(response.Body)
-			} else {
-				return mcp.NewToolResultText(string(jsonBytes))
-			}
+			return h.formatData(data, response.Body)
 		} else {
 			return mcp.NewToolResultText(response.Body)
 		}
 
 	default:
 		// Default: return the response body as text
-		if response.Data != nil {
-			jsonBytes, err := json.MarshalIndent(response.Data, "", "  ")
-			if err != nil {
-				return mcp.NewToolResultText(response.Body)
-			} else {
-				return mcp.NewToolResultText(string(jsonBytes))
-			}
+		if data != nil {
+			return h.formatData(data, response.Body)
 		} else {
 			return mcp.NewToolResultText(response.Body)
 		}
 	}
 }
 
+// formatData renders parsed response data as indented JSON, falling back to the raw body
+func (h *ToolHandler) formatData(data interface{}, rawBody string) *mcp.CallToolResult {
+	if str, ok := data.(string); ok {
+		return mcp.NewToolResultText(str)
+	}
+	jsonBytes, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultText(rawBody)
+	}
+	return mcp.NewToolResultText(string(jsonBytes))
+}
+
 // sanitizeArguments removes sensitive data from arguments for logging
 func (h *ToolHandler) sanitizeArguments(arguments map[string]interface{}) map[string]interface{} {
 	sanitized := make(map[string]interface{})
 
-	sensitiveKeys := []string{"password", "token", "api_key", "secret", "auth"}
-
 	for key, value := range arguments {
-		// Check if the key contains sensitive information
-		isSensitive := false
-		for _, sensitiveKey := range sensitiveKeys {
-			if regexp.MustCompile(`(?i)` + sensitiveKey).MatchString(key) {
-				isSensitive = true
-				break
-			}
-		}
-
-		if isSensitive {
-			sanitized[key] = "***REDACTED***"
+		if isSensitiveKey(key) {
+			sanitized[key] = redactedValue
 		} else {
 			sanitized[key] = value
 		}
@@ -326,3 +551,15 @@ func (h *ToolHandler) sanitizeArguments(arguments map[string]interface{}) map[st
 
 	return sanitized
 }
+
+// isSensitiveKey reports whether an argument name suggests a credential
+func isSensitiveKey(key string) bool {
+	sensitiveKeys := []string{"password", "token", "api_key", "secret", "auth"}
+
+	for _, sensitiveKey := range sensitiveKeys {
+		if regexp.MustCompile(`(?i)` + sensitiveKey).MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
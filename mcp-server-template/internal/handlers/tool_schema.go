@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"sort"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// withProperty adds a property of any JSON Schema type to a tool's input schema.
+// mcp-go only ships builders for strings, numbers and booleans; this mirrors them so
+// object and array parameters are declared the same way.
+func withProperty(name, schemaType string, opts ...mcp.PropertyOption) mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		schema := map[string]interface{}{"type": schemaType}
+		for _, opt := range opts {
+			opt(schema)
+		}
+
+		if required, ok := schema["required"].(bool); ok && required {
+			delete(schema, "required")
+			t.InputSchema.Required = append(t.InputSchema.Required, name)
+		}
+		t.InputSchema.Properties[name] = schema
+	}
+}
+
+// schemaValue sets a JSON Schema keyword, such as "default" or "enum", that mcp-go has
+// no typed option for
+func schemaValue(keyword string, value interface{}) mcp.PropertyOption {
+	return func(schema map[string]interface{}) {
+		schema[keyword] = value
+	}
+}
+
+// parameterSchema renders a parameter as a JSON Schema property, recursing into object
+// fields and array items
+func parameterSchema(param *config.ParameterConfig) map[string]interface{} {
+	schema := map[string]interface{}{"type": param.Type}
+	if param.Description != "" {
+		schema["description"] = param.Description
+	}
+	if param.Default != nil {
+		schema["default"] = param.Default
+	}
+
+	if v := param.Validation; v != nil {
+		switch param.Type {
+		case "string":
+			if v.MinLength != nil {
+				schema["minLength"] = *v.MinLength
+			}
+			if v.MaxLength != nil {
+				schema["maxLength"] = *v.MaxLength
+			}
+			if v.Pattern != nil {
+				schema["pattern"] = *v.Pattern
+			}
+			if len(v.Enum) > 0 {
+				schema["enum"] = v.Enum
+			}
+		case "number":
+			if v.MinValue != nil {
+				schema["minimum"] = *v.MinValue
+			}
+			if v.MaxValue != nil {
+				schema["maximum"] = *v.MaxValue
+			}
+			if len(v.NumberEnum) > 0 {
+				schema["enum"] = v.NumberEnum
+			}
+		}
+		if v.Const != nil {
+			schema["const"] = v.Const
+		}
+	}
+
+	for keyword, value := range nestedSchema(param) {
+		schema[keyword] = value
+	}
+	return schema
+}
+
+// nestedSchema returns the "properties", "required" and "items" keywords describing an
+// object's fields or an array's elements; it is empty when the parameter declares none
+func nestedSchema(param *config.ParameterConfig) map[string]interface{} {
+	nested := make(map[string]interface{})
+	if len(param.Properties) > 0 {
+		properties := make(map[string]interface{}, len(param.Properties))
+		var required []string
+		for _, name := range sortedFieldNames(param.Properties) {
+			field := param.Properties[name]
+			properties[name] = parameterSchema(&field)
+			if field.Required {
+				required = append(required, name)
+			}
+		}
+		nested["properties"] = properties
+		if len(required) > 0 {
+			nested["required"] = required
+		}
+	}
+	if param.Items != nil {
+		nested["items"] = parameterSchema(param.Items)
+	}
+	return nested
+}
+
+// sortedFieldNames returns the field names of an object parameter in a stable order
+func sortedFieldNames(properties map[string]config.ParameterConfig) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+// compileTransform compiles a tool's jq program
+func compileTransform(program string) (*gojq.Code, error) {
+	query, err := gojq.Parse(program)
+	if err != nil {
+		return nil, err
+	}
+	return gojq.Compile(query)
+}
+
+// runTransform applies a compiled jq program to response data. A program that emits
+// one value returns it as is; several values are collected into an array. The program
+// stops when ctx is done, so a runaway program can't outlive the tool call.
+func runTransform(ctx context.Context, code *gojq.Code, data interface{}) (interface{}, error) {
+	var outputs []interface{}
+	iter := code.RunWithContext(ctx, data)
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := value.(error); ok {
+			var halt *gojq.HaltError
+			if errors.As(err, &halt) && halt.Value() == nil {
+				break
+			}
+			return nil, err
+		}
+		outputs = append(outputs, value)
+	}
+
+	switch len(outputs) {
+	case 0:
+		return nil, fmt.Errorf("program produced no output")
+	case 1:
+		return outputs[0], nil
+	}
+	return outputs, nil
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// wsPongWait is how long a connection may stay silent before it is considered dead
+	wsPongWait = 60 * time.Second
+	// wsPingInterval must be shorter than wsPongWait so pongs arrive in time
+	wsPingInterval = 25 * time.Second
+	// wsWriteWait bounds each frame write
+	wsWriteWait = 10 * time.Second
+)
+
+// WebSocketHandler serves MCP JSON-RPC over WebSocket. Each text frame carries a
+// request (or batch) and responses are written back as frames tagged by id.
+type WebSocketHandler struct {
+	rpc      *JSONRPCHandler
+	logger   *logrus.Logger
+	upgrader websocket.Upgrader
+
+	mu     sync.Mutex
+	conns  map[*wsConnection]struct{}
+	closed bool
+}
+
+// wsConnection wraps a socket with a write lock, as gorilla allows one concurrent writer
+type wsConnection struct {
+	id      string // Identifies the connection for per-client notifications
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewWebSocketHandler creates a WebSocket transport that dispatches through the JSON-RPC handler
+func NewWebSocketHandler(rpc *JSONRPCHandler) *WebSocketHandler {
+	security := &rpc.config.Security
+	return &WebSocketHandler{
+		rpc:    rpc,
+		logger: rpc.logger,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			// Browsers don't apply CORS to WebSockets, so without an allowlist any page a
+			// user visits could drive the server; only same-origin pages may connect then
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				if origin == "" {
+					return true
+				}
+				if security.EnableCORS {
+					return OriginAllowed(security, origin)
+				}
+				u, err := url.Parse(origin)
+				return err == nil && strings.EqualFold(u.Host, r.Host)
+			},
+		},
+		conns: make(map[*wsConnection]struct{}),
+	}
+}
+
+// ServeHTTP upgrades the connection and serves JSON-RPC frames until it closes
+func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := requestIDFromHTTP(r)
+	conn, err := h.upgrader.Upgrade(w, r, http.Header{RequestIDHeader: []string{requestID}})
+	if err != nil {
+		h.logger.WithError(err).Warn("WebSocket upgrade failed")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &wsConnection{id: uuid.NewString(), conn: conn, ctx: ctx, cancel: cancel}
+	if !h.track(c) {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(wsWriteWait))
+		conn.Close()
+		cancel()
+		return
+	}
+	defer h.untrack(c)
+
+	h.logger.WithField("remote_addr", r.RemoteAddr).Info("WebSocket client connected")
+
+	// Register for notifications before reading, so the first request can subscribe
+	notifications, unsubscribe := h.rpc.Notifications(c.id)
+	go h.keepAlive(c)
+	go h.forwardNotifications(c, notifications, unsubscribe)
+
+	conn.SetReadLimit(h.rpc.MaxRequestBytes())
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	var inflight sync.WaitGroup
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				h.logger.WithError(err).Warn("WebSocket read failed")
+			}
+			break
+		}
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		// Requests are handled concurrently; responses carry their id for correlation
+		inflight.Add(1)
+		go func(data []byte) {
+			defer inflight.Done()
+			if response := h.rpc.HandleMessage(withClientID(WithRequestID(c.ctx, requestID), c.id), data); response != nil {
+				h.write(c, response)
+			}
+		}(data)
+	}
+
+	c.cancel()
+	inflight.Wait()
+	h.logger.WithField("remote_addr", r.RemoteAddr).Info("WebSocket client disconnected")
+}
+
+// Close sends a close frame to every open connection and rejects new ones
+func (h *WebSocketHandler) Close() {
+	h.mu.Lock()
+	h.closed = true
+	conns := make([]*wsConnection, 0, len(h.conns))
+	for c := range h.conns {
+		conns = append(conns, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range conns {
+		c.writeMu.Lock()
+		c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(wsWriteWait))
+		c.writeMu.Unlock()
+		c.cancel()
+		c.conn.Close()
+	}
+}
+
+func (h *WebSocketHandler) track(c *wsConnection) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.conns[c] = struct{}{}
+	return true
+}
+
+func (h *WebSocketHandler) untrack(c *wsConnection) {
+	h.mu.Lock()
+	delete(h.conns, c)
+	h.mu.Unlock()
+	c.cancel()
+	c.conn.Close()
+}
+
+// keepAlive pings the peer until the connection ends
+func (h *WebSocketHandler) keepAlive(c *wsConnection) {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+			c.writeMu.Unlock()
+			if err != nil {
+				c.cancel()
+				return
+			}
+		}
+	}
+}
+
+// forwardNotifications writes server notifications to the peer until the connection ends
+func (h *WebSocketHandler) forwardNotifications(c *wsConnection, notifications <-chan *JSONRPCNotification, unsubscribe func()) {
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case notification := <-notifications:
+			h.write(c, notification)
+		}
+	}
+}
+
+// write sends a JSON payload as a single text frame
+func (h *WebSocketHandler) write(c *wsConnection, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to encode WebSocket response")
+		return
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		h.logger.WithError(err).Debug("WebSocket write failed")
+	}
+}
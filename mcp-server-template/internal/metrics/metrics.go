@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Tool-level metrics, recorded once per tools/call
+var (
+	ToolCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mcp_tool_calls_total",
+		Help: "Tool calls by tool and outcome (success, error, invalid_params, circuit_open, dry_run)",
+	}, []string{"tool", "outcome"})
+
+	ToolCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mcp_tool_call_duration_seconds",
+		Help:    "End-to-end tool call latency including retries",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"tool"})
+
+	ToolCallsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mcp_tool_calls_in_flight",
+		Help: "Tool calls currently being executed",
+	}, []string{"tool"})
+)
+
+// Upstream metrics, recorded once per HTTP attempt
+var (
+	UpstreamResponses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mcp_upstream_responses_total",
+		Help: "Upstream HTTP responses by tool and status code",
+	}, []string{"tool", "status_code"})
+
+	UpstreamErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mcp_upstream_errors_total",
+		Help: "Upstream requests that produced no response, by tool and reason (timeout, cancelled, network)",
+	}, []string{"tool", "reason"})
+
+	UpstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mcp_upstream_request_duration_seconds",
+		Help:    "Latency of individual upstream HTTP attempts",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"tool"})
+)
+
+// Collectors returns every package-level collector so a registry can expose them
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		ToolCalls,
+		ToolCallDuration,
+		ToolCallsInFlight,
+		UpstreamResponses,
+		UpstreamErrors,
+		UpstreamDuration,
+	}
+}
@@ -0,0 +1,126 @@
+package openapi
+
+import "net/http"
+
+// document is the subset of an OpenAPI 3 document the importer reads
+type document struct {
+	OpenAPI string `yaml:"openapi"`
+	Info    struct {
+		Title       string `yaml:"title"`
+		Description string `yaml:"description"`
+		Version     string `yaml:"version"`
+	} `yaml:"info"`
+	Servers []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+	Paths      map[string]*pathItem `yaml:"paths"`
+	Components struct {
+		Schemas       map[string]*schema      `yaml:"schemas"`
+		Parameters    map[string]*parameter   `yaml:"parameters"`
+		RequestBodies map[string]*requestBody `yaml:"requestBodies"`
+	} `yaml:"components"`
+}
+
+type pathItem struct {
+	Parameters []*parameter `yaml:"parameters"`
+	Get        *operation   `yaml:"get"`
+	Post       *operation   `yaml:"post"`
+	Put        *operation   `yaml:"put"`
+	Patch      *operation   `yaml:"patch"`
+	Delete     *operation   `yaml:"delete"`
+}
+
+type methodOperation struct {
+	method    string
+	operation *operation
+}
+
+// operations returns the item's operations in a fixed method order
+func (p *pathItem) operations() []methodOperation {
+	var ops []methodOperation
+	for _, op := range []methodOperation{
+		{http.MethodGet, p.Get},
+		{http.MethodPost, p.Post},
+		{http.MethodPut, p.Put},
+		{http.MethodPatch, p.Patch},
+		{http.MethodDelete, p.Delete},
+	} {
+		if op.operation != nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+type operation struct {
+	OperationID string       `yaml:"operationId"`
+	Summary     string       `yaml:"summary"`
+	Description string       `yaml:"description"`
+	Parameters  []*parameter `yaml:"parameters"`
+	RequestBody *requestBody `yaml:"requestBody"`
+}
+
+type parameter struct {
+	Ref         string  `yaml:"$ref"`
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Description string  `yaml:"description"`
+	Required    bool    `yaml:"required"`
+	Schema      *schema `yaml:"schema"`
+	Style       string  `yaml:"style"`
+	Explode     *bool   `yaml:"explode"`
+}
+
+type requestBody struct {
+	Ref      string               `yaml:"$ref"`
+	Required bool                 `yaml:"required"`
+	Content  map[string]mediaType `yaml:"content"`
+}
+
+type mediaType struct {
+	Schema *schema `yaml:"schema"`
+}
+
+type schema struct {
+	Ref         string             `yaml:"$ref"`
+	Type        interface{}        `yaml:"type"` // A string, or a list of them in OpenAPI 3.1
+	Description string             `yaml:"description"`
+	Enum        []interface{}      `yaml:"enum"`
+	Default     interface{}        `yaml:"default"`
+	Properties  map[string]*schema `yaml:"properties"`
+	Required    []string           `yaml:"required"`
+	Items       *schema            `yaml:"items"`
+}
+
+// schemaType maps the schema's type to a parameter type. Integers become numbers, and
+// schemas without a usable type become strings unless they declare properties or items.
+func (s *schema) schemaType() string {
+	types := []string{}
+	switch t := s.Type.(type) {
+	case string:
+		types = append(types, t)
+	case []interface{}:
+		for _, v := range t {
+			if str, ok := v.(string); ok && str != "null" {
+				types = append(types, str)
+			}
+		}
+	}
+
+	if len(types) > 0 {
+		switch types[0] {
+		case "integer", "number":
+			return "number"
+		case "boolean", "object", "array":
+			return types[0]
+		}
+		return "string"
+	}
+	switch {
+	case len(s.Properties) > 0:
+		return "object"
+	case s.Items != nil:
+		return "array"
+	}
+	return "string"
+}
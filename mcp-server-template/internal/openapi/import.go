@@ -0,0 +1,322 @@
+// Package openapi scaffolds a server config from an OpenAPI 3 document
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// maxSchemaDepth bounds how far nested and recursive schemas are followed
+const maxSchemaDepth = 5
+
+// Field limits from the config struct tags; longer text is cut to fit
+const (
+	maxToolDescription   = 500
+	maxParamDescription  = 200
+	maxServerName        = 100
+	maxServerDescription = 500
+)
+
+// Options control how a document is turned into a config
+type Options struct {
+	// BaseURL replaces the first entry of the document's servers list
+	BaseURL string
+}
+
+// Import builds a config with one tool per operation in an OpenAPI 3 document, given
+// as JSON or YAML. Path, query and header parameters and the properties of a JSON
+// request body become tool parameters. Authentication is left for the caller to add.
+func Import(data []byte, opts Options) (*config.Config, error) {
+	var doc document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, expected 3.x", doc.OpenAPI)
+	}
+
+	baseURL := opts.BaseURL
+	if baseURL == "" && len(doc.Servers) > 0 {
+		baseURL = doc.Servers[0].URL
+	}
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		return nil, fmt.Errorf("document has no absolute server URL; pass a base URL")
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{
+			Name:        truncate(serverName(doc.Info.Title), maxServerName),
+			Version:     serverVersion(doc.Info.Version),
+			Description: truncate(doc.Info.Description, maxServerDescription),
+		},
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	names := make(map[string]int)
+	for _, path := range paths {
+		item := doc.Paths[path]
+		for _, op := range item.operations() {
+			tool := doc.tool(baseURL, path, op.method, op.operation, item.Parameters)
+
+			// Operation IDs are unique in a valid document, but derived names may not be
+			names[tool.Name]++
+			if n := names[tool.Name]; n > 1 {
+				tool.Name = fmt.Sprintf("%s_%d", tool.Name, n)
+			}
+			cfg.Tools = append(cfg.Tools, tool)
+		}
+	}
+	if len(cfg.Tools) == 0 {
+		return nil, fmt.Errorf("document defines no operations")
+	}
+	return cfg, nil
+}
+
+// tool converts one operation. Parameters declared on the path item apply unless the
+// operation redefines them, and each keeps its location through the parameter's "in".
+func (d *document) tool(baseURL, path, method string, op *operation, shared []*parameter) config.ToolConfig {
+	tool := config.ToolConfig{
+		Name:        toolName(op.OperationID, method, path),
+		Description: truncate(firstNonEmpty(op.Summary, op.Description, method+" "+path), maxToolDescription),
+		Method:      method,
+		Endpoint:    baseURL + path,
+		ReturnType:  "object",
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range append(append([]*parameter{}, op.Parameters...), shared...) {
+		p = d.resolveParameter(p)
+		if p == nil || seen[p.Name] || (p.In != "path" && p.In != "query" && p.In != "header") {
+			continue
+		}
+		seen[p.Name] = true
+
+		param := d.parameter(p.Name, p.Description, p.Schema, 0)
+		param.Required = p.Required || p.In == "path"
+		param.In = p.In
+		// Form style arrays with explode: false are joined with commas
+		if p.In == "query" && param.Type == "array" && (p.Style == "" || p.Style == "form") && p.Explode != nil && !*p.Explode {
+			param.Style = "comma"
+		}
+		tool.Parameters = append(tool.Parameters, param)
+	}
+
+	body := d.jsonBody(op.RequestBody)
+	if body == nil || method == http.MethodGet {
+		return tool
+	}
+	tool.ContentType = "application/json"
+	required := d.resolveRequestBody(op.RequestBody).Required
+
+	bodySchema := d.resolveSchema(body)
+	if bodySchema.schemaType() == "object" && len(bodySchema.Properties) > 0 {
+		for _, name := range sortedKeys(bodySchema.Properties) {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			prop := d.resolveSchema(bodySchema.Properties[name])
+			param := d.parameter(name, prop.Description, prop, 0)
+			param.Required = required && contains(bodySchema.Required, name)
+			param.In = "body"
+			tool.Parameters = append(tool.Parameters, param)
+		}
+		return tool
+	}
+
+	// Bodies that aren't objects with known fields are passed through whole
+	param := d.parameter("body", firstNonEmpty(bodySchema.Description, "Request body"), bodySchema, 0)
+	param.Required = required
+	tool.Parameters = append(tool.Parameters, param)
+	tool.BodyTemplate = "{{toJson .body}}"
+	return tool
+}
+
+// parameter converts a schema into a parameter, recursing into object fields and array
+// items up to maxSchemaDepth
+func (d *document) parameter(name, description string, s *schema, depth int) config.ParameterConfig {
+	s = d.resolveSchema(s)
+	param := config.ParameterConfig{
+		Name:        name,
+		Type:        s.schemaType(),
+		Description: truncate(firstNonEmpty(description, s.Description, name), maxParamDescription),
+		Default:     s.Default,
+	}
+
+	switch param.Type {
+	case "string":
+		var enum []string
+		for _, v := range s.Enum {
+			if str, ok := v.(string); ok {
+				enum = append(enum, str)
+			}
+		}
+		if len(enum) > 0 {
+			param.Validation = &config.ParameterValidation{Enum: enum}
+		}
+	case "object":
+		if depth < maxSchemaDepth && len(s.Properties) > 0 {
+			param.Properties = make(map[string]config.ParameterConfig, len(s.Properties))
+			for field, fieldSchema := range s.Properties {
+				nested := d.parameter(field, "", fieldSchema, depth+1)
+				nested.Name = ""
+				nested.Required = contains(s.Required, field)
+				param.Properties[field] = nested
+			}
+		}
+	case "array":
+		if depth < maxSchemaDepth && s.Items != nil {
+			items := d.parameter("", "", s.Items, depth+1)
+			items.Name = ""
+			param.Items = &items
+		}
+	}
+	return param
+}
+
+// jsonBody returns the schema of a request body's JSON content, if it has one
+func (d *document) jsonBody(body *requestBody) *schema {
+	body = d.resolveRequestBody(body)
+	if body == nil {
+		return nil
+	}
+	for _, contentType := range sortedKeys(body.Content) {
+		base := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+		if base == "application/json" || strings.HasSuffix(base, "+json") {
+			if s := body.Content[contentType].Schema; s != nil {
+				return s
+			}
+			return &schema{Type: "object"}
+		}
+	}
+	return nil
+}
+
+func (d *document) resolveParameter(p *parameter) *parameter {
+	for i := 0; p != nil && p.Ref != "" && i < maxSchemaDepth; i++ {
+		p = d.Components.Parameters[refName(p.Ref, "parameters")]
+	}
+	return p
+}
+
+func (d *document) resolveRequestBody(b *requestBody) *requestBody {
+	for i := 0; b != nil && b.Ref != "" && i < maxSchemaDepth; i++ {
+		b = d.Components.RequestBodies[refName(b.Ref, "requestBodies")]
+	}
+	return b
+}
+
+// resolveSchema follows $ref to a component schema. Unresolvable references become an
+// untyped schema, which is treated as a string.
+func (d *document) resolveSchema(s *schema) *schema {
+	for i := 0; s != nil && s.Ref != "" && i < maxSchemaDepth; i++ {
+		s = d.Components.Schemas[refName(s.Ref, "schemas")]
+	}
+	if s == nil || s.Ref != "" {
+		return &schema{}
+	}
+	return s
+}
+
+// refName extracts the component name from a local reference such as
+// "#/components/schemas/Pet"
+func refName(ref, section string) string {
+	return strings.TrimPrefix(ref, "#/components/"+section+"/")
+}
+
+// toolName converts an operation ID to snake_case, falling back to the method and path
+func toolName(operationID, method, path string) string {
+	source := operationID
+	if source == "" {
+		source = strings.ToLower(method) + " " + strings.NewReplacer("{", "by ", "}", "").Replace(path)
+	}
+
+	var b strings.Builder
+	prevLower := false
+	for _, r := range source {
+		switch {
+		case unicode.IsUpper(r):
+			if prevLower {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			prevLower = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			prevLower = true
+		default:
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+			prevLower = false
+		}
+	}
+	return truncate(strings.Trim(b.String(), "_"), 100)
+}
+
+// serverName slugs the document title into a server name
+func serverName(title string) string {
+	name := strings.ReplaceAll(toolName(title, "", ""), "_", "-")
+	if name == "" {
+		return "openapi-server"
+	}
+	return name
+}
+
+// serverVersion normalizes the document version to semver, defaulting to 1.0.0
+func serverVersion(version string) string {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return "1.0.0"
+	}
+	return v.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.TrimSpace(s[:max])
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// callerKey is the context key of the identity requireAuth or requireAdmin accepted a
+// request with
+type callerKey struct{}
+
+// apiKeysEnabled reports whether shared API keys are accepted
+func (s *MCPServer) apiKeysEnabled() bool {
+	return s.config.Security.EnableAuth && len(s.config.Security.APIKeys) > 0
+}
+
+// requireAuth protects next with the configured authentication mode: API keys when set,
+// otherwise the OAuth layer. Validation rejects configs that enable both.
+func (s *MCPServer) requireAuth(next http.Handler, port int) http.Handler {
+	if !s.apiKeysEnabled() {
+		if s.config.Security.OAuth.Enabled {
+			return s.wrapWithAuth(next, port)
+		}
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, ok := matchKey(r, s.config.Security.APIKeys); ok {
+			next.ServeHTTP(w, withCaller(r, "api_key:"+fingerprint(key)))
+			return
+		}
+
+		s.logger.WithField("remote_addr", r.RemoteAddr).Warn("Rejected request without a valid API key")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+		http.Error(w, "invalid or missing API key", http.StatusUnauthorized)
+	})
+}
+
+// requireAdmin protects next with security.admin_keys, which are separate from the
+// API keys ordinary MCP clients use
+func (s *MCPServer) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, ok := matchKey(r, s.config.Security.AdminKeys); ok {
+			next.ServeHTTP(w, withCaller(r, "admin_key:"+fingerprint(key)))
+			return
+		}
+
+		s.logger.WithField("remote_addr", r.RemoteAddr).Warn("Rejected admin request without a valid admin key")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-admin"`)
+		http.Error(w, "invalid or missing admin key", http.StatusUnauthorized)
+	})
+}
+
+// matchKey checks the X-API-Key header and the bearer token against keys and returns
+// the key that matched. Keys are hashed before the constant-time comparison so their
+// lengths do not leak either.
+func matchKey(r *http.Request, keys []string) (string, bool) {
+	var candidates []string
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		candidates = append(candidates, key)
+	}
+	if authz := r.Header.Get("Authorization"); len(authz) > 7 && strings.EqualFold(authz[:7], "bearer ") {
+		candidates = append(candidates, strings.TrimSpace(authz[7:]))
+	}
+
+	matched, valid := "", false
+	for _, candidate := range candidates {
+		got := sha256.Sum256([]byte(candidate))
+		for _, key := range keys {
+			want := sha256.Sum256([]byte(key))
+			if subtle.ConstantTimeCompare(got[:], want[:]) == 1 {
+				matched, valid = key, true
+			}
+		}
+	}
+	return matched, valid
+}
+
+// caller names who made r for audit logs: "api_key:", "admin_key:" or "bearer:"
+// followed by the fingerprint of the secret it authenticated with, or "anonymous"
+// without auth
+func caller(r *http.Request) string {
+	if id, ok := r.Context().Value(callerKey{}).(string); ok {
+		return id
+	}
+	return "anonymous"
+}
+
+func withCaller(r *http.Request, id string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), callerKey{}, id))
+}
+
+// fingerprint identifies a secret in logs by the start of its SHA-256, never the
+// secret itself
+func fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:4])
+}
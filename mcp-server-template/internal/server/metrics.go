@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+
+	"mcp-server-template/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	endpointRequestsDesc = prometheus.NewDesc(
+		"mcp_endpoint_requests_total",
+		"Upstream requests per balanced endpoint",
+		[]string{"tool", "endpoint", "outcome"}, nil,
+	)
+	breakerStateDesc = prometheus.NewDesc(
+		"mcp_circuit_breaker_state",
+		"Circuit breaker state per tool (0=closed, 1=half_open, 2=open)",
+		[]string{"tool"}, nil,
+	)
+	breakerOpensDesc = prometheus.NewDesc(
+		"mcp_circuit_breaker_opens_total",
+		"Times each tool's circuit breaker has opened",
+		[]string{"tool"}, nil,
+	)
+)
+
+// metricsHandler builds a registry with the tool/upstream metrics, server info gauges
+// and runtime collectors, and serves it in the Prometheus exposition format
+func (s *MCPServer) metricsHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics.Collectors()...)
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		&toolStateCollector{server: s},
+	)
+
+	// Static gauges kept for dashboards built against the original endpoint
+	info := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mcp_server_info",
+		Help: "Server information",
+	}, []string{"name", "version"})
+	info.WithLabelValues(s.config.Server.Name, s.config.Server.Version).Set(1)
+
+	registry.MustRegister(info)
+
+	for _, count := range []struct {
+		name, help string
+		value      int
+	}{
+		{"mcp_tools_count", "Number of registered tools", len(s.config.Tools)},
+		{"mcp_prompts_count", "Number of registered prompts", len(s.config.Prompts)},
+		{"mcp_resources_count", "Number of registered resources", len(s.config.Resources)},
+	} {
+		gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: count.name, Help: count.help})
+		gauge.Set(float64(count.value))
+		registry.MustRegister(gauge)
+	}
+
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// toolStateCollector exposes balancer and circuit breaker state, read at scrape time
+type toolStateCollector struct {
+	server *MCPServer
+}
+
+func (c *toolStateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- endpointRequestsDesc
+	ch <- breakerStateDesc
+	ch <- breakerOpensDesc
+}
+
+func (c *toolStateCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, st := range c.server.toolHandler.EndpointStats() {
+		ch <- prometheus.MustNewConstMetric(endpointRequestsDesc, prometheus.CounterValue, float64(st.Successes), st.Tool, st.Endpoint, "success")
+		ch <- prometheus.MustNewConstMetric(endpointRequestsDesc, prometheus.CounterValue, float64(st.Failures), st.Tool, st.Endpoint, "failure")
+	}
+	for _, st := range c.server.toolHandler.BreakerStats() {
+		ch <- prometheus.MustNewConstMetric(breakerStateDesc, prometheus.GaugeValue, float64(st.StateValue), st.Tool)
+		ch <- prometheus.MustNewConstMetric(breakerOpensDesc, prometheus.CounterValue, float64(st.Opens), st.Tool)
+	}
+}
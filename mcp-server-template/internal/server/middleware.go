@@ -0,0 +1,133 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"mcp-server-template/internal/handlers"
+
+	"golang.org/x/time/rate"
+)
+
+// limiterIdleTTL is how long a client's bucket is kept after its last request
+const limiterIdleTTL = 5 * time.Minute
+
+// withMiddleware wraps the router with rate limiting and CORS. Rate limiting runs first
+// so rejected clients cost as little as possible; the probe endpoints are never limited
+// so they keep working under load.
+func (s *MCPServer) withMiddleware(next http.Handler) http.Handler {
+	handler := s.withCORS(next)
+
+	if s.config.Security.EnableRateLimit {
+		limiter := newClientRateLimiter(s.config.Security.RateLimit)
+		limited := handler
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/health" && r.URL.Path != "/ready" && !limiter.allow(clientIP(r)) {
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			limited.ServeHTTP(w, r)
+		})
+		s.logger.WithField("requests_per_second", s.config.Security.RateLimit).Info("Rate limiting enabled")
+	}
+
+	return handler
+}
+
+// withCORS sets CORS headers and answers preflight requests. Without enable_cors any
+// origin is accepted; with it only allowed_origins are. Requests from other origins are
+// refused outright, not just denied the CORS headers: browsers send simple requests,
+// such as a text/plain POST, and WebSocket upgrades without asking first, so the
+// server would otherwise run them.
+func (s *MCPServer) withCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && !s.originAllowed(origin) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		if s.config.Security.EnableCORS && origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		w.Header().Set("Access-Control-Expose-Headers", handlers.RequestIDHeader)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, "+handlers.RequestIDHeader)
+			w.Header().Set("Access-Control-Max-Age", "86400")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// originAllowed reports whether origin may call the server
+func (s *MCPServer) originAllowed(origin string) bool {
+	return handlers.OriginAllowed(&s.config.Security, origin)
+}
+
+// clientIP returns the connecting address. Forwarding headers are ignored because any
+// client can set them to dodge the limit.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// clientRateLimiter keeps one token bucket per client IP
+type clientRateLimiter struct {
+	perSecond int
+
+	mu        sync.Mutex
+	clients   map[string]*clientBucket
+	lastSweep time.Time
+}
+
+type clientBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newClientRateLimiter(perSecond int) *clientRateLimiter {
+	return &clientRateLimiter{
+		perSecond: perSecond,
+		clients:   make(map[string]*clientBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// allow takes a token from ip's bucket. Buckets hold one second's worth of requests, so
+// short bursts up to the rate are accepted.
+func (l *clientRateLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > limiterIdleTTL {
+		for key, bucket := range l.clients {
+			if now.Sub(bucket.lastSeen) > limiterIdleTTL {
+				delete(l.clients, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	bucket, ok := l.clients[ip]
+	if !ok {
+		bucket = &clientBucket{limiter: rate.NewLimiter(rate.Limit(l.perSecond), l.perSecond)}
+		l.clients[ip] = bucket
+	}
+	bucket.lastSeen = now
+	return bucket.limiter.Allow()
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// discoveryTimeout bounds each discovery/JWKS fetch performed at startup
+const discoveryTimeout = 10 * time.Second
+
+// verifyAuthorizationServers fetches the discovery document and JWKS of every configured
+// authorization server so misconfigured issuers surface at startup instead of as 401s
+func (s *MCPServer) verifyAuthorizationServers(ctx context.Context) error {
+	oauth := s.config.Security.OAuth
+	if !oauth.Enabled || oauth.VerifyDiscovery == "" || oauth.VerifyDiscovery == "off" {
+		return nil
+	}
+
+	client := &http.Client{Timeout: discoveryTimeout}
+
+	var problems []string
+	for _, issuer := range oauth.AuthorizationServers {
+		if err := s.verifyAuthorizationServer(ctx, client, issuer); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", issuer, err))
+			continue
+		}
+		s.logger.WithField("issuer", issuer).Info("Authorization server verified")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	err := fmt.Errorf("authorization server verification failed: %s", strings.Join(problems, "; "))
+	if oauth.VerifyDiscovery == "strict" {
+		return err
+	}
+
+	s.logger.WithError(err).Error("!!! OAuth authorization servers are unreachable or misconfigured; token validation will fail !!!")
+	return nil
+}
+
+// verifyAuthorizationServer checks a single issuer's discovery metadata and JWKS
+func (s *MCPServer) verifyAuthorizationServer(ctx context.Context, client *http.Client, issuer string) error {
+	if err := s.checkDiscoveryScheme(issuer); err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(issuer, "/")
+	var metadata struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+
+	var lastErr error
+	for _, path := range []string{"/.well-known/openid-configuration", "/.well-known/oauth-authorization-server"} {
+		if lastErr = s.fetchJSON(ctx, client, base+path, &metadata); lastErr == nil {
+			break
+		}
+		s.logger.WithError(lastErr).WithField("issuer", issuer).Debug("Discovery document fetch failed")
+	}
+	if lastErr != nil {
+		return fmt.Errorf("discovery document unavailable: %w", lastErr)
+	}
+
+	if metadata.Issuer == "" {
+		return fmt.Errorf("discovery document is missing issuer")
+	}
+	if metadata.JWKSURI == "" {
+		return fmt.Errorf("discovery document is missing jwks_uri")
+	}
+	if err := s.checkDiscoveryScheme(metadata.JWKSURI); err != nil {
+		return fmt.Errorf("jwks_uri: %w", err)
+	}
+
+	var jwks struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := s.fetchJSON(ctx, client, metadata.JWKSURI, &jwks); err != nil {
+		return fmt.Errorf("JWKS unavailable: %w", err)
+	}
+	if len(jwks.Keys) == 0 {
+		return fmt.Errorf("JWKS at %s contains no keys", metadata.JWKSURI)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"issuer":   issuer,
+		"jwks_uri": metadata.JWKSURI,
+		"keys":     len(jwks.Keys),
+	}).Debug("Fetched authorization server metadata")
+
+	return nil
+}
+
+// checkDiscoveryScheme requires https unless AllowInsecureHTTP is set
+func (s *MCPServer) checkDiscoveryScheme(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	switch parsed.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if s.config.Security.OAuth.AllowInsecureHTTP {
+			return nil
+		}
+		return fmt.Errorf("insecure http scheme (set allow_insecure_http for development)")
+	default:
+		return fmt.Errorf("unsupported URL scheme %q", parsed.Scheme)
+	}
+}
+
+// fetchJSON GETs a URL and decodes its JSON body into out
+func (s *MCPServer) fetchJSON(ctx context.Context, client *http.Client, rawURL string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d from %s", resp.StatusCode, rawURL)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("malformed JSON from %s: %w", rawURL, err)
+	}
+	return nil
+}
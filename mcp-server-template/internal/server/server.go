@@ -2,12 +2,16 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"mcp-server-template/internal/config"
@@ -23,8 +27,13 @@ type MCPServer struct {
 	mcpServer   *server.MCPServer
 	config      *config.Config
 	toolHandler *handlers.ToolHandler
+	resources   *handlers.ResourceLoader
 	logger      *logrus.Logger
 	httpServer  *http.Server
+	sseHandler  *handlers.SSEHandler
+	wsHandler   *handlers.WebSocketHandler
+	rpcHandler  *handlers.JSONRPCHandler
+	enableSSE   bool
 }
 
 // New creates a new configured MCP server instance
@@ -57,13 +66,14 @@ func New(cfg *config.Config) (*MCPServer, error) {
 	)
 
 	// Create tool handler
-	toolHandler := handlers.NewToolHandler()
+	toolHandler := handlers.NewToolHandler(cfg, logger)
 
 	// Create our wrapper
 	mcpServerWrapper := &MCPServer{
 		mcpServer:   mcpServer,
 		config:      cfg,
 		toolHandler: toolHandler,
+		resources:   handlers.NewResourceLoader(cfg, logger),
 		logger:      logger,
 	}
 
@@ -135,16 +145,14 @@ func (s *MCPServer) registerPrompts() error {
 
 		// Register prompt with handler
 		s.mcpServer.AddPrompt(prompt, func(arguments map[string]string) (*mcp.GetPromptResult, error) {
-			// Substitute arguments in the prompt content
-			content := promptConfig.Content
-			for key, value := range arguments {
-				placeholder := fmt.Sprintf("{%s}", key)
-				content = strings.ReplaceAll(content, placeholder, value)
+			if missing := handlers.MissingPromptArguments(&promptConfig, arguments); len(missing) > 0 {
+				return nil, fmt.Errorf("prompt %s is missing required arguments: %s", promptConfig.Name, strings.Join(missing, ", "))
 			}
-
-			return mcp.NewGetPromptResult(promptConfig.Description, []mcp.PromptMessage{
-				mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(content)),
-			}), nil
+			messages, err := s.resources.PromptMessages(context.Background(), &promptConfig, arguments)
+			if err != nil {
+				return nil, err
+			}
+			return mcp.NewGetPromptResult(promptConfig.Description, messages), nil
 		})
 
 		s.logger.WithField("prompt_name", promptConfig.Name).Debug("Prompt registered")
@@ -174,6 +182,8 @@ func (s *MCPServer) convertToMCPPrompt(promptConfig *config.PromptConfig) mcp.Pr
 
 // registerResources registers all configured resources
 func (s *MCPServer) registerResources() error {
+	s.registerResourceTemplates()
+
 	if len(s.config.Resources) == 0 {
 		s.logger.Info("No resources to register")
 		return nil
@@ -188,12 +198,12 @@ func (s *MCPServer) registerResources() error {
 		// Register resource with handler
 		s.mcpServer.AddResource(resource, func(request mcp.ReadResourceRequest) ([]interface{}, error) {
 			// Get resource content
-			content, err := s.getResourceContent(&resourceConfig)
+			content, err := s.resources.Load(context.Background(), &resourceConfig)
 			if err != nil {
 				return nil, fmt.Errorf("failed to get resource content: %w", err)
 			}
 
-			return []interface{}{mcp.NewTextContent(content)}, nil
+			return []interface{}{content.MCPContents()}, nil
 		})
 
 		s.logger.WithField("resource_uri", resourceConfig.URI).Debug("Resource registered")
@@ -202,135 +212,224 @@ func (s *MCPServer) registerResources() error {
 	return nil
 }
 
-// convertToMCPResource converts a config resource to an MCP resource
-func (s *MCPServer) convertToMCPResource(resourceConfig *config.ResourceConfig) mcp.Resource {
-	var opts []mcp.ResourceOption
-	opts = append(opts, mcp.WithResourceDescription(resourceConfig.Description))
-	opts = append(opts, mcp.WithMIMEType(resourceConfig.MimeType))
-
-	return mcp.NewResource(resourceConfig.URI, resourceConfig.Name, opts...)
-}
-
-// getResourceContent retrieves the content for a resource
-func (s *MCPServer) getResourceContent(resource *config.ResourceConfig) (string, error) {
-	// Inline content
-	if resource.Content != "" {
-		return resource.Content, nil
-	}
-
-	// File path content
-	if resource.FilePath != "" {
-		// Make path relative to current working directory if not absolute
-		path := resource.FilePath
-		if !filepath.IsAbs(path) {
-			wd, err := os.Getwd()
+// registerResourceTemplates registers templated resources, resolving each requested
+// URI against the template's file path or URL
+func (s *MCPServer) registerResourceTemplates() {
+	for _, tmpl := range s.config.ResourceTemplates {
+		template := mcp.NewResourceTemplate(tmpl.URITemplate, tmpl.Name,
+			mcp.WithTemplateDescription(tmpl.Description),
+			mcp.WithTemplateMIMEType(tmpl.MimeType),
+		)
+
+		s.mcpServer.AddResourceTemplate(template, func(request mcp.ReadResourceRequest) ([]interface{}, error) {
+			resource, err := s.resources.ResolveTemplate(request.Params.URI)
 			if err != nil {
-				return "", fmt.Errorf("failed to get working directory: %w", err)
+				return nil, err
 			}
-			path = filepath.Join(wd, path)
-		}
-
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return "", fmt.Errorf("failed to read file %s: %w", path, err)
-		}
-		return string(content), nil
-	}
-
-	// URL content (simple HTTP GET)
-	if resource.URL != "" {
-		client := &http.Client{Timeout: 30 * time.Second}
-		resp, err := client.Get(resource.URL)
-		if err != nil {
-			return "", fmt.Errorf("failed to fetch URL %s: %w", resource.URL, err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("HTTP error %d when fetching %s", resp.StatusCode, resource.URL)
-		}
-
-		content := make([]byte, 0)
-		buffer := make([]byte, 1024)
-		for {
-			n, err := resp.Body.Read(buffer)
-			if n > 0 {
-				content = append(content, buffer[:n]...)
+			if resource == nil {
+				return nil, fmt.Errorf("no resource template matches %s", request.Params.URI)
 			}
+
+			content, err := s.resources.Load(context.Background(), resource)
 			if err != nil {
-				break
+				return nil, fmt.Errorf("failed to get resource content: %w", err)
 			}
-		}
+			return []interface{}{content.MCPContents()}, nil
+		})
 
-		return string(content), nil
+		s.logger.WithField("uri_template", tmpl.URITemplate).Debug("Resource template registered")
 	}
+}
 
-	return "", fmt.Errorf("no content source specified for resource %s", resource.URI)
+// convertToMCPResource converts a config resource to an MCP resource
+func (s *MCPServer) convertToMCPResource(resourceConfig *config.ResourceConfig) mcp.Resource {
+	var opts []mcp.ResourceOption
+	opts = append(opts, mcp.WithResourceDescription(resourceConfig.Description))
+	opts = append(opts, mcp.WithMIMEType(resourceConfig.MimeType))
+
+	return mcp.NewResource(resourceConfig.URI, resourceConfig.Name, opts...)
 }
 
-// StartStdio starts the MCP server using standard input/output
+// StartStdio starts the MCP server using standard input/output. stdout then carries the
+// protocol, so logs are pointed at stderr first; a stray log line on stdout would
+// corrupt the stream for the client.
 func (s *MCPServer) StartStdio() error {
+	logrus.SetOutput(os.Stderr)
+	s.logger.SetOutput(os.Stderr)
+
 	s.logger.Info("Starting MCP server on stdio")
-	return server.ServeStdio(s.mcpServer)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := s.ServeStdio(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
 }
 
-// Start starts the MCP server on the specified port
+// shutdownTimeout bounds how long Start waits for running tool calls and connections
+// once its context is cancelled
+const shutdownTimeout = 30 * time.Second
+
+// Start starts the MCP server on the specified port. It serves until ctx is cancelled,
+// then shuts the server down and returns.
 func (s *MCPServer) Start(ctx context.Context, port int) error {
 	s.logger.WithField("port", port).Info("Starting MCP server")
 
-	// Create HTTP server
-	mux := http.NewServeMux()
-
-	// Add JSON-RPC handler for MCP protocol
-	jsonrpcHandler := handlers.NewJSONRPCHandler(s.config, s.toolHandler)
-	// If OAuth is enabled, wrap with auth and expose discovery
-	if s.config.Security.OAuth.Enabled {
-		mux.HandleFunc("/.well-known/oauth-protected-resource", s.oauthProtectedResourceHandler(port))
-		mux.Handle("/mcp", s.wrapWithAuth(jsonrpcHandler, port))
-	} else {
-		mux.Handle("/mcp", jsonrpcHandler)
+	if err := s.verifyAuthorizationServers(ctx); err != nil {
+		return err
 	}
 
-	// Add health check endpoint
-	mux.HandleFunc("/health", s.healthCheckHandler)
-
-	// Add metrics endpoint if enabled
-	if s.config.Runtime.MetricsEnabled {
-		mux.HandleFunc("/metrics", s.metricsHandler)
+	tlsConfig, err := s.serverTLSConfig()
+	if err != nil {
+		return err
 	}
 
 	s.httpServer = &http.Server{
 		Addr:         fmt.Sprintf(":%d", port),
-		Handler:      mux,
+		Handler:      s.routes(port),
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
+		TLSConfig:    tlsConfig,
 	}
 
 	// Start server in a goroutine
 	errChan := make(chan error, 1)
 	go func() {
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tlsConfig != nil {
+			// The certificate is already loaded into TLSConfig
+			err = s.httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = s.httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			errChan <- err
 		}
 	}()
 
-	s.logger.WithField("port", port).Info("MCP server started successfully")
+	s.logger.WithFields(logrus.Fields{
+		"port": port,
+		"tls":  tlsConfig != nil,
+	}).Info("MCP server started successfully")
 
 	// Wait for context cancellation or server error
 	select {
 	case <-ctx.Done():
-		s.logger.Info("Server context cancelled, shutting down")
-		return s.httpServer.Shutdown(context.Background())
+		// The deadline lets Drain cancel tool calls that would otherwise hold shutdown
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := s.Shutdown(shutdownCtx); err != nil {
+			s.logger.WithError(err).Error("Error during server shutdown")
+		}
+		return nil
 	case err := <-errChan:
 		return fmt.Errorf("server error: %w", err)
 	}
 }
 
+// routes builds the HTTP handler serving every endpoint, wrapped in the middleware
+// chain. port is the port clients reach the server on, used in OAuth metadata.
+func (s *MCPServer) routes(port int) http.Handler {
+	mux := http.NewServeMux()
+
+	// Add JSON-RPC handler for MCP protocol
+	jsonrpcHandler := handlers.NewJSONRPCHandler(s.config, s.toolHandler, s.resources, s.logger)
+	s.rpcHandler = jsonrpcHandler
+	// Expose OAuth discovery when bearer tokens are accepted
+	if s.config.Security.OAuth.Enabled {
+		mux.HandleFunc("/.well-known/oauth-protected-resource", s.oauthProtectedResourceHandler(port))
+	}
+	mux.Handle("/mcp", s.requireAuth(jsonrpcHandler, port))
+
+	// WebSocket transport sharing the same JSON-RPC dispatch
+	s.wsHandler = handlers.NewWebSocketHandler(jsonrpcHandler)
+	mux.Handle("/mcp/ws", s.requireAuth(s.wsHandler, port))
+
+	// Optional HTTP+SSE transport alongside the plain POST endpoint
+	if s.sseEnabled() {
+		s.sseHandler = handlers.NewSSEHandler(jsonrpcHandler, "/mcp/message")
+		mux.Handle("/mcp/sse", s.requireAuth(http.HandlerFunc(s.sseHandler.ServeStream), port))
+		mux.Handle("/mcp/message", s.requireAuth(http.HandlerFunc(s.sseHandler.ServeMessage), port))
+		s.logger.Info("SSE transport enabled at /mcp/sse")
+	}
+
+	// Administrative endpoints only exist when admin keys are configured
+	if len(s.config.Security.AdminKeys) > 0 {
+		mux.Handle("/admin/flush", s.requireAdmin(http.HandlerFunc(s.adminFlushHandler)))
+	}
+
+	// Liveness and readiness probes
+	mux.HandleFunc("/health", s.healthCheckHandler)
+	mux.HandleFunc("/ready", s.readinessHandler)
+
+	// What initialize would report, for discovery without a JSON-RPC session
+	mux.HandleFunc("/capabilities", s.capabilitiesHandler)
+
+	// Add metrics endpoint if enabled
+	if s.config.Runtime.MetricsEnabled {
+		mux.Handle("/metrics", s.metricsHandler())
+	}
+
+	return s.withMiddleware(mux)
+}
+
+// serverTLSConfig loads the certificate named by security.tls_cert_path and
+// tls_key_path. It returns nil when neither is set, so the server speaks plain HTTP.
+func (s *MCPServer) serverTLSConfig() (*tls.Config, error) {
+	certPath, keyPath := s.config.Security.TLSCertPath, s.config.Security.TLSKeyPath
+	if certPath == "" && keyPath == "" {
+		return nil, nil
+	}
+	if certPath == "" || keyPath == "" {
+		return nil, fmt.Errorf("security.tls_cert_path and security.tls_key_path must be set together")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate %s and key %s: %w", certPath, keyPath, err)
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}, nil
+}
+
+// StartSSE starts the HTTP server with the SSE transport enabled in addition to the
+// plain POST JSON-RPC endpoint
+func (s *MCPServer) StartSSE(ctx context.Context, port int) error {
+	s.enableSSE = true
+	return s.Start(ctx, port)
+}
+
+// sseEnabled reports whether the SSE transport was requested via StartSSE, the
+// runtime config, or the MCP_ENABLE_SSE environment variable
+func (s *MCPServer) sseEnabled() bool {
+	if s.enableSSE || s.config.Runtime.EnableSSE {
+		return true
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv("MCP_ENABLE_SSE"))
+	return enabled
+}
+
 // Shutdown gracefully shuts down the server
 func (s *MCPServer) Shutdown(ctx context.Context) error {
 	s.logger.Info("Shutting down MCP server")
 
+	// Let running tool calls finish first; streams are still open to deliver results
+	if s.rpcHandler != nil {
+		if remaining := s.rpcHandler.Drain(ctx); remaining > 0 {
+			s.logger.WithField("active_tool_calls", remaining).Warn("Shutdown deadline reached with tool calls still running")
+		} else {
+			s.logger.Info("All tool calls finished")
+		}
+	}
+
+	// Long-lived streams would otherwise hold Shutdown until its deadline
+	s.closeStreams()
+	defer s.toolHandler.Close()
+
 	if s.httpServer != nil {
 		return s.httpServer.Shutdown(ctx)
 	}
@@ -338,6 +437,16 @@ func (s *MCPServer) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// closeStreams terminates long-lived SSE and WebSocket connections
+func (s *MCPServer) closeStreams() {
+	if s.sseHandler != nil {
+		s.sseHandler.Close()
+	}
+	if s.wsHandler != nil {
+		s.wsHandler.Close()
+	}
+}
+
 // healthCheckHandler handles health check requests
 func (s *MCPServer) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -358,33 +467,79 @@ func (s *MCPServer) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// metricsHandler handles metrics requests (basic implementation)
-func (s *MCPServer) metricsHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain")
-	w.WriteHeader(http.StatusOK)
+// readinessHandler reports whether the server should receive traffic. Unlike /health,
+// which only shows the process is alive, it answers 503 once shutdown has begun so load
+// balancers stop routing new sessions here while in-flight calls drain.
+func (s *MCPServer) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	// Basic metrics - in production, you'd use a proper metrics library
-	metrics := fmt.Sprintf(`# HELP mcp_server_info Server information
-# TYPE mcp_server_info gauge
-mcp_server_info{name="%s",version="%s"} 1
-# HELP mcp_tools_count Number of registered tools
-# TYPE mcp_tools_count gauge
-mcp_tools_count %d
-# HELP mcp_prompts_count Number of registered prompts  
-# TYPE mcp_prompts_count gauge
-mcp_prompts_count %d
-# HELP mcp_resources_count Number of registered resources
-# TYPE mcp_resources_count gauge
-mcp_resources_count %d
-`,
-		s.config.Server.Name,
-		s.config.Server.Version,
-		len(s.config.Tools),
-		len(s.config.Prompts),
-		len(s.config.Resources),
-	)
+	response := map[string]interface{}{"status": "ready"}
+	if s.rpcHandler != nil && s.rpcHandler.Draining() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		response = map[string]interface{}{"status": "not_ready", "reason": "shutting down"}
+	}
 
-	w.Write([]byte(metrics))
+	if err := writeJSON(w, response); err != nil {
+		s.logger.WithError(err).Error("Failed to write readiness response")
+	}
+}
+
+// capabilitiesHandler returns the protocol version, capabilities and server info that
+// an initialize request would
+func (s *MCPServer) capabilitiesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Reported as a streaming client sees them, as the WebSocket transport is always on
+	if err := writeJSON(w, s.rpcHandler.InitializeResult(true)); err != nil {
+		s.logger.WithError(err).Error("Failed to write capabilities response")
+	}
+}
+
+// adminFlushHandler clears cached responses, endpoint cooldowns, circuit breakers and
+// session cookies, optionally for a single tool given by the "tool" query parameter, and
+// cached URL resources, optionally for a single URI given by the "resource" query parameter
+func (s *MCPServer) adminFlushHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	toolName := r.URL.Query().Get("tool")
+	resourceURI := r.URL.Query().Get("resource")
+
+	// A resource-only flush leaves tool state alone
+	var result handlers.FlushResult
+	if toolName != "" || resourceURI == "" {
+		var err error
+		result, err = s.toolHandler.FlushState(toolName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	}
+	if resourceURI != "" || toolName == "" {
+		result.ResourceEntries = s.resources.Invalidate(resourceURI)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"caller":           caller(r),
+		"remote_addr":      r.RemoteAddr,
+		"user_agent":       r.UserAgent(),
+		"tool_name":        toolName,
+		"cache_entries":    result.CacheEntries,
+		"endpoints_reset":  result.EndpointsReset,
+		"breakers_reset":   result.BreakersReset,
+		"sessions_reset":   result.SessionsReset,
+		"resource_uri":     resourceURI,
+		"resource_entries": result.ResourceEntries,
+	}).Warn("Admin flush triggered")
+
+	if err := writeJSON(w, result); err != nil {
+		s.logger.WithError(err).Error("Failed to write admin flush response")
+	}
 }
 
 // writeJSON writes a JSON response
@@ -425,7 +580,7 @@ func (s *MCPServer) wrapWithAuth(next http.Handler, port int) http.Handler {
 		// If you add validation: parse token, validate iss/aud/exp using AS metadata & JWKS.
 		// On failure, keep the 401 + WWW-Authenticate flow.
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, withCaller(r, "bearer:"+fingerprint(strings.TrimSpace(authz[7:]))))
 	})
 }
 
@@ -435,10 +590,18 @@ func (s *MCPServer) writeWWWAuthenticate(w http.ResponseWriter, r *http.Request,
 	w.Header().Set("WWW-Authenticate", val)
 }
 
+// canonicalBaseURL is the URL clients reach the server at. X-Forwarded-Proto is only
+// believed from a proxy listed in security.trusted_proxies, since any client can set it.
 func (s *MCPServer) canonicalBaseURL(r *http.Request, port int) string {
-	scheme := "https"
-	if r.TLS == nil && r.Header.Get("X-Forwarded-Proto") == "" {
-		scheme = "http"
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" && s.fromTrustedProxy(r) {
+		switch forwarded := strings.ToLower(strings.TrimSpace(strings.Split(proto, ",")[0])); forwarded {
+		case "http", "https":
+			scheme = forwarded
+		}
 	}
 	host := r.Host
 	if host == "" {
@@ -447,6 +610,24 @@ func (s *MCPServer) canonicalBaseURL(r *http.Request, port int) string {
 	return fmt.Sprintf("%s://%s", scheme, host)
 }
 
+// fromTrustedProxy reports whether r came directly from a configured trusted proxy
+func (s *MCPServer) fromTrustedProxy(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range s.config.Security.TrustedProxies {
+		if network, err := config.ParseProxyNetwork(proxy); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *MCPServer) canonicalMCPURL(r *http.Request, port int) string {
 	return s.canonicalBaseURL(r, port) + "/mcp"
 }
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ServeStdio answers newline-delimited JSON-RPC messages read from in on out until in
+// ends or ctx is cancelled. Messages go to the mcp-go server as with its own stdio
+// transport, except that tools/list leaves out the tools the other transports hide.
+func (s *MCPServer) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read stdin: %w", err)
+		case line := <-lines:
+			response := s.handleStdioMessage(ctx, []byte(line))
+			if response == nil {
+				continue
+			}
+			data, err := json.Marshal(response)
+			if err != nil {
+				return fmt.Errorf("failed to encode response: %w", err)
+			}
+			if _, err := fmt.Fprintf(out, "%s\n", data); err != nil {
+				return fmt.Errorf("failed to write response: %w", err)
+			}
+		}
+	}
+}
+
+// handleStdioMessage passes one message to the mcp-go server and filters its tools/list
+func (s *MCPServer) handleStdioMessage(ctx context.Context, line []byte) mcp.JSONRPCMessage {
+	var message json.RawMessage
+	if err := json.Unmarshal(line, &message); err != nil {
+		response := mcp.JSONRPCError{JSONRPC: mcp.JSONRPC_VERSION}
+		response.Error.Code = mcp.PARSE_ERROR
+		response.Error.Message = "Parse error"
+		return response
+	}
+
+	response := s.mcpServer.HandleMessage(ctx, message)
+	if result, ok := response.(mcp.JSONRPCResponse); ok {
+		if list, ok := result.Result.(mcp.ListToolsResult); ok {
+			listed := make([]mcp.Tool, 0, len(list.Tools))
+			for _, tool := range list.Tools {
+				if s.toolHandler.Listed(tool.Name) {
+					listed = append(listed, tool)
+				}
+			}
+			list.Tools = listed
+			result.Result = list
+			return result
+		}
+	}
+	return response
+}
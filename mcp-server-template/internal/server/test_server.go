@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// TestServer serves a configured MCP server on an in-memory httptest listener, with
+// the same handler chain as Start, and calls it the way an MCP client would. It lets
+// tests exercise tools, prompts and resources without a real port or hand-written
+// JSON-RPC bodies.
+type TestServer struct {
+	// URL is the base URL of the server, e.g. for reaching /health directly
+	URL string
+	// Header is sent with every request, e.g. to supply an API key
+	Header http.Header
+
+	server *MCPServer
+	http   *httptest.Server
+	nextID atomic.Int64
+}
+
+// NewTestServer configures a server from cfg and starts serving it. Call Close when
+// done.
+func NewTestServer(cfg *config.Config) (*TestServer, error) {
+	s, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := httptest.NewUnstartedServer(nil)
+	_, portText, _ := net.SplitHostPort(ts.Listener.Addr().String())
+	port, _ := strconv.Atoi(portText)
+	ts.Config.Handler = s.routes(port)
+	ts.Start()
+
+	return &TestServer{URL: ts.URL, Header: http.Header{}, server: s, http: ts}, nil
+}
+
+// Logger returns the server's logger, e.g. to capture what it logs with a hook
+func (t *TestServer) Logger() *logrus.Logger {
+	return t.server.logger
+}
+
+// Close drains running tool calls and stops the server
+func (t *TestServer) Close() {
+	t.server.Shutdown(context.Background())
+	t.http.Close()
+}
+
+// Call sends one JSON-RPC request to /mcp and decodes its result into result, which
+// may be nil. A JSON-RPC error response is returned as a *handlers.JSONRPCError.
+func (t *TestServer) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      t.nextID.Add(1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL+"/mcp", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range t.Header {
+		req.Header[name] = values
+	}
+
+	resp, err := t.http.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: HTTP %d: %s", method, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var response struct {
+		Result json.RawMessage        `json:"result"`
+		Error  *handlers.JSONRPCError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("%s: invalid response: %w", method, err)
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+// Initialize performs the initialize handshake
+func (t *TestServer) Initialize(ctx context.Context) (*mcp.InitializeResult, error) {
+	params := map[string]interface{}{
+		"protocolVersion": "2024-11-05",
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "test-client", "version": "1.0.0"},
+	}
+	var result mcp.InitializeResult
+	if err := t.Call(ctx, "initialize", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListTools returns the tools the server advertises
+func (t *TestServer) ListTools(ctx context.Context) (*mcp.ListToolsResult, error) {
+	var result mcp.ListToolsResult
+	if err := t.Call(ctx, "tools/list", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CallTool calls a tool. Failures reported by the tool come back as a result with
+// IsError set, not as an error.
+func (t *TestServer) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	params := map[string]interface{}{"name": name, "arguments": arguments}
+	var result mcp.CallToolResult
+	if err := t.Call(ctx, "tools/call", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ResultText joins the text content items of a tool result with newlines
+func ResultText(result *mcp.CallToolResult) string {
+	var texts []string
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case map[string]interface{}:
+			if text, ok := c["text"].(string); ok && c["type"] == "text" {
+				texts = append(texts, text)
+			}
+		case mcp.TextContent:
+			texts = append(texts, c.Text)
+		case *mcp.TextContent:
+			texts = append(texts, c.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// DecodeResultJSON parses a tool result's text content as JSON into v
+func DecodeResultJSON(result *mcp.CallToolResult, v interface{}) error {
+	return json.Unmarshal([]byte(ResultText(result)), v)
+}
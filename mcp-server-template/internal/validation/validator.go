@@ -18,7 +18,7 @@ type Validator struct {
 }
 
 // New creates a new validator instance
-func New() *Validator {
+func New(logger *logrus.Logger) *Validator {
 	validate := validator.New()
 
 	// Register custom validation functions
@@ -28,7 +28,7 @@ func New() *Validator {
 
 	return &Validator{
 		validate: validate,
-		logger:   logrus.New(),
+		logger:   logger,
 	}
 }
 
@@ -0,0 +1,78 @@
+// Package configcheck validates a server config.json document with the server's own
+// loader, for programs that store configs without running them, such as the backend.
+package configcheck
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Check parses and validates a config.json document the way the server does at
+// startup and returns every problem found, or nil when none is.
+//
+// Nothing about this machine is consulted, since the config will run somewhere else.
+// ${VAR:-default} placeholders take their default and the rest are kept as written.
+// TLS files are not looked for and exec commands are not matched against the
+// allowlist, because both depend on the host. The server can therefore still refuse a
+// config that passes.
+// The base config and each of its profiles are checked. Includes are refused, because
+// a single document has no files to pull in.
+func Check(data []byte) []string {
+	var doc struct {
+		Includes []string                   `json:"includes"`
+		Profiles map[string]json.RawMessage `json:"profiles"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []string{"config: " + err.Error()}
+	}
+
+	problems := check(data, config.LoadOptions{IgnoreEnv: true})
+	if len(doc.Includes) > 0 {
+		problems = append(problems, "includes are not supported, the config has to be self-contained")
+	}
+
+	reported := make(map[string]bool, len(problems))
+	for _, problem := range problems {
+		reported[problem] = true
+	}
+	profiles := make([]string, 0, len(doc.Profiles))
+	for name := range doc.Profiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	for _, name := range profiles {
+		for _, problem := range check(data, config.LoadOptions{IgnoreEnv: true, Profile: name}) {
+			// Problems of the base config show up under every profile that keeps them
+			if !reported[problem] {
+				problems = append(problems, "profile "+name+": "+problem)
+			}
+		}
+	}
+	return problems
+}
+
+// check parses and validates data with opts, listing each failed field rule separately
+func check(data []byte, opts config.LoadOptions) []string {
+	cfg, err := config.ParseWithOptions(data, opts)
+	if err == nil {
+		err = config.ValidateWithOptions(cfg, config.ValidateOptions{SkipHostChecks: true})
+	}
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return []string{err.Error()}
+	}
+	problems := make([]string, len(fieldErrs))
+	for i, fieldErr := range fieldErrs {
+		problems[i] = fieldErr.Error()
+	}
+	return problems
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/server"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminFlushLogsCaller(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "flush-test", Version: "1.0.0"},
+		Security: config.SecurityConfig{
+			EnableAuth: true,
+			APIKeys:    []string{"client-key"},
+			AdminKeys:  []string{"flush-key-1", "flush-key-2"},
+		},
+	}
+	ts, err := server.NewTestServer(cfg)
+	require.NoError(t, err)
+	defer ts.Close()
+	hook := test.NewLocal(ts.Logger())
+
+	flush := func(key string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/admin/flush", nil)
+		require.NoError(t, err)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// Keys for ordinary MCP clients don't unlock admin endpoints
+	for _, key := range []string{"", "client-key"} {
+		resp := flush(key)
+		resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode, key)
+	}
+
+	for _, key := range []string{"flush-key-1", "flush-key-2"} {
+		resp := flush(key)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		resp.Body.Close()
+	}
+
+	var callers []interface{}
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Admin flush triggered" {
+			callers = append(callers, entry.Data["caller"])
+		}
+	}
+	require.Len(t, callers, 2)
+	// Each key is identified by a fingerprint, never logged as is
+	for _, c := range callers {
+		require.Regexp(t, `^admin_key:[0-9a-f]{8}$`, c)
+	}
+	require.NotEqual(t, callers[0], callers[1])
+}
+
+func TestAdminFlushNeedsAdminKeys(t *testing.T) {
+	// OAuth alone doesn't verify tokens, so it must not expose admin endpoints
+	ts, err := server.NewTestServer(&config.Config{
+		Server:   config.ServerConfig{Name: "flush-test", Version: "1.0.0"},
+		Security: config.SecurityConfig{OAuth: config.OAuthConfig{Enabled: true}},
+	})
+	require.NoError(t, err)
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/admin/flush", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer anything")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
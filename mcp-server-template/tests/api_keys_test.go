@@ -0,0 +1,31 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/server"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeysRejectWrongBearerToken(t *testing.T) {
+	ts, err := server.NewTestServer(&config.Config{
+		Server:   config.ServerConfig{Name: "keys-test", Version: "1.0.0"},
+		Security: config.SecurityConfig{EnableAuth: true, APIKeys: []string{"real-key"}},
+	})
+	require.NoError(t, err)
+	defer ts.Close()
+	ctx := context.Background()
+
+	for _, authz := range []string{"", "Bearer totally-wrong", "Bearer real-key-but-longer"} {
+		ts.Header.Set("Authorization", authz)
+		_, err := ts.Initialize(ctx)
+		require.ErrorContains(t, err, "HTTP 401", authz)
+	}
+
+	ts.Header.Set("Authorization", "Bearer real-key")
+	_, err = ts.Initialize(ctx)
+	require.NoError(t, err)
+}
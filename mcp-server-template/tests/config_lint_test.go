@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"testing"
+
+	"mcp-server-template/internal/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintReportsSoftProblems(t *testing.T) {
+	cfg := &config.Config{
+		Tools: []config.ToolConfig{
+			{
+				Name:         "create_issue",
+				Endpoint:     "https://api.example.com/repos/{{.repo}}/issues",
+				Method:       "POST",
+				BodyTemplate: `{"title": "{{.title}}", "body": "{{index . "body-text"}}"}`,
+				Auth:         &config.AuthConfig{Type: "bearer", EnvVar: "LINT_TEST_UNSET_TOKEN"},
+				Parameters: []config.ParameterConfig{
+					{Name: "repo", Type: "string"},
+					{Name: "title", Type: "string"},
+					{Name: "labels", Type: "array"},
+				},
+			},
+			{
+				Name:     "list_issues",
+				Endpoint: "http://api.example.com/issues",
+				Method:   "GET",
+				Parameters: []config.ParameterConfig{
+					{Name: "state", Type: "string"},
+				},
+			},
+		},
+		Prompts: []config.PromptConfig{
+			{
+				Name:      "triage",
+				Content:   "Triage {issue} in {repo}",
+				Arguments: []config.ArgumentConfig{{Name: "issue"}},
+			},
+		},
+	}
+
+	var got []string
+	for _, w := range config.Lint(cfg) {
+		got = append(got, w.String())
+	}
+	require.ElementsMatch(t, []string{
+		`tools[create_issue].body_template: references undeclared parameter "body-text"`,
+		`tools[create_issue]: parameter "labels" is declared but never sent`,
+		`tools[create_issue].auth: environment variable LINT_TEST_UNSET_TOKEN is not set`,
+		`tools: host api.example.com is called over both http and https`,
+		`prompts[triage].content: placeholder {repo} has no matching argument`,
+	}, got)
+}
+
+func TestLintAcceptsTemplatesPassingAllArguments(t *testing.T) {
+	cfg := &config.Config{
+		Tools: []config.ToolConfig{{
+			Name:         "create",
+			Endpoint:     "https://api.example.com/items",
+			Method:       "POST",
+			BodyTemplate: `{{toJson .}}`,
+			Parameters:   []config.ParameterConfig{{Name: "name", Type: "string"}},
+		}},
+	}
+	require.Empty(t, config.Lint(cfg))
+}
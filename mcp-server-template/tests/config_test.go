@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -143,12 +144,14 @@ func TestConfigLoad(t *testing.T) {
 			err := os.WriteFile(configPath, []byte(tt.configJSON), 0644)
 			require.NoError(t, err)
 
-			// Load config
+			// Load config and validate it, as the server does at startup
 			cfg, err := config.Load(configPath)
+			if err == nil {
+				err = config.Validate(cfg)
+			}
 
 			if tt.expectError {
 				assert.Error(t, err)
-				assert.Nil(t, cfg)
 			} else {
 				assert.NoError(t, err)
 				require.NotNil(t, cfg)
@@ -176,6 +179,8 @@ func TestConfigValidation(t *testing.T) {
 					Version:     "1.0.0",
 					Description: "Valid server config",
 				},
+				Security: config.SecurityConfig{RateLimit: 100},
+				Runtime:  config.RuntimeConfig{MaxConcurrentRequests: 100, LogLevel: "info", Environment: "development"},
 			},
 			expectError: false,
 		},
@@ -200,6 +205,8 @@ func TestConfigValidation(t *testing.T) {
 						Method:      "GET",
 					},
 				},
+				Security: config.SecurityConfig{RateLimit: 100},
+				Runtime:  config.RuntimeConfig{MaxConcurrentRequests: 100, LogLevel: "info", Environment: "development"},
 			},
 			expectError: true,
 			errorMsg:    "duplicate tool name",
@@ -271,8 +278,8 @@ func TestConfigDefaults(t *testing.T) {
 	// Check defaults were applied
 	assert.Equal(t, "1.0.0", cfg.Server.Version)
 	assert.Equal(t, "GET", cfg.Tools[0].Method)
-	assert.Equal(t, config.Duration(30*time.Second), cfg.Tools[0].Timeout)
-	assert.Equal(t, 3, cfg.Tools[0].Retries)
+	assert.Equal(t, 30*time.Second, cfg.Tools[0].TimeoutDuration())
+	assert.Equal(t, 3, cfg.Tools[0].RetryCount())
 	assert.Equal(t, 100, cfg.Security.RateLimit)
 	assert.Equal(t, 100, cfg.Runtime.MaxConcurrentRequests)
 	assert.Equal(t, "info", cfg.Runtime.LogLevel)
@@ -317,6 +324,61 @@ func TestEnvironmentVariableSubstitution(t *testing.T) {
 	assert.Equal(t, "Bearer secret-key-123", cfg.Tools[0].Headers["Authorization"])
 }
 
+func TestUnresolvedEnvironmentVariables(t *testing.T) {
+	configJSON := `{
+		"server": {"name": "env-test-server", "version": "1.0.0"},
+		"tools": [{
+			"name": "test_tool",
+			"description": "Test tool with env vars",
+			"endpoint": "${TEST_UNSET_BASE_URL:-https://api.example.com}/test",
+			"method": "GET",
+			"headers": {"Authorization": "Bearer ${TEST_UNSET_KEY}", "X-Other": "${TEST_UNSET_OTHER}"}
+		}]
+	}`
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(configJSON), 0644))
+
+	// By default the placeholder is kept and only logged
+	cfg, err := config.Load(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/test", cfg.Tools[0].Endpoint)
+	assert.Equal(t, "Bearer ${TEST_UNSET_KEY}", cfg.Tools[0].Headers["Authorization"])
+
+	_, err = config.LoadWithOptions(configPath, config.LoadOptions{StrictEnv: true})
+	require.EqualError(t, err, "unresolved environment variables: TEST_UNSET_KEY, TEST_UNSET_OTHER")
+
+	// runtime.strict_env in the file has the same effect
+	strictJSON := strings.Replace(configJSON, `"tools"`, `"runtime": {"strict_env": true}, "tools"`, 1)
+	require.NoError(t, os.WriteFile(configPath, []byte(strictJSON), 0644))
+	_, err = config.Load(configPath)
+	require.ErrorContains(t, err, "TEST_UNSET_KEY")
+}
+
+func TestRequiredEnvironmentVariables(t *testing.T) {
+	t.Setenv("TEST_REQUIRED_SET", "abc")
+	configJSON := `{
+		"server": {"name": "env-test-server", "version": "1.0.0"},
+		"tools": [{
+			"name": "test_tool",
+			"description": "Test tool with env vars",
+			"endpoint": "https://api.example.com/test",
+			"method": "GET",
+			"headers": {"X-Set": "${TEST_REQUIRED_SET:?unused}", "X-Key": "${TEST_REQUIRED_UNSET:?set it to your API key}"}
+		}]
+	}`
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(configJSON), 0644))
+
+	_, err := config.Load(configPath)
+	require.EqualError(t, err, "required environment variables not set: TEST_REQUIRED_UNSET: set it to your API key")
+
+	t.Setenv("TEST_REQUIRED_UNSET", "key")
+	cfg, err := config.Load(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", cfg.Tools[0].Headers["X-Set"])
+	assert.Equal(t, "key", cfg.Tools[0].Headers["X-Key"])
+}
+
 func TestAuthConfigValidation(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -395,11 +457,13 @@ func setDefaults(cfg *config.Config) {
 		if tool.Method == "" {
 			tool.Method = "GET"
 		}
-		if tool.Timeout == 0 {
-			tool.Timeout = 30 * time.Second
+		if tool.Timeout == nil {
+			timeout := config.Duration(30 * time.Second)
+			tool.Timeout = &timeout
 		}
-		if tool.Retries == 0 {
-			tool.Retries = 3
+		if tool.Retries == nil {
+			retries := 3
+			tool.Retries = &retries
 		}
 	}
 
@@ -429,3 +493,223 @@ func validateAuthConfig(auth *config.AuthConfig) error {
 	}
 	return nil
 }
+
+func TestAPIKeyAuthNeedsKeys(t *testing.T) {
+	parse := func(security string) error {
+		cfg, err := config.Parse([]byte(`{"server":{"name":"auth","version":"1.0.0"},"security":` + security + `}`))
+		require.NoError(t, err)
+		return config.Validate(cfg)
+	}
+
+	require.NoError(t, parse(`{"enable_auth":true,"api_keys":["k1"]}`))
+	require.ErrorContains(t, parse(`{"enable_auth":true}`), "security.api_keys is empty")
+	require.ErrorContains(t, parse(`{"enable_auth":true,"api_keys":["k1",""]}`), "security.api_keys[1] is empty")
+	require.ErrorContains(t, parse(`{"enable_auth":true,"api_keys":["k1"],"oauth":{"enabled":true}}`), "cannot be combined with security.oauth.enabled")
+}
+
+func TestConfigIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	tool := func(name string) string {
+		return `{"name": "` + name + `", "description": "d", "endpoint": "https://api.example.com", "method": "GET"}`
+	}
+
+	root := writeFile("config.json", `{
+		"server": {"name": "composed", "version": "1.0.0"},
+		"includes": ["teams/*.json"],
+		"tools": [`+tool("base_tool")+`]
+	}`)
+	writeFile("teams/billing.json", `{"tools": [`+tool("billing_tool")+`], "includes": ["../shared/prompts.json"]}`)
+	writeFile("teams/search.json", `{"tools": [`+tool("search_tool")+`]}`)
+	writeFile("shared/prompts.json", `{"prompts": [{"name": "p", "description": "d", "content": "c"}]}`)
+
+	cfg, err := config.Load(root)
+	require.NoError(t, err)
+	require.NoError(t, config.Validate(cfg))
+	var names []string
+	for _, tool := range cfg.Tools {
+		names = append(names, tool.Name)
+	}
+	assert.Equal(t, []string{"base_tool", "billing_tool", "search_tool"}, names)
+	require.Len(t, cfg.Prompts, 1)
+
+	writeFile("teams/search.json", `{"tools": [`+tool("billing_tool")+`]}`)
+	_, err = config.Load(root)
+	require.ErrorContains(t, err, "duplicate tool name billing_tool: defined in "+filepath.Join(dir, "teams/billing.json")+" and "+filepath.Join(dir, "teams/search.json"))
+
+	writeFile("teams/search.json", `{"includes": ["../config.json"]}`)
+	_, err = config.Load(root)
+	require.ErrorContains(t, err, "include cycle")
+}
+
+func TestToolDefaultsAreMerged(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(root, []byte(`{
+		"server": {"name": "defaults", "version": "1.0.0"},
+		"defaults": {
+			"headers": {"X-Org-Id": "acme", "Accept-Language": "en"},
+			"auth": {"type": "bearer", "token": "shared-token"},
+			"timeout": "5s",
+			"retries": 1
+		},
+		"includes": ["team.json"],
+		"tools": [
+			{"name": "plain", "description": "d", "endpoint": "https://api.example.com/a"},
+			{"name": "custom", "description": "d", "endpoint": "https://api.example.com/b",
+			 "headers": {"accept-language": "fr"}, "auth": {"type": "bearer", "token": "own"}, "timeout": "60s", "retries": 4},
+			{"name": "opted_out", "description": "d", "endpoint": "https://api.example.com/d",
+			 "auth": {"type": "none"}, "timeout": "0s", "retries": 0}
+		]
+	}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "team.json"), []byte(`{
+		"defaults": {"headers": {"X-Team": "search"}},
+		"tools": [{"name": "included", "description": "d", "endpoint": "https://api.example.com/c"}]
+	}`), 0644))
+
+	cfg, err := config.Load(root)
+	require.NoError(t, err)
+	require.NoError(t, config.Validate(cfg))
+	tools := make(map[string]config.ToolConfig)
+	for _, tool := range cfg.Tools {
+		tools[tool.Name] = tool
+	}
+
+	plain := tools["plain"]
+	assert.Equal(t, map[string]string{"X-Org-Id": "acme", "Accept-Language": "en"}, plain.Headers)
+	assert.Equal(t, "shared-token", plain.Auth.Token)
+	assert.Equal(t, 5*time.Second, plain.TimeoutDuration())
+	assert.Equal(t, 1, plain.RetryCount())
+
+	// The tool's own settings win, with header names matched case-insensitively
+	custom := tools["custom"]
+	assert.Equal(t, map[string]string{"X-Org-Id": "acme", "accept-language": "fr"}, custom.Headers)
+	assert.Equal(t, "own", custom.Auth.Token)
+	assert.Equal(t, 60*time.Second, custom.TimeoutDuration())
+	assert.Equal(t, 4, custom.RetryCount())
+
+	// auth of type none drops the default auth, and zero is a value rather than unset
+	optedOut := tools["opted_out"]
+	assert.Nil(t, optedOut.Auth)
+	require.NotNil(t, optedOut.Timeout)
+	assert.Zero(t, optedOut.TimeoutDuration())
+	require.NotNil(t, optedOut.Retries)
+	assert.Zero(t, optedOut.RetryCount())
+
+	// Included files inherit the including file's defaults under their own
+	included := tools["included"]
+	assert.Equal(t, map[string]string{"X-Org-Id": "acme", "Accept-Language": "en", "X-Team": "search"}, included.Headers)
+	assert.Equal(t, "shared-token", included.Auth.Token)
+}
+
+func TestRelativeEndpointsJoinBaseURL(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(root, []byte(`{
+		"server": {"name": "base-url", "version": "1.0.0"},
+		"defaults": {"base_url": "https://api.example.com/v1/"},
+		"includes": ["staging.json"],
+		"tools": [
+			{"name": "user", "description": "d", "method": "GET", "endpoint": "/users/{id}"},
+			{"name": "absolute", "description": "d", "method": "GET", "endpoint": "https://other.example.com/ping"},
+			{"name": "own_base", "description": "d", "method": "GET", "base_url": "https://eu.example.com", "endpoint": "orders"},
+			{"name": "balanced", "description": "d", "method": "GET", "endpoints": [{"url": "/a"}, {"url": "https://b.example.com/a"}]}
+		]
+	}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "staging.json"), []byte(`{
+		"defaults": {"base_url": "https://staging.example.com"},
+		"tools": [{"name": "included", "description": "d", "method": "GET", "endpoint": "/health"}]
+	}`), 0644))
+
+	cfg, err := config.Load(root)
+	require.NoError(t, err)
+	require.NoError(t, config.Validate(cfg))
+	tools := make(map[string]config.ToolConfig)
+	for _, tool := range cfg.Tools {
+		tools[tool.Name] = tool
+	}
+
+	assert.Equal(t, "https://api.example.com/v1/users/{id}", tools["user"].Endpoint)
+	assert.Equal(t, "https://other.example.com/ping", tools["absolute"].Endpoint)
+	assert.Equal(t, "https://eu.example.com/orders", tools["own_base"].Endpoint)
+	assert.Equal(t, "https://api.example.com/v1/a", tools["balanced"].Endpoint)
+	assert.Equal(t, "https://b.example.com/a", tools["balanced"].Endpoints[1].URL)
+	assert.Equal(t, "https://staging.example.com/health", tools["included"].Endpoint)
+
+	// Without a base URL a relative endpoint is still rejected
+	cfg, err = config.Parse([]byte(`{
+		"server": {"name": "base-url", "version": "1.0.0"},
+		"tools": [{"name": "user", "description": "d", "method": "GET", "endpoint": "/users"}]
+	}`))
+	require.NoError(t, err)
+	assert.Error(t, config.Validate(cfg))
+}
+
+func TestConfigProfiles(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(root, []byte(`{
+		"server": {"name": "profiles", "version": "1.0.0"},
+		"defaults": {"base_url": "http://localhost:9000", "auth": {"type": "bearer", "token": "dev-token"}},
+		"runtime": {"log_level": "debug", "strict_env": true, "max_response_bytes": 9007199254740993},
+		"includes": ["extra.json"],
+		"profiles": {
+			"prod": {
+				"defaults": {"base_url": "https://api.example.com", "auth": {"token": "${PROFILE_TEST_PROD_TOKEN}"}},
+				"runtime": {"log_level": "warn"}
+			},
+			"staging": {"defaults": {"base_url": "https://staging.example.com", "auth": {"token": "${PROFILE_TEST_STAGING_TOKEN:?needed}"}}}
+		},
+		"tools": [{"name": "user", "description": "d", "method": "GET", "endpoint": "/users"}]
+	}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "extra.json"), []byte(`{
+		"profiles": {"prod": {"defaults": {"base_url": "https://extra.example.com"}}},
+		"tools": [{"name": "extra", "description": "d", "method": "GET", "endpoint": "/extra"}]
+	}`), 0644))
+	t.Setenv("PROFILE_TEST_PROD_TOKEN", "prod-token")
+
+	endpoints := func(cfg *config.Config) map[string]string {
+		byName := make(map[string]string)
+		for _, tool := range cfg.Tools {
+			byName[tool.Name] = tool.Endpoint
+		}
+		return byName
+	}
+
+	// Without a profile the base applies, and placeholders in profiles aren't checked
+	cfg, err := config.Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, "debug", cfg.Runtime.LogLevel)
+	assert.Equal(t, "dev-token", cfg.Defaults.Auth.Token)
+	assert.Equal(t, "http://localhost:9000/users", endpoints(cfg)["user"])
+
+	cfg, err = config.LoadWithOptions(root, config.LoadOptions{Profile: "prod"})
+	require.NoError(t, err)
+	require.NoError(t, config.Validate(cfg))
+	assert.Equal(t, "warn", cfg.Runtime.LogLevel)
+	assert.Equal(t, "bearer", cfg.Defaults.Auth.Type)
+	assert.Equal(t, "prod-token", cfg.Defaults.Auth.Token)
+	assert.Equal(t, "https://api.example.com/users", endpoints(cfg)["user"])
+	assert.Equal(t, "https://extra.example.com/extra", endpoints(cfg)["extra"])
+	// Numbers aren't rounded through float64 when the profile is merged
+	assert.Equal(t, int64(9007199254740993), cfg.Runtime.MaxResponseBytes)
+
+	_, err = config.LoadWithOptions(root, config.LoadOptions{Profile: "staging"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "needed")
+
+	// The environment variable is left to the command line
+	t.Setenv(config.ProfileEnvVar, "staging")
+	cfg, err = config.Load(root)
+	require.NoError(t, err)
+	assert.Equal(t, "dev-token", cfg.Defaults.Auth.Token)
+
+	_, err = config.LoadWithOptions(root, config.LoadOptions{Profile: "qa"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown profile "qa", expected one of: prod, staging`)
+}
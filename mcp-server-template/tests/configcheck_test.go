@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"testing"
+
+	"mcp-server-template/pkg/configcheck"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCheckUsesServerDefaults(t *testing.T) {
+	// No method, parameter type or transport: the loader fills them in
+	problems := configcheck.Check([]byte(`{
+		"server": {"name": "weather", "version": "1.0.0"},
+		"defaults": {"base_url": "${API_BASE_URL:-https://api.example.com}"},
+		"tools": [{
+			"name": "get_weather",
+			"description": "Current weather",
+			"endpoint": "/weather",
+			"parameters": [{"name": "city", "description": "City"}]
+		}]
+	}`))
+	require.Empty(t, problems)
+}
+
+func TestConfigCheckIgnoresEnvironment(t *testing.T) {
+	t.Setenv("CHECK_TEST_TOKEN", "")
+	problems := configcheck.Check([]byte(`{
+		"server": {"name": "weather", "version": "1.0.0"},
+		"tools": [{
+			"name": "get_weather",
+			"description": "Current weather",
+			"endpoint": "${WEATHER_API}/weather",
+			"headers": {"Authorization": "Bearer ${CHECK_TEST_TOKEN:?the API token}"}
+		}]
+	}`))
+	require.Empty(t, problems)
+}
+
+func TestConfigCheckIgnoresHostFiles(t *testing.T) {
+	// The files and binaries only exist where the server will run
+	problems := configcheck.Check([]byte(`{
+		"server": {"name": "ops", "version": "1.0.0"},
+		"security": {
+			"upstream_tls": {"ca_file": "/etc/mcp/does-not-exist/ca.pem"},
+			"exec_allowlist": ["/opt/tools/bin/deploy"]
+		},
+		"tools": [{
+			"name": "deploy",
+			"description": "Deploys a service",
+			"protocol": "exec",
+			"exec": {"command": "deploy"}
+		}]
+	}`))
+	require.Empty(t, problems)
+
+	// Rules that don't depend on the host still apply
+	problems = configcheck.Check([]byte(`{
+		"server": {"name": "ops", "version": "1.0.0"},
+		"security": {"upstream_tls": {"cert_file": "/etc/mcp/client.pem"}}
+	}`))
+	require.Len(t, problems, 1)
+	require.Contains(t, problems[0], "cert_file and key_file must be set together")
+}
+
+func TestConfigCheckReportsProblems(t *testing.T) {
+	problems := configcheck.Check([]byte(`{
+		"server": {"name": "weather", "version": "latest"},
+		"includes": ["tools/*.json"],
+		"tools": [{"name": "get_weather", "description": "Current weather", "endpoint": "https://api.example.com"}],
+		"profiles": {"prod": {"server": {"version": "1.0.0"}, "tools": [
+			{"name": "a", "description": "A", "endpoint": "https://a.example.com"},
+			{"name": "a", "description": "A again", "endpoint": "https://a.example.com"}
+		]}}
+	}`))
+	require.Len(t, problems, 3, problems)
+	require.Contains(t, problems[0], "Version")
+	require.Contains(t, problems[1], "includes are not supported")
+	require.Contains(t, problems[2], "profile prod: business rule validation failed: duplicate tool name: a")
+
+	require.Len(t, configcheck.Check([]byte(`{"server":`)), 1)
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newExecRunner returns a runner that allows echo and sh, with its logger's hook
+func newExecRunner() (*handlers.ExecRunner, *test.Hook) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	cfg := &config.Config{Security: config.SecurityConfig{ExecAllowlist: []string{"echo", "sh"}}}
+	return handlers.NewExecRunner(cfg, logger), hook
+}
+
+func execTool(command string, args ...string) *config.ToolConfig {
+	return &config.ToolConfig{Name: "run", Protocol: "exec", Exec: &config.ExecConfig{Command: command, Args: args}}
+}
+
+func TestExecRejectsCommandsOutsideAllowlist(t *testing.T) {
+	runner, _ := newExecRunner()
+
+	_, err := runner.Run(context.Background(), execTool("rm", "{{.path}}"), map[string]interface{}{"path": "/tmp/x"})
+	assert.ErrorContains(t, err, "command rm is not in the exec allowlist")
+}
+
+func TestExecRejectsArgumentsThatBecomeOptions(t *testing.T) {
+	runner, _ := newExecRunner()
+	tool := execTool("echo", "-n", "{{.text}}")
+
+	for _, text := range []string{"-e", "--help"} {
+		_, err := runner.Run(context.Background(), tool, map[string]interface{}{"text": text})
+		assert.ErrorContains(t, err, "would be read as an option", text)
+	}
+
+	// Options written by the tool author are kept
+	resp, err := runner.Run(context.Background(), tool, map[string]interface{}{"text": "plain"})
+	require.NoError(t, err)
+	assert.Equal(t, "plain", resp.Body)
+}
+
+func TestExecPassesShellMetacharactersLiterally(t *testing.T) {
+	runner, _ := newExecRunner()
+	text := `a; echo injected && $(id) | cat > /tmp/x "q" 'q' *`
+
+	resp, err := runner.Run(context.Background(), execTool("echo", "{{.text}}"), map[string]interface{}{"text": text})
+	require.NoError(t, err)
+	assert.Equal(t, text+"\n", resp.Body)
+	assert.EqualValues(t, 0, resp.Data.(map[string]interface{})["exit_code"])
+}
+
+func TestExecReportsNonZeroExit(t *testing.T) {
+	runner, _ := newExecRunner()
+
+	_, err := runner.Run(context.Background(), execTool("sh", "-c", "echo broken >&2; exit 3"), nil)
+	assert.EqualError(t, err, "exit status 3: broken")
+}
+
+func TestExecRedactsSensitiveArgumentsInLogs(t *testing.T) {
+	runner, hook := newExecRunner()
+	tool := execTool("echo", "--token={{.api_token}}", "{{.user}}")
+
+	_, err := runner.Run(context.Background(), tool, map[string]interface{}{"api_token": "s3cret-value", "user": "ada"})
+	require.NoError(t, err)
+
+	var logged []string
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "Running command" {
+			logged = entry.Data["args"].([]string)
+		}
+	}
+	assert.Equal(t, []string{"***REDACTED***", "ada"}, logged)
+	for _, entry := range hook.AllEntries() {
+		assert.NotContains(t, fmt.Sprint(entry.Data), "s3cret-value")
+	}
+}
@@ -0,0 +1,442 @@
+package tests
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClientRoutesThroughProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+
+	// Stub forward proxy: records the absolute-form request and relays it upstream
+	var proxied atomic.Int32
+	var proxiedHost atomic.Value
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		proxiedHost.Store(r.URL.Host)
+
+		outbound, err := http.NewRequestWithContext(r.Context(), r.Method, r.URL.String(), r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		resp, err := http.DefaultTransport.RoundTrip(outbound)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+
+	tool := &config.ToolConfig{
+		Name:     "proxied_tool",
+		Endpoint: upstream.URL + "/data",
+		Method:   "GET",
+	}
+
+	t.Run("global proxy", func(t *testing.T) {
+		proxied.Store(0)
+		cfg := &config.Config{Runtime: config.RuntimeConfig{ProxyURL: proxy.URL}}
+
+		resp, err := handlers.NewHTTPClient(cfg, logrus.New()).ExecuteRequest(context.Background(), tool, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(1), proxied.Load())
+		assert.Equal(t, upstreamURL.Host, proxiedHost.Load())
+	})
+
+	t.Run("per-tool proxy", func(t *testing.T) {
+		proxied.Store(0)
+		perTool := *tool
+		perTool.ProxyURL = proxy.URL
+
+		resp, err := handlers.NewHTTPClient(&config.Config{}, logrus.New()).ExecuteRequest(context.Background(), &perTool, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(1), proxied.Load())
+	})
+
+	t.Run("tool opts out of global proxy", func(t *testing.T) {
+		proxied.Store(0)
+		direct := *tool
+		direct.ProxyURL = "none"
+		cfg := &config.Config{Runtime: config.RuntimeConfig{ProxyURL: proxy.URL}}
+
+		resp, err := handlers.NewHTTPClient(cfg, logrus.New()).ExecuteRequest(context.Background(), &direct, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(0), proxied.Load())
+	})
+}
+
+func TestHTTPClientTemplateFunctions(t *testing.T) {
+	var got *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		io.WriteString(w, `{}`)
+	}))
+	defer upstream.Close()
+
+	t.Setenv("TEMPLATE_TEST_PASSWORD", "p@ss")
+	tool := &config.ToolConfig{
+		Name:     "search",
+		Endpoint: upstream.URL + "/search?q={{urlquery .term}}",
+		Method:   "GET",
+		Headers: map[string]string{
+			"Authorization": `Basic {{printf "%s:%s" .user (env "TEMPLATE_TEST_PASSWORD") | b64enc}}`,
+		},
+		QueryParams: map[string]string{"limit": `{{.limit | default 10}}`},
+	}
+
+	client := handlers.NewHTTPClient(&config.Config{}, logrus.New())
+	_, err := client.ExecuteRequest(context.Background(), tool, map[string]interface{}{"term": "a b&c", "user": "alice"})
+	require.NoError(t, err)
+	require.NotNil(t, got)
+
+	user, pass, ok := got.BasicAuth()
+	require.True(t, ok)
+	assert.Equal(t, "alice", user)
+	assert.Equal(t, "p@ss", pass)
+	assert.Equal(t, "a b&c", got.URL.Query().Get("q"))
+	assert.Equal(t, "10", got.URL.Query().Get("limit"))
+}
+
+func TestHTTPClientSessionCookies(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "abc123", Path: "/"})
+			http.Redirect(w, r, "/welcome", http.StatusFound)
+		case "/welcome":
+			io.WriteString(w, `{}`)
+		case "/me":
+			if c, err := r.Cookie("sid"); err == nil && c.Value == "abc123" {
+				io.WriteString(w, `{"user":"alice"}`)
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{Tools: []config.ToolConfig{
+		{Name: "login", Endpoint: upstream.URL + "/login", Method: "POST", Session: "app"},
+		{Name: "me", Endpoint: upstream.URL + "/me", Method: "GET", Session: "app"},
+		{Name: "anonymous_me", Endpoint: upstream.URL + "/me", Method: "GET"},
+	}}
+	client := handlers.NewHTTPClient(cfg, logrus.New())
+
+	_, err := client.ExecuteRequest(context.Background(), &cfg.Tools[0], map[string]interface{}{})
+	require.NoError(t, err)
+
+	resp, err := client.ExecuteRequest(context.Background(), &cfg.Tools[1], map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Tools outside the session don't see its cookies
+	resp, err = client.ExecuteRequest(context.Background(), &cfg.Tools[2], map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	assert.Equal(t, 1, client.FlushState("me").SessionsReset)
+	resp, err = client.ExecuteRequest(context.Background(), &cfg.Tools[1], map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestHTTPClientResponseHeaderTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		io.WriteString(w, `{}`)
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{Runtime: config.RuntimeConfig{
+		HTTPClient: config.HTTPClientConfig{ResponseHeaderTimeout: config.Duration(50 * time.Millisecond)},
+	}}
+	tool := &config.ToolConfig{Name: "slow", Endpoint: upstream.URL, Method: "GET"}
+
+	_, err := handlers.NewHTTPClient(cfg, logrus.New()).ExecuteRequest(context.Background(), tool, map[string]interface{}{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout awaiting response headers")
+}
+
+func TestHTTPClientParameterLocations(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		io.WriteString(w, `{}`)
+	}))
+	defer upstream.Close()
+
+	tool := &config.ToolConfig{
+		Name:     "add_member",
+		Endpoint: upstream.URL + "/orgs/{org}/members",
+		Method:   "POST",
+		Parameters: []config.ParameterConfig{
+			{Name: "org", In: "path", Type: "string"},
+			{Name: "dry_run", In: "query", Type: "boolean"},
+			{Name: "X-Tenant-ID", In: "header", Type: "string"},
+			{Name: "email", Type: "string"},
+		},
+	}
+
+	client := handlers.NewHTTPClient(&config.Config{}, logrus.New())
+	_, err := client.ExecuteRequest(context.Background(), tool, map[string]interface{}{
+		"org": "acme/labs", "dry_run": true, "X-Tenant-ID": "t-1", "email": "a@example.com",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, got)
+
+	assert.Equal(t, "/orgs/acme%2Flabs/members", got.URL.EscapedPath())
+	assert.Equal(t, "dry_run=true", got.URL.RawQuery)
+	assert.Equal(t, "t-1", got.Header.Get("X-Tenant-ID"))
+	assert.JSONEq(t, `{"email": "a@example.com"}`, gotBody)
+
+	_, err = client.ExecuteRequest(context.Background(), tool, map[string]interface{}{"email": "a@example.com"})
+	require.ErrorContains(t, err, "path parameter org is missing")
+}
+
+func TestHTTPClientFormEncodedBody(t *testing.T) {
+	var got *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		got = r
+		io.WriteString(w, `{}`)
+	}))
+	defer upstream.Close()
+
+	tool := &config.ToolConfig{
+		Name:        "token",
+		Endpoint:    upstream.URL + "/oauth/token",
+		Method:      "POST",
+		ContentType: "application/x-www-form-urlencoded; charset=utf-8",
+	}
+
+	client := handlers.NewHTTPClient(&config.Config{}, logrus.New())
+	_, err := client.ExecuteRequest(context.Background(), tool, map[string]interface{}{
+		"grant_type": "client_credentials",
+		"scope":      []interface{}{"read", "write"},
+		"note":       "a&b=c",
+		"ttl":        float64(1000000),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, got)
+
+	// Parameters such as charset don't change the encoding
+	assert.Equal(t, "application/x-www-form-urlencoded; charset=utf-8", got.Header.Get("Content-Type"))
+	assert.Positive(t, got.ContentLength)
+	assert.Equal(t, "client_credentials", got.PostForm.Get("grant_type"))
+	assert.Equal(t, []string{"read", "write"}, got.PostForm["scope"])
+	assert.Equal(t, "a&b=c", got.PostForm.Get("note"))
+	assert.Equal(t, "1000000", got.PostForm.Get("ttl"))
+}
+
+func TestHTTPClientQueryStyles(t *testing.T) {
+	var got *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		io.WriteString(w, `{}`)
+	}))
+	defer upstream.Close()
+
+	tool := &config.ToolConfig{
+		Name:     "search",
+		Endpoint: upstream.URL + "/issues",
+		Method:   "GET",
+		Parameters: []config.ParameterConfig{
+			{Name: "label", Type: "array"},
+			{Name: "state", Type: "array", Style: "comma"},
+			{Name: "id", Type: "array", Style: "brackets"},
+			{Name: "filter", Type: "object"},
+			{Name: "limit", Type: "number"},
+		},
+	}
+
+	client := handlers.NewHTTPClient(&config.Config{}, logrus.New())
+	_, err := client.ExecuteRequest(context.Background(), tool, map[string]interface{}{
+		"label":  []interface{}{"bug", "ui"},
+		"state":  []interface{}{"open", "closed"},
+		"id":     []interface{}{float64(1), float64(2)},
+		"filter": map[string]interface{}{"owner": "me", "since": "2024-01-01"},
+		"limit":  float64(1000000),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, got)
+
+	query := got.URL.Query()
+	assert.Equal(t, []string{"bug", "ui"}, query["label"])
+	assert.Equal(t, "open,closed", query.Get("state"))
+	assert.Equal(t, []string{"1", "2"}, query["id[]"])
+	assert.Equal(t, "me", query.Get("filter[owner]"))
+	assert.Equal(t, "2024-01-01", query.Get("filter[since]"))
+	assert.Equal(t, "1000000", query.Get("limit"))
+}
+
+func TestHTTPClientCompression(t *testing.T) {
+	var gotEncoding, gotAccept string
+	var gotBody []byte
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEncoding = r.Header.Get("Content-Encoding")
+		gotAccept = r.Header.Get("Accept-Encoding")
+		var body io.Reader = r.Body
+		if gotEncoding == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = zr
+		}
+		gotBody, _ = io.ReadAll(body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "deflate")
+		zw := zlib.NewWriter(w)
+		io.WriteString(zw, `{"accepted":true}`)
+		zw.Close()
+	}))
+	defer upstream.Close()
+
+	tool := &config.ToolConfig{
+		Name:        "ingest",
+		Endpoint:    upstream.URL + "/reports",
+		Method:      "POST",
+		Compression: &config.CompressionConfig{RequestGzip: true, MinRequestBytes: 100, AcceptEncoding: "deflate"},
+	}
+	client := handlers.NewHTTPClient(&config.Config{}, logrus.New())
+
+	report := strings.Repeat("line of report data\n", 50)
+	resp, err := client.ExecuteRequest(context.Background(), tool, map[string]interface{}{"report": report})
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", gotEncoding)
+	assert.Equal(t, "deflate", gotAccept)
+	assert.JSONEq(t, `{"report":`+strconv.Quote(report)+`}`, string(gotBody))
+	assert.Equal(t, map[string]interface{}{"accepted": true}, resp.Data)
+
+	// Bodies under the threshold go out uncompressed
+	_, err = client.ExecuteRequest(context.Background(), tool, map[string]interface{}{"report": "short"})
+	require.NoError(t, err)
+	assert.Empty(t, gotEncoding)
+	assert.JSONEq(t, `{"report":"short"}`, string(gotBody))
+}
+
+func TestHTTPClientResponseEncodings(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/brotli":
+			w.Header().Set("Content-Encoding", "br")
+			io.WriteString(w, "plain text")
+		case "/empty":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer upstream.Close()
+	client := handlers.NewHTTPClient(&config.Config{}, logrus.New())
+
+	// Encodings the tool didn't ask for are passed through as sent
+	tool := &config.ToolConfig{Name: "brotli", Endpoint: upstream.URL + "/brotli", Method: "GET"}
+	resp, err := client.ExecuteRequest(context.Background(), tool, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "plain text", resp.Body)
+
+	tool.Compression = &config.CompressionConfig{AcceptEncoding: "gzip"}
+	_, err = client.ExecuteRequest(context.Background(), tool, nil)
+	assert.ErrorContains(t, err, `unsupported Content-Encoding "br"`)
+
+	// Empty bodies have nothing to decode
+	for _, method := range []string{"GET", "HEAD"} {
+		tool := &config.ToolConfig{Name: "empty", Endpoint: upstream.URL + "/empty", Method: method,
+			Compression: &config.CompressionConfig{AcceptEncoding: "gzip"}}
+		_, err := client.ExecuteRequest(context.Background(), tool, nil)
+		assert.NoError(t, err, method)
+	}
+}
+
+func TestHTTPClientUserAgent(t *testing.T) {
+	var got string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+		io.WriteString(w, `{}`)
+	}))
+	defer upstream.Close()
+
+	cfg, err := config.Parse([]byte(`{"server": {"name": "Acme Tools", "version": "2.1.0"}}`))
+	require.NoError(t, err)
+	client := handlers.NewHTTPClient(cfg, logrus.New())
+
+	tool := &config.ToolConfig{Name: "ua", Endpoint: upstream.URL, Method: "GET"}
+	_, err = client.ExecuteRequest(context.Background(), tool, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Acme-Tools/2.1.0", got)
+
+	// A tool's own header replaces the default
+	tool.Headers = map[string]string{"user-agent": "reports-bot/3"}
+	_, err = client.ExecuteRequest(context.Background(), tool, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "reports-bot/3", got)
+}
+
+func TestHTTPClientHeaderPrecedence(t *testing.T) {
+	var got *http.Request
+	var body string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+		io.WriteString(w, `{}`)
+	}))
+	defer upstream.Close()
+
+	client := handlers.NewHTTPClient(&config.Config{}, logrus.New())
+	tool := &config.ToolConfig{
+		Name:        "submit",
+		Endpoint:    upstream.URL,
+		Method:      "POST",
+		ContentType: "application/json",
+		Headers: map[string]string{
+			"content-type": "application/x-www-form-urlencoded; charset=utf-8",
+			"Accept":       "application/xml",
+			"X-Tenant":     "configured",
+		},
+		Parameters: []config.ParameterConfig{
+			{Name: "X-Tenant", Type: "string", In: "header"},
+			{Name: "name", Type: "string"},
+		},
+	}
+	_, err := client.ExecuteRequest(context.Background(), tool, map[string]interface{}{"X-Tenant": "argument", "name": "a b"})
+	require.NoError(t, err)
+
+	// The configured Content-Type is sent and decides the body encoding
+	assert.Equal(t, "application/x-www-form-urlencoded; charset=utf-8", got.Header.Get("Content-Type"))
+	assert.Equal(t, "name=a+b", body)
+	assert.Equal(t, "application/xml", got.Header.Get("Accept"))
+	assert.Equal(t, "configured", got.Header.Get("X-Tenant"))
+}
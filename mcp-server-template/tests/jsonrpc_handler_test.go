@@ -0,0 +1,433 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolsCallCancelledWhenClientDisconnects(t *testing.T) {
+	upstreamCalled := make(chan struct{})
+	upstreamCancelled := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(upstreamCalled)
+		select {
+		case <-r.Context().Done():
+			close(upstreamCancelled)
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "cancel-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{
+				Name:        "slow_tool",
+				Description: "Never answers in time",
+				Endpoint:    upstream.URL,
+				Method:      "GET",
+				Timeout:     durationPtr(30 * time.Second),
+			},
+		},
+	}
+
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("cancel-test", "1.0.0"), cfg.Tools))
+	handler := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow_tool","arguments":{}}}`
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)).WithContext(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+
+	select {
+	case <-upstreamCalled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream was never called")
+	}
+
+	// Simulate the client hanging up mid-call
+	cancel()
+
+	select {
+	case <-upstreamCancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream request was not cancelled after client disconnect")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler did not return after client disconnect")
+	}
+}
+
+func TestCompletionCompleteFiltersEnumValues(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "completion-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{
+				Name:        "weather",
+				Description: "Current weather",
+				Endpoint:    "http://example.invalid",
+				Method:      "GET",
+				Parameters: []config.ParameterConfig{
+					{
+						Name:        "units",
+						Type:        "string",
+						Description: "Unit system",
+						Validation:  &config.ParameterValidation{Enum: []string{"metric", "imperial", "Mixed"}},
+					},
+				},
+			},
+		},
+	}
+
+	handler := handlers.NewJSONRPCHandler(cfg, handlers.NewToolHandler(cfg, logrus.New()), handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"completion/complete","params":{"ref":{"type":"ref/tool","name":"weather"},"argument":{"name":"units","value":"m"}}}`
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+
+	var resp struct {
+		Result struct {
+			Completion struct {
+				Values  []string `json:"values"`
+				Total   int      `json:"total"`
+				HasMore bool     `json:"hasMore"`
+			} `json:"completion"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, []string{"metric", "Mixed"}, resp.Result.Completion.Values)
+	require.Equal(t, 2, resp.Result.Completion.Total)
+	require.False(t, resp.Result.Completion.HasMore)
+}
+
+func TestToolsCallReportsStructuredError(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "error-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{Name: "flaky", Description: "Unavailable upstream", Endpoint: upstream.URL, Method: "GET"},
+		},
+	}
+
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("error-test", "1.0.0"), cfg.Tools))
+	handler := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"flaky","arguments":{}}}`
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+
+	var resp struct {
+		Result struct {
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+			IsError bool `json:"isError"`
+		} `json:"result"`
+		Error interface{} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Nil(t, resp.Error)
+	require.True(t, resp.Result.IsError)
+	require.Len(t, resp.Result.Content, 2)
+	require.Contains(t, resp.Result.Content[0].Text, "HTTP Error 503")
+
+	var toolErr handlers.ToolError
+	require.NoError(t, json.Unmarshal([]byte(resp.Result.Content[1].Text), &toolErr))
+	require.Equal(t, "upstream_status", toolErr.Code)
+	require.True(t, toolErr.Retryable)
+	require.Equal(t, http.StatusServiceUnavailable, toolErr.UpstreamStatus)
+}
+
+func TestOversizedRequestBodyIsRejected(t *testing.T) {
+	cfg := &config.Config{
+		Server:  config.ServerConfig{Name: "limit-test", Version: "1.0.0"},
+		Runtime: config.RuntimeConfig{MaxRequestBytes: 64},
+	}
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	handler := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"ping","params":{"padding":"` + strings.Repeat("x", 100) + `"}}`
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	var resp struct {
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, -32600, resp.Error.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestNotificationsGetNoResponse(t *testing.T) {
+	cfg := &config.Config{Server: config.ServerConfig{Name: "notify-test", Version: "1.0.0"}}
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	handler := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","method":"initialized"}`,
+		`{"jsonrpc":"2.0","method":"notifications/unknown"}`,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+		require.Equal(t, http.StatusNoContent, rec.Code, body)
+		require.Empty(t, rec.Body.String(), body)
+	}
+
+	// An explicit null id is a request, not a notification, and is answered
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":null,"method":"ping"}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"result"`)
+}
+
+func TestMalformedParamsAreInvalidParams(t *testing.T) {
+	cfg := &config.Config{Server: config.ServerConfig{Name: "params-test", Version: "1.0.0"}}
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	handler := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"clientInfo":"not-an-object"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"greet","arguments":["a","b"]}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":42}}`,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+
+		var resp struct {
+			Error struct {
+				Code int    `json:"code"`
+				Data string `json:"data"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), body)
+		require.Equal(t, -32602, resp.Error.Code, body)
+		require.Contains(t, resp.Error.Data, "cannot unmarshal", body)
+	}
+}
+
+func TestToolsListFiltersByTagAndEnabled(t *testing.T) {
+	disabled := false
+	cfg := &config.Config{
+		Server:  config.ServerConfig{Name: "filter-test", Version: "1.0.0"},
+		Runtime: config.RuntimeConfig{ToolTags: []string{"billing", "support"}},
+		Tools: []config.ToolConfig{
+			{Name: "invoice", Description: "Get an invoice", Endpoint: "http://example.com", Method: "GET", Tags: []string{"billing"}},
+			{Name: "ticket", Description: "Open a ticket", Endpoint: "http://example.com", Method: "POST", Tags: []string{"support"}},
+			{Name: "refund", Description: "Refund a charge", Endpoint: "http://example.com", Method: "POST", Tags: []string{"billing"}, Enabled: &disabled},
+			{Name: "deploy", Description: "Deploy a build", Endpoint: "http://example.com", Method: "POST", Tags: []string{"ops"}},
+		},
+	}
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("filter-test", "1.0.0"), cfg.Tools))
+	handler := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	list := func(body string) []string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+		var resp struct {
+			Result struct {
+				Tools []struct {
+					Name string `json:"name"`
+				} `json:"tools"`
+			} `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		var names []string
+		for _, tool := range resp.Result.Tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+
+	require.Equal(t, []string{"invoice", "ticket"}, list(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	require.Equal(t, []string{"ticket"}, list(`{"jsonrpc":"2.0","id":2,"method":"tools/list","params":{"tags":["support"]}}`))
+
+	// Hidden tools stay callable by name
+	_, err := toolHandler.ExecuteTool(context.Background(), "refund", map[string]interface{}{"__dry_run": true})
+	require.NoError(t, err)
+}
+
+func TestCancelledNotificationAbortsToolCall(t *testing.T) {
+	upstreamCalled := make(chan struct{})
+	upstreamCancelled := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(upstreamCalled)
+		select {
+		case <-r.Context().Done():
+			close(upstreamCancelled)
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "cancel-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{Name: "slow_tool", Description: "Never answers in time", Endpoint: upstream.URL, Method: "GET", Timeout: durationPtr(30 * time.Second)},
+		},
+	}
+
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("cancel-test", "1.0.0"), cfg.Tools))
+	handler := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+	srv := serveStream(t, handler)
+
+	caller := dialStream(t, srv)
+	caller.Send("call-1", "tools/call", map[string]interface{}{"name": "slow_tool", "arguments": map[string]interface{}{}})
+	select {
+	case <-upstreamCalled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream was never called")
+	}
+
+	// Neither another connection nor a plain POST can cancel the call
+	cancel := map[string]interface{}{"requestId": "call-1", "reason": "user pressed stop"}
+	dialStream(t, srv).Send(nil, "notifications/cancelled", cancel)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(
+		`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"call-1"}}`)))
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+	select {
+	case <-upstreamCancelled:
+		t.Fatal("a different client cancelled the call")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	caller.Send(nil, "notifications/cancelled", cancel)
+	select {
+	case <-upstreamCancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream request was not cancelled")
+	}
+
+	// A cancelled request gets no response
+	for _, msg := range caller.Drain(500 * time.Millisecond) {
+		require.NotNil(t, msg["method"], "unexpected response %v", msg)
+	}
+}
+
+func TestToolCallCancelledWhileQueued(t *testing.T) {
+	release := make(chan struct{})
+	upstreamCalled := make(chan struct{}, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalled <- struct{}{}
+		<-release
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	cfg := &config.Config{
+		Server:  config.ServerConfig{Name: "queue-test", Version: "1.0.0"},
+		Runtime: config.RuntimeConfig{MaxConcurrentRequests: 1},
+		Tools: []config.ToolConfig{
+			{Name: "slow_tool", Description: "Holds the only slot", Endpoint: upstream.URL, Method: "GET", Timeout: durationPtr(30 * time.Second)},
+		},
+	}
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("queue-test", "1.0.0"), cfg.Tools))
+	handler := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	call := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow_tool","arguments":{}}}`)
+	go handler.HandleMessage(context.Background(), call)
+	select {
+	case <-upstreamCalled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream was never called")
+	}
+
+	// A caller that gives up while queued is told so rather than that the server is busy
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	resp := handler.HandleMessage(ctx, call).(*handlers.JSONRPCResponse)
+	require.NotNil(t, resp.Error)
+	require.Equal(t, -32800, resp.Error.Code)
+	require.Equal(t, "Request cancelled", resp.Error.Message)
+}
+
+func TestDrainCancelsToolCallsBeforeDeadline(t *testing.T) {
+	upstreamCalled := make(chan struct{}, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalled <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "drain-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{Name: "slow_tool", Description: "Outlives the deadline", Endpoint: upstream.URL, Method: "GET", Timeout: durationPtr(30 * time.Second)},
+		},
+	}
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("drain-test", "1.0.0"), cfg.Tools))
+	handler := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	call := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow_tool","arguments":{}}}`)
+	responses := make(chan *handlers.JSONRPCResponse, 1)
+	go func() { responses <- handler.HandleMessage(context.Background(), call).(*handlers.JSONRPCResponse) }()
+	select {
+	case <-upstreamCalled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream was never called")
+	}
+
+	// The running call is cancelled ahead of the deadline, so it can still answer
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	require.Equal(t, 0, handler.Drain(ctx))
+	require.Less(t, time.Since(start), time.Second)
+	require.NoError(t, ctx.Err())
+
+	select {
+	case resp := <-responses:
+		require.Equal(t, true, resp.Result.(map[string]interface{})["isError"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled tool call never answered")
+	}
+
+	// Calls arriving after the drain are refused
+	require.True(t, handler.Draining())
+	resp := handler.HandleMessage(context.Background(), call).(*handlers.JSONRPCResponse)
+	require.NotNil(t, resp.Error)
+	require.Equal(t, "Server shutting down", resp.Error.Message)
+}
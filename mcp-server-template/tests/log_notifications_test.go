@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogNotificationsArePerConnection(t *testing.T) {
+	cfg := &config.Config{Server: config.ServerConfig{Name: "log-test", Version: "1.0.0"}}
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+	rpc := handlers.NewJSONRPCHandler(cfg, handlers.NewToolHandler(cfg, logger), handlers.NewResourceLoader(cfg, logger), logger)
+	srv := serveStream(t, rpc)
+
+	quiet := dialStream(t, srv)
+	verbose := dialStream(t, srv)
+
+	resp, _ := quiet.Call(101, "logging/setLevel", map[string]string{"level": "error"})
+	require.Nil(t, resp["error"])
+	resp, _ = quiet.Call(102, "ping", nil)
+	require.Nil(t, resp["error"])
+
+	// A client's level only applies to its own notifications
+	assert.Equal(t, logrus.DebugLevel, logger.GetLevel())
+
+	resp, _ = verbose.Call(1, "logging/setLevel", map[string]string{"level": "debug"})
+	require.Nil(t, resp["error"])
+	_, early := verbose.Call(7, "ping", nil)
+	logger.Info("server record with no request")
+	received := append(early, verbose.Drain(300*time.Millisecond)...)
+
+	var sawPing bool
+	for _, msg := range received {
+		require.Equal(t, "notifications/message", msg["method"])
+		data := msg["params"].(map[string]interface{})["data"].(map[string]interface{})
+		assert.NotEqual(t, "server record with no request", data["message"])
+		// The quiet client's requests never show up here
+		assert.NotContains(t, []interface{}{101.0, 102.0}, data["id"])
+		sawPing = sawPing || (data["method"] == "ping" && data["id"] == 7.0)
+	}
+	assert.True(t, sawPing, "the verbose client gets its own debug records")
+	assert.Empty(t, quiet.Drain(300*time.Millisecond))
+
+	// Unknown levels are still rejected
+	other := dialStream(t, srv)
+	resp, _ = other.Call(1, "logging/setLevel", map[string]string{"level": "verbose"})
+	require.NotNil(t, resp["error"])
+}
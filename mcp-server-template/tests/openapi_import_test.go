@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+	"mcp-server-template/internal/openapi"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+const petstoreSpec = `
+openapi: 3.0.3
+info:
+  title: Pet Store
+  version: "2.1"
+servers:
+  - url: https://petstore.example.com/v1
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets
+      parameters:
+        - name: limit
+          in: query
+          schema: {type: integer}
+        - name: status
+          in: query
+          schema: {type: string, enum: [available, sold]}
+  /pets/{pet-id}/visits:
+    parameters:
+      - name: pet-id
+        in: path
+        required: true
+        schema: {type: string}
+    post:
+      operationId: createVisit
+      summary: Book a vet visit
+      parameters:
+        - $ref: '#/components/parameters/Notify'
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/Visit'}
+components:
+  parameters:
+    Notify:
+      name: notify
+      in: query
+      schema: {type: boolean}
+  schemas:
+    Visit:
+      type: object
+      required: [date]
+      properties:
+        date: {type: string, description: Visit date}
+        reasons:
+          type: array
+          items: {type: string}
+`
+
+func TestOpenAPIImportGeneratesValidTools(t *testing.T) {
+	cfg, err := openapi.Import([]byte(petstoreSpec), openapi.Options{})
+	require.NoError(t, err)
+
+	// The written file must load and validate like a hand-written one
+	data, err := json.Marshal(map[string]interface{}{"server": cfg.Server, "tools": cfg.Tools})
+	require.NoError(t, err)
+	loaded, err := config.Parse(data)
+	require.NoError(t, err)
+	require.NoError(t, config.Validate(loaded))
+
+	require.Equal(t, "pet-store", cfg.Server.Name)
+	require.Equal(t, "2.1.0", cfg.Server.Version)
+	require.Len(t, cfg.Tools, 2)
+
+	list := cfg.Tools[0]
+	require.Equal(t, "list_pets", list.Name)
+	require.Equal(t, "GET", list.Method)
+	require.Equal(t, "https://petstore.example.com/v1/pets", list.Endpoint)
+	require.Equal(t, "number", list.Parameters[0].Type)
+	require.Equal(t, "query", list.Parameters[0].In)
+	require.Equal(t, []string{"available", "sold"}, list.Parameters[1].Validation.Enum)
+
+	visit := cfg.Tools[1]
+	require.Equal(t, "create_visit", visit.Name)
+	require.Equal(t, "Book a vet visit", visit.Description)
+	require.Equal(t, "application/json", visit.ContentType)
+	names := make(map[string]bool)
+	for _, p := range visit.Parameters {
+		names[p.Name] = p.Required
+	}
+	require.Equal(t, map[string]bool{"notify": false, "pet-id": true, "date": true, "reasons": false}, names)
+}
+
+func TestOpenAPIImportedToolBuildsRequest(t *testing.T) {
+	var gotPath, gotQuery string
+	var gotBody map[string]interface{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &gotBody)
+		io.WriteString(w, `{}`)
+	}))
+	defer upstream.Close()
+
+	cfg, err := openapi.Import([]byte(petstoreSpec), openapi.Options{BaseURL: upstream.URL})
+	require.NoError(t, err)
+
+	_, err = handlers.NewHTTPClient(&config.Config{}, logrus.New()).ExecuteRequest(context.Background(), &cfg.Tools[1], map[string]interface{}{
+		"pet-id": "rex", "notify": true, "date": "2024-05-01",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "/pets/rex/visits", gotPath)
+	require.Equal(t, "notify=true", gotQuery)
+	require.Equal(t, map[string]interface{}{"date": "2024-05-01"}, gotBody)
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+
+	"github.com/gorilla/websocket"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolsCallReportsPageProgress(t *testing.T) {
+	var upstream *httptest.Server
+	upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "", "1":
+			fmt.Fprintf(w, `{"items":[1],"next":"%s/items?page=2"}`, upstream.URL)
+		case "2":
+			fmt.Fprintf(w, `{"items":[2],"next":"%s/items?page=3"}`, upstream.URL)
+		default:
+			fmt.Fprint(w, `{"items":[3]}`)
+		}
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "progress-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{{
+			Name:        "list_items",
+			Description: "Lists every item",
+			Endpoint:    upstream.URL + "/items",
+			Method:      "GET",
+			Pagination:  &config.PaginationConfig{ItemsPath: "items", NextPath: "next"},
+		}},
+	}
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("test", "1.0.0"), cfg.Tools))
+	rpc := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+	srv := httptest.NewServer(handlers.NewWebSocketHandler(rpc))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"list_items","_meta":{"progressToken":"tok"}}}`)))
+
+	// The response and notifications are written independently, so either may come first
+	var progress []float64
+	responded := false
+	for !responded || len(progress) < 3 {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		var msg map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&msg))
+		if msg["method"] == "notifications/progress" {
+			params := msg["params"].(map[string]interface{})
+			require.Equal(t, "tok", params["progressToken"])
+			progress = append(progress, params["progress"].(float64))
+			continue
+		}
+		if msg["method"] != nil {
+			continue // log notifications
+		}
+		require.EqualValues(t, 7, msg["id"])
+		require.Nil(t, msg["error"])
+		responded = true
+	}
+	require.Equal(t, []float64{1, 2, 3}, progress)
+}
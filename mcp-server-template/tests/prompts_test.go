@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// getPrompt sends prompts/get and returns the decoded response
+func getPrompt(t *testing.T, cfg *config.Config, params string) map[string]interface{} {
+	t.Helper()
+
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	handler := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":` + params + `}`
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestPromptContentFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "review.md")
+	require.NoError(t, os.WriteFile(path, []byte("# Review\n\nCheck the {language} code for bugs."), 0o600))
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "prompt-test", Version: "1.0.0"},
+		Prompts: []config.PromptConfig{
+			{Name: "review", Description: "Code review", FilePath: path,
+				Arguments: []config.ArgumentConfig{{Name: "language", Description: "Language"}}},
+		},
+	}
+
+	resp := getPrompt(t, cfg, `{"name":"review","arguments":{"language":"Go"}}`)
+	require.Nil(t, resp["error"])
+	messages := resp["result"].(map[string]interface{})["messages"].([]interface{})
+	text := messages[0].(map[string]interface{})["content"].(map[string]interface{})["text"]
+	require.Equal(t, "# Review\n\nCheck the Go code for bugs.", text)
+}
+
+func TestPromptGoTemplate(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "prompt-test", Version: "1.0.0"},
+		Prompts: []config.PromptConfig{
+			{Name: "greet", Description: "Greeting",
+				Content: `Hello {{.name | default "there"}}.{{if .verbose}} Explain each step.{{end}} {keep}`,
+				Arguments: []config.ArgumentConfig{
+					{Name: "name", Description: "Who to greet"},
+					{Name: "verbose", Description: "Ask for detail"},
+				}},
+			{Name: "typo", Description: "Unknown reference", Content: "Hi {{.nmae}}"},
+		},
+	}
+
+	text := func(resp map[string]interface{}) interface{} {
+		require.Nil(t, resp["error"])
+		messages := resp["result"].(map[string]interface{})["messages"].([]interface{})
+		return messages[0].(map[string]interface{})["content"].(map[string]interface{})["text"]
+	}
+
+	require.Equal(t, "Hello there. {keep}", text(getPrompt(t, cfg, `{"name":"greet"}`)))
+	require.Equal(t, "Hello Ada. Explain each step. {keep}", text(getPrompt(t, cfg, `{"name":"greet","arguments":{"name":"Ada","verbose":"yes"}}`)))
+
+	resp := getPrompt(t, cfg, `{"name":"typo"}`)
+	require.EqualValues(t, -32602, resp["error"].(map[string]interface{})["code"])
+}
+
+func TestPromptTemplatesCannotReadEnvironment(t *testing.T) {
+	t.Setenv("PROMPT_TEST_API_KEY", "sk-secret")
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "prompt-test", Version: "1.0.0"},
+		Prompts: []config.PromptConfig{
+			{Name: "leak_env", Description: "Reads a variable", Content: `{{env "PROMPT_TEST_API_KEY"}}`},
+			{Name: "leak_expand", Description: "Expands a variable", Content: `{{expandenv "$PROMPT_TEST_API_KEY"}}`},
+		},
+	}
+
+	for _, name := range []string{"leak_env", "leak_expand"} {
+		resp := getPrompt(t, cfg, `{"name":"`+name+`"}`)
+		require.NotNil(t, resp["error"], name)
+		require.NotContains(t, fmt.Sprint(resp), "sk-secret")
+	}
+}
+
+func TestPromptRequiresDeclaredArguments(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "prompt-test", Version: "1.0.0"},
+		Prompts: []config.PromptConfig{
+			{Name: "translate", Description: "Translate text", Content: "Translate {text} into {language}.",
+				Arguments: []config.ArgumentConfig{
+					{Name: "text", Description: "Text", Required: true},
+					{Name: "language", Description: "Target language", Required: true},
+					{Name: "tone", Description: "Tone"},
+				}},
+		},
+	}
+
+	resp := getPrompt(t, cfg, `{"name":"translate","arguments":{"tone":"formal"}}`)
+	rpcErr := resp["error"].(map[string]interface{})
+	require.EqualValues(t, -32602, rpcErr["code"])
+	require.Contains(t, rpcErr["data"], "missing required arguments: text, language")
+
+	resp = getPrompt(t, cfg, `{"name":"translate","arguments":{"text":"hola","language":"English"}}`)
+	require.Nil(t, resp["error"])
+}
+
+func TestPromptMessagesKeepRolesAndOrder(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "prompt-test", Version: "1.0.0"},
+		Prompts: []config.PromptConfig{
+			{Name: "classify", Description: "Classify a ticket",
+				Arguments: []config.ArgumentConfig{{Name: "ticket", Description: "Ticket text", Required: true}},
+				Messages: []config.PromptMessageConfig{
+					{Role: "system", Content: "Answer with one word."},
+					{Role: "user", Content: "I was charged twice"},
+					{Role: "assistant", Content: "billing"},
+					{Role: "user", Content: "{{.ticket}}"},
+				}},
+		},
+	}
+
+	resp := getPrompt(t, cfg, `{"name":"classify","arguments":{"ticket":"The app crashes"}}`)
+	require.Nil(t, resp["error"])
+	messages := resp["result"].(map[string]interface{})["messages"].([]interface{})
+	require.Len(t, messages, 4)
+
+	var roles, texts []string
+	for _, m := range messages {
+		message := m.(map[string]interface{})
+		roles = append(roles, message["role"].(string))
+		texts = append(texts, message["content"].(map[string]interface{})["text"].(string))
+	}
+	require.Equal(t, []string{"system", "user", "assistant", "user"}, roles)
+	require.Equal(t, "The app crashes", texts[3])
+}
+
+func TestPromptMessageEmbedsResource(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "prompt-test", Version: "1.0.0"},
+		Resources: []config.ResourceConfig{
+			{URI: "docs://runbook", Name: "Runbook", MimeType: "text/markdown", Content: "1. Deploy\n2. Verify"},
+		},
+		Prompts: []config.PromptConfig{
+			{Name: "follow", Description: "Follow the runbook",
+				Messages: []config.PromptMessageConfig{
+					{Role: "user", Resource: "docs://runbook"},
+					{Role: "user", Content: "Follow the steps above."},
+				}},
+		},
+	}
+
+	resp := getPrompt(t, cfg, `{"name":"follow"}`)
+	require.Nil(t, resp["error"])
+	messages := resp["result"].(map[string]interface{})["messages"].([]interface{})
+	require.Len(t, messages, 2)
+
+	content := messages[0].(map[string]interface{})["content"].(map[string]interface{})
+	require.Equal(t, "resource", content["type"])
+	resource := content["resource"].(map[string]interface{})
+	require.Equal(t, "docs://runbook", resource["uri"])
+	require.Equal(t, "text/markdown", resource["mimeType"])
+	require.Equal(t, "1. Deploy\n2. Verify", resource["text"])
+}
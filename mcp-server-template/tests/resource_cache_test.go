@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLResourceCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	var mu sync.Mutex
+	fetches := make(map[string]int)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches[r.URL.Path]++
+		mu.Unlock()
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{Server: config.ServerConfig{Name: "cache-test", Version: "1.0.0"}}
+	loader := handlers.NewResourceLoader(cfg, logrus.New())
+	load := func(page int) {
+		t.Helper()
+		_, err := loader.Load(context.Background(), &config.ResourceConfig{
+			URI:      fmt.Sprintf("docs://page/%d", page),
+			URL:      fmt.Sprintf("%s/%d", upstream.URL, page),
+			CacheTTL: config.Duration(time.Hour),
+		})
+		require.NoError(t, err)
+	}
+	fetched := func(page int) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return fetches[fmt.Sprintf("/%d", page)]
+	}
+
+	// Fill the cache, which holds 128 entries, then use page 0 again so page 1 is oldest
+	for page := 0; page < 128; page++ {
+		load(page)
+	}
+	load(0)
+	require.Equal(t, 1, fetched(0))
+
+	load(128)
+	load(0)
+	require.Equal(t, 1, fetched(0))
+	load(1)
+	require.Equal(t, 2, fetched(1))
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceSubscriptionNotifiesOnFileChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.txt")
+	require.NoError(t, os.WriteFile(path, []byte("green"), 0o600))
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "subscribe-test", Version: "1.0.0"},
+		Resources: []config.ResourceConfig{
+			{URI: "file://status", Name: "Status", MimeType: "text/plain", FilePath: path},
+		},
+	}
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	rpc := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+	srv := httptest.NewServer(handlers.NewWebSocketHandler(rpc))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	read := func() map[string]interface{} {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		var msg map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&msg))
+		return msg
+	}
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"file://status"}}`)))
+	resp := read()
+	require.Nil(t, resp["error"])
+	require.EqualValues(t, 1, resp["id"])
+
+	require.NoError(t, os.WriteFile(path, []byte("red"), 0o600))
+	notification := read()
+	require.Equal(t, "notifications/resources/updated", notification["method"])
+	params, _ := json.Marshal(notification["params"])
+	require.JSONEq(t, `{"uri":"file://status"}`, string(params))
+}
+
+func TestResourceSubscribeNeedsStream(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "subscribe-test", Version: "1.0.0"},
+		Resources: []config.ResourceConfig{
+			{URI: "docs://readme", Name: "Readme", MimeType: "text/plain", Content: "hi"},
+		},
+	}
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	rpc := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	resp := rpc.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"docs://readme"}}`)).(*handlers.JSONRPCResponse)
+	require.NotNil(t, resp.Error)
+	require.Equal(t, -32600, resp.Error.Code)
+
+	// Nor is the capability advertised without a stream
+	resp = rpc.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":2,"method":"initialize","params":{}}`)).(*handlers.JSONRPCResponse)
+	require.Nil(t, resp.Error)
+	resources := resp.Result.(map[string]interface{})["capabilities"].(map[string]interface{})["resources"]
+	require.Equal(t, false, resources.(map[string]interface{})["subscribe"])
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+	"mcp-server-template/internal/server"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisallowedOriginsAreRefused(t *testing.T) {
+	ts, err := server.NewTestServer(&config.Config{
+		Server: config.ServerConfig{Name: "cors-test", Version: "1.0.0"},
+		Security: config.SecurityConfig{
+			EnableCORS:     true,
+			AllowedOrigins: []string{"https://app.example.com"},
+		},
+	})
+	require.NoError(t, err)
+	defer ts.Close()
+	ctx := context.Background()
+
+	// A simple cross-site request, which browsers send without a preflight
+	ts.Header.Set("Origin", "https://evil.example.com")
+	err = ts.Call(ctx, "tools/list", nil, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "HTTP 403")
+
+	ts.Header.Set("Origin", "https://app.example.com")
+	require.NoError(t, ts.Call(ctx, "tools/list", nil, nil))
+
+	// Non-browser clients send no Origin
+	ts.Header.Del("Origin")
+	require.NoError(t, ts.Call(ctx, "tools/list", nil, nil))
+}
+
+func TestWebSocketOriginCheck(t *testing.T) {
+	dial := func(security config.SecurityConfig, origin string) error {
+		cfg := &config.Config{Server: config.ServerConfig{Name: "ws-origin-test", Version: "1.0.0"}, Security: security}
+		rpc := handlers.NewJSONRPCHandler(cfg, handlers.NewToolHandler(cfg, logrus.New()), nil, logrus.New())
+		srv := httptest.NewServer(handlers.NewWebSocketHandler(rpc))
+		defer srv.Close()
+
+		header := http.Header{}
+		if origin == "same" {
+			origin = srv.URL
+		}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), header)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	// Without CORS configured only same-origin pages may connect
+	require.Error(t, dial(config.SecurityConfig{}, "https://evil.example.com"))
+	require.NoError(t, dial(config.SecurityConfig{}, "same"))
+	require.NoError(t, dial(config.SecurityConfig{}, ""))
+
+	cors := config.SecurityConfig{EnableCORS: true, AllowedOrigins: []string{"https://app.example.com"}}
+	require.NoError(t, dial(cors, "https://app.example.com"))
+	require.Error(t, dial(cors, "https://evil.example.com"))
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/server"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerReadinessProbe(t *testing.T) {
+	cfg := &config.Config{Server: config.ServerConfig{Name: "probe-test", Version: "1.0.0"}}
+	srv, err := server.New(cfg)
+	require.NoError(t, err)
+
+	port := freePort(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Start(ctx, port) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = client.Get(fmt.Sprintf("http://127.0.0.1:%d/ready", port))
+		return err == nil
+	}, 5*time.Second, 50*time.Millisecond)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, "ready", body["status"])
+
+	capsResp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/capabilities", port))
+	require.NoError(t, err)
+	defer capsResp.Body.Close()
+	require.Equal(t, http.StatusOK, capsResp.StatusCode)
+
+	var caps struct {
+		ProtocolVersion string                 `json:"protocolVersion"`
+		Capabilities    map[string]interface{} `json:"capabilities"`
+		ServerInfo      map[string]string      `json:"serverInfo"`
+	}
+	require.NoError(t, json.NewDecoder(capsResp.Body).Decode(&caps))
+	require.NotEmpty(t, caps.ProtocolVersion)
+	require.Contains(t, caps.Capabilities, "tools")
+	require.Equal(t, "probe-test", caps.ServerInfo["name"])
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/server"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerServesHTTPSWithConfiguredCertificate(t *testing.T) {
+	certPath, keyPath, pool := writeSelfSignedCert(t)
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "tls-test", Version: "1.0.0"},
+		Security: config.SecurityConfig{
+			TLSCertPath: certPath,
+			TLSKeyPath:  keyPath,
+		},
+	}
+	srv, err := server.New(cfg)
+	require.NoError(t, err)
+
+	port := freePort(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Start(ctx, port) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	client := &http.Client{
+		Timeout:   2 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+	}
+	healthURL := fmt.Sprintf("https://localhost:%d/health", port)
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = client.Get(healthURL)
+		return err == nil
+	}, 5*time.Second, 50*time.Millisecond)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotNil(t, resp.TLS)
+	require.True(t, resp.TLS.HandshakeComplete)
+}
+
+func TestServerRejectsPartialTLSConfig(t *testing.T) {
+	certPath, _, _ := writeSelfSignedCert(t)
+
+	cfg := &config.Config{
+		Server:   config.ServerConfig{Name: "tls-test", Version: "1.0.0"},
+		Security: config.SecurityConfig{TLSCertPath: certPath},
+	}
+	srv, err := server.New(cfg)
+	require.NoError(t, err)
+
+	err = srv.Start(context.Background(), freePort(t))
+	require.ErrorContains(t, err, "must be set together")
+}
+
+func TestForwardedProtoOnlyFromTrustedProxies(t *testing.T) {
+	resourceMetadata := func(trusted []string) string {
+		cfg := &config.Config{
+			Server: config.ServerConfig{Name: "proxy-test", Version: "1.0.0"},
+			Security: config.SecurityConfig{
+				OAuth:          config.OAuthConfig{Enabled: true},
+				TrustedProxies: trusted,
+			},
+		}
+		ts, err := server.NewTestServer(cfg)
+		require.NoError(t, err)
+		defer ts.Close()
+
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/mcp", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		return resp.Header.Get("WWW-Authenticate")
+	}
+
+	require.Contains(t, resourceMetadata(nil), `resource_metadata="http://`)
+	require.Contains(t, resourceMetadata([]string{"10.0.0.0/8"}), `resource_metadata="http://`)
+	require.Contains(t, resourceMetadata([]string{"127.0.0.1"}), `resource_metadata="https://`)
+	require.Contains(t, resourceMetadata([]string{"127.0.0.0/8", "::1"}), `resource_metadata="https://`)
+
+	cfg, err := config.Parse([]byte(`{"server":{"name":"proxy-test","version":"1.0.0"},"security":{"trusted_proxies":["proxy.internal"]}}`))
+	require.NoError(t, err)
+	require.ErrorContains(t, config.Validate(cfg), "trusted_proxies")
+}
+
+// writeSelfSignedCert writes a localhost certificate and key to a temp dir and returns
+// their paths with a pool that trusts the certificate
+func writeSelfSignedCert(t *testing.T) (string, string, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "server.crt")
+	keyPath := filepath.Join(dir, "server.key")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return certPath, keyPath, pool
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+
+	"github.com/gorilla/websocket"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedResultsFeedLaterToolsOnSameConnection(t *testing.T) {
+	var mu sync.Mutex
+	var fetched []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/huge":
+			fmt.Fprintf(w, `{"blob":%q}`, strings.Repeat("x", 300<<10))
+			return
+		case r.Method == http.MethodPost:
+			fmt.Fprint(w, `{"user":{"id":"u42"}}`)
+			return
+		}
+		mu.Lock()
+		fetched = append(fetched, r.URL.Path)
+		mu.Unlock()
+		fmt.Fprint(w, `{"name":"Ada"}`)
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "context-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{Name: "create_user", Description: "Creates a user", Endpoint: upstream.URL + "/users", Method: "POST", SaveAs: map[string]string{"user_id": "user.id"}},
+			{Name: "get_user", Description: "Fetches the last created user", Endpoint: upstream.URL + "/users/{{.ctx.user_id}}", Method: "GET"},
+			{Name: "get_huge", Description: "Saves more than a connection may keep", Endpoint: upstream.URL + "/huge", Method: "GET", SaveAs: map[string]string{"blob": "blob"}},
+			{Name: "use_huge", Description: "Uses the oversized value", Endpoint: upstream.URL + "/echo/{{.ctx.blob}}", Method: "GET"},
+		},
+	}
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("test", "1.0.0"), cfg.Tools))
+	rpc := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+	srv := httptest.NewServer(handlers.NewWebSocketHandler(rpc))
+	defer srv.Close()
+
+	dial := func() *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		require.NoError(t, err)
+		return conn
+	}
+	call := func(conn *websocket.Conn, id int, tool string) map[string]interface{} {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":%q}}`, id, tool))))
+		for {
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+			var msg map[string]interface{}
+			require.NoError(t, conn.ReadJSON(&msg))
+			if msg["method"] != nil {
+				continue // log notifications
+			}
+			require.EqualValues(t, id, msg["id"])
+			require.Nil(t, msg["error"])
+			return msg["result"].(map[string]interface{})
+		}
+	}
+	failure := func(result map[string]interface{}) string {
+		require.Equal(t, true, result["isError"])
+		return fmt.Sprint(result["content"])
+	}
+
+	first := dial()
+	defer first.Close()
+	call(first, 1, "create_user")
+	call(first, 2, "get_user")
+
+	// Saved values are scoped to the connection that saved them, and a value that
+	// was never saved fails the call instead of being sent as "<no value>"
+	second := dial()
+	defer second.Close()
+	require.Contains(t, failure(call(second, 1, "get_user")), "ctx.user_id not set")
+
+	// A connection keeps only a bounded amount
+	call(second, 2, "get_huge")
+	require.Contains(t, failure(call(second, 3, "use_huge")), "ctx.blob not set")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"/users/u42"}, fetched)
+}
+
+func TestToolsCannotDeclareCtxParameter(t *testing.T) {
+	cfg, err := config.Parse([]byte(`{
+		"server": {"name": "context-test", "version": "1.0.0"},
+		"tools": [{"name": "spoof", "description": "d", "method": "GET", "endpoint": "https://api.example.com/{{.ctx.user_id}}",
+			"parameters": [{"name": "ctx", "type": "object"}]}]
+	}`))
+	require.NoError(t, err)
+	require.ErrorContains(t, config.Validate(cfg), "parameter named ctx")
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/server"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdioToolsListHidesFilteredTools(t *testing.T) {
+	disabled := false
+	cfg := &config.Config{
+		Server:  config.ServerConfig{Name: "stdio-test", Version: "1.0.0"},
+		Runtime: config.RuntimeConfig{ToolTags: []string{"billing"}},
+		Tools: []config.ToolConfig{
+			{Name: "invoice", Description: "Get an invoice", Endpoint: "http://example.com", Method: "GET", Tags: []string{"billing"}},
+			{Name: "refund", Description: "Refund a charge", Endpoint: "http://example.com", Method: "POST", Tags: []string{"billing"}, Enabled: &disabled},
+			{Name: "deploy", Description: "Deploy a build", Endpoint: "http://example.com", Method: "POST", Tags: []string{"ops"}},
+		},
+	}
+	s, err := server.New(cfg)
+	require.NoError(t, err)
+
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{}}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"refund","arguments":{"__dry_run":true}}}` + "\n")
+	var out bytes.Buffer
+	require.NoError(t, s.ServeStdio(context.Background(), in, &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var list struct {
+		Result struct {
+			Tools []struct {
+				Name string `json:"name"`
+			} `json:"tools"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &list))
+	var names []string
+	for _, tool := range list.Result.Tools {
+		names = append(names, tool.Name)
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{"invoice"}, names)
+
+	// Hidden tools stay callable by name
+	var call map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &call))
+	require.Nil(t, call["error"])
+	require.NotNil(t, call["result"])
+}
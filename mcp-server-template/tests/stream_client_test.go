@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-server-template/internal/handlers"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// streamClient is a WebSocket MCP client for tests of per-connection behaviour
+type streamClient struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+// serveStream serves rpc over WebSocket for the duration of the test
+func serveStream(t *testing.T, rpc *handlers.JSONRPCHandler) *httptest.Server {
+	srv := httptest.NewServer(handlers.NewWebSocketHandler(rpc))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// dialStream opens a WebSocket connection to srv, closed when the test ends
+func dialStream(t *testing.T, srv *httptest.Server) *streamClient {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return &streamClient{t: t, conn: conn}
+}
+
+// Send writes a request, or a notification when id is nil
+func (c *streamClient) Send(id interface{}, method string, params interface{}) {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if id != nil {
+		msg["id"] = id
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+	require.NoError(c.t, c.conn.WriteJSON(msg))
+}
+
+// Call sends a request and reads until its response, returning the response and the
+// notifications that arrived before it
+func (c *streamClient) Call(id int, method string, params interface{}) (map[string]interface{}, []map[string]interface{}) {
+	c.Send(id, method, params)
+	var notifications []map[string]interface{}
+	for {
+		msg, ok := c.read(5 * time.Second)
+		require.True(c.t, ok, "no response to %s", method)
+		if msg["method"] != nil {
+			notifications = append(notifications, msg)
+			continue
+		}
+		require.EqualValues(c.t, id, msg["id"], fmt.Sprintf("response to %s", method))
+		return msg, notifications
+	}
+}
+
+// Drain returns the notifications that arrive within wait. A timed out WebSocket read
+// can't be retried, so it must be the last read on the connection.
+func (c *streamClient) Drain(wait time.Duration) []map[string]interface{} {
+	var notifications []map[string]interface{}
+	deadline := time.Now().Add(wait)
+	for {
+		msg, ok := c.read(time.Until(deadline))
+		if !ok {
+			return notifications
+		}
+		notifications = append(notifications, msg)
+	}
+}
+
+func (c *streamClient) read(wait time.Duration) (map[string]interface{}, bool) {
+	require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(wait)))
+	_, data, err := c.conn.ReadMessage()
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return nil, false
+	}
+	require.NoError(c.t, err)
+	var msg map[string]interface{}
+	require.NoError(c.t, json.Unmarshal(data, &msg))
+	return msg, true
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+	"mcp-server-template/internal/server"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestServerRunsToolCalls(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"city":"` + r.URL.Query().Get("city") + `","temp":21}`))
+	}))
+	defer upstream.Close()
+
+	ts, err := server.NewTestServer(&config.Config{
+		Server: config.ServerConfig{Name: "harness-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{{
+			Name:        "get_weather",
+			Description: "Current weather for a city",
+			Endpoint:    upstream.URL,
+			Method:      "GET",
+			Parameters:  []config.ParameterConfig{{Name: "city", Type: "string", Required: true}},
+		}},
+	})
+	require.NoError(t, err)
+	defer ts.Close()
+	ctx := context.Background()
+
+	init, err := ts.Initialize(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "harness-test", init.ServerInfo.Name)
+
+	tools, err := ts.ListTools(ctx)
+	require.NoError(t, err)
+	require.Len(t, tools.Tools, 1)
+	require.Equal(t, "get_weather", tools.Tools[0].Name)
+
+	result, err := ts.CallTool(ctx, "get_weather", map[string]interface{}{"city": "Oslo"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	var weather struct {
+		City string  `json:"city"`
+		Temp float64 `json:"temp"`
+	}
+	require.NoError(t, server.DecodeResultJSON(result, &weather))
+	require.Equal(t, "Oslo", weather.City)
+
+	_, err = ts.CallTool(ctx, "no_such_tool", nil)
+	var rpcErr *handlers.JSONRPCError
+	require.True(t, errors.As(err, &rpcErr))
+	require.Equal(t, -32000, rpcErr.Code)
+}
+
+func TestLargeResponsesAreTruncatedOrChunked(t *testing.T) {
+	body := strings.Repeat("x", 200<<10)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`"` + body + `"`))
+	}))
+	defer upstream.Close()
+
+	ts, err := server.NewTestServer(&config.Config{
+		Server:  config.ServerConfig{Name: "large-test", Version: "1.0.0"},
+		Runtime: config.RuntimeConfig{MaxResponseBytes: 100 << 10},
+		Tools: []config.ToolConfig{
+			{Name: "dump", Description: "Huge body", Endpoint: upstream.URL, Method: "GET"},
+			{Name: "stream_dump", Description: "Huge body, streamed", Endpoint: upstream.URL, Method: "GET", Streaming: true},
+		},
+	})
+	require.NoError(t, err)
+	defer ts.Close()
+	ctx := context.Background()
+
+	result, err := ts.CallTool(ctx, "dump", nil)
+	require.NoError(t, err)
+	text := server.ResultText(result)
+	require.Less(t, len(text), 101<<10)
+	require.Contains(t, text, "[truncated: response exceeded 102400 bytes]")
+
+	result, err = ts.CallTool(ctx, "stream_dump", nil)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 2) // 100 KiB cut into 64 KiB pieces
+	require.True(t, strings.HasPrefix(server.ResultText(result), `"xxx`))
+}
+
+func TestExposedResponseHeadersAreAddedToResult(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/orders/981")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"status":"created"}`))
+	}))
+	defer upstream.Close()
+
+	ts, err := server.NewTestServer(&config.Config{
+		Server: config.ServerConfig{Name: "headers-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{{
+			Name:          "create_order",
+			Description:   "Creates an order",
+			Endpoint:      upstream.URL,
+			Method:        "POST",
+			ExposeHeaders: []string{"location", "X-RateLimit-Remaining", "X-Total-Count"},
+		}},
+	})
+	require.NoError(t, err)
+	defer ts.Close()
+
+	result, err := ts.CallTool(context.Background(), "create_order", nil)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 2)
+	headers := result.Content[1].(map[string]interface{})["text"].(string)
+	require.JSONEq(t, `{"headers":{"location":"/orders/981","X-RateLimit-Remaining":"42"}}`, headers)
+}
@@ -0,0 +1,311 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/handlers"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterToolsDeclaresObjectAndArrayParameters(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "schema-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{
+				Name:        "search",
+				Description: "Search with filters",
+				Endpoint:    "https://api.example.com/search",
+				Method:      "POST",
+				Parameters: []config.ParameterConfig{
+					{Name: "filters", Type: "object", Description: "Field filters", Required: true},
+					{Name: "tags", Type: "array", Description: "Tags to match", Default: []interface{}{"all"}},
+				},
+			},
+		},
+	}
+
+	mcpServer := server.NewMCPServer("schema-test", "1.0.0")
+	require.NoError(t, handlers.NewToolHandler(cfg, logrus.New()).RegisterTools(mcpServer, cfg.Tools))
+
+	resp := mcpServer.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	result, ok := resp.(mcp.JSONRPCResponse).Result.(mcp.ListToolsResult)
+	require.True(t, ok, "unexpected response %+v", resp)
+	require.Len(t, result.Tools, 1)
+
+	schema := result.Tools[0].InputSchema
+	require.Equal(t, map[string]interface{}{"type": "object", "description": "Field filters"}, schema.Properties["filters"])
+	require.Equal(t, map[string]interface{}{"type": "array", "description": "Tags to match", "default": []interface{}{"all"}}, schema.Properties["tags"])
+	require.Equal(t, []string{"filters"}, schema.Required)
+}
+
+func TestNumberEnumAndConstAreEnforced(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "enum-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{
+				Name:        "list",
+				Description: "List items",
+				Endpoint:    "http://127.0.0.1:1/items",
+				Method:      "GET",
+				Parameters: []config.ParameterConfig{
+					{Name: "page_size", Type: "number", Description: "Page size", Validation: &config.ParameterValidation{NumberEnum: []float64{10, 25}}},
+					{Name: "dry_run", Type: "boolean", Description: "Dry run", Validation: &config.ParameterValidation{Const: true}},
+				},
+			},
+		},
+	}
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("enum-test", "1.0.0"), cfg.Tools))
+	handler := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	call := func(args string) string {
+		body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"list","arguments":` + args + `}}`
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+		return rec.Body.String()
+	}
+
+	require.Contains(t, call(`{"page_size": 15}`), "value must be one of: [10 25]")
+	require.Contains(t, call(`{"page_size": 10, "dry_run": false}`), "value must be true")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)))
+	require.Contains(t, rec.Body.String(), `"enum":[10,25]`)
+	require.Contains(t, rec.Body.String(), `"const":true`)
+}
+
+func TestNestedParameterSchemasAreValidated(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "nested-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{
+				Name:        "create_order",
+				Description: "Create an order",
+				Endpoint:    "http://127.0.0.1:1/orders",
+				Method:      "POST",
+				Parameters: []config.ParameterConfig{
+					{
+						Name: "order", Type: "object", Description: "Order body", Required: true,
+						Properties: map[string]config.ParameterConfig{
+							"customer": {Type: "string", Description: "Customer ID", Required: true},
+							"lines": {Type: "array", Description: "Order lines", Items: &config.ParameterConfig{
+								Type: "object",
+								Properties: map[string]config.ParameterConfig{
+									"quantity": {Type: "number", Required: true, Validation: &config.ParameterValidation{MinValue: floatPtr(1)}},
+								},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("nested-test", "1.0.0"), cfg.Tools))
+	handler := handlers.NewJSONRPCHandler(cfg, toolHandler, handlers.NewResourceLoader(cfg, logrus.New()), logrus.New())
+
+	call := func(args string) string {
+		body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"create_order","arguments":` + args + `}}`
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+		return rec.Body.String()
+	}
+
+	require.Contains(t, call(`{"order": {"lines": []}}`), "required field customer is missing")
+	require.Contains(t, call(`{"order": {"customer": "c1", "lines": [{"quantity": 0}]}}`), "field lines: item 0: field quantity: number too small")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)))
+	var listed struct {
+		Result struct {
+			Tools []struct {
+				InputSchema struct {
+					Properties map[string]map[string]interface{} `json:"properties"`
+				} `json:"inputSchema"`
+			} `json:"tools"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	order := listed.Result.Tools[0].InputSchema.Properties["order"]
+	require.Equal(t, []interface{}{"customer"}, order["required"])
+	lines := order["properties"].(map[string]interface{})["lines"].(map[string]interface{})
+	require.Equal(t, "object", lines["items"].(map[string]interface{})["type"])
+}
+
+func floatPtr(v float64) *float64 { return &v }
+
+func durationPtr(d time.Duration) *config.Duration {
+	duration := config.Duration(d)
+	return &duration
+}
+
+func TestDryRunRendersRequestWithoutCallingUpstream(t *testing.T) {
+	called := false
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	defer upstream.Close()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "dry-run-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{
+				Name:         "create_issue",
+				Description:  "Create an issue",
+				Endpoint:     upstream.URL + "/repos/{{.repo}}/issues",
+				Method:       "POST",
+				ContentType:  "application/json",
+				BodyTemplate: `{"title": "{{.title}}"}`,
+				Auth:         &config.AuthConfig{Type: "bearer", Token: "s3cret"},
+				Parameters: []config.ParameterConfig{
+					{Name: "repo", Type: "string", Description: "Repository", Required: true},
+					{Name: "title", Type: "string", Description: "Title", Required: true},
+				},
+			},
+		},
+	}
+
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("dry-run-test", "1.0.0"), cfg.Tools))
+	result, err := toolHandler.ExecuteTool(context.Background(), "create_issue", map[string]interface{}{
+		"repo": "acme/app", "title": "Broken", "__dry_run": true,
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.False(t, called)
+
+	var rendered handlers.RenderedRequest
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &rendered))
+	require.Equal(t, "POST", rendered.Method)
+	require.Equal(t, upstream.URL+"/repos/acme/app/issues", rendered.URL)
+	require.Equal(t, `{"title": "Broken"}`, rendered.Body)
+	require.Equal(t, "***REDACTED***", rendered.Headers["Authorization"])
+	require.Equal(t, "application/json", rendered.Headers["Content-Type"])
+}
+
+func TestUpstreamErrorBodiesAreSummarized(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"code": 42, "message": "city not found"}, "trace": "..."}`))
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`<html><head><style>body{}</style></head><body><h1>502 Bad Gateway</h1><p>nginx</p></body></html>`))
+		}
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "error-body-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{Name: "json_error", Description: "JSON error", Endpoint: upstream.URL + "/json", Method: "GET"},
+			{Name: "html_error", Description: "HTML error", Endpoint: upstream.URL + "/html", Method: "GET"},
+		},
+	}
+
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("error-body-test", "1.0.0"), cfg.Tools))
+
+	result, err := toolHandler.ExecuteTool(context.Background(), "json_error", map[string]interface{}{})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Equal(t, "HTTP Error 400: city not found", result.Content[0].(mcp.TextContent).Text)
+
+	result, err = toolHandler.ExecuteTool(context.Background(), "html_error", map[string]interface{}{})
+	require.NoError(t, err)
+	require.Equal(t, "HTTP Error 502: 502 Bad Gateway nginx", result.Content[0].(mcp.TextContent).Text)
+
+	cfg.Runtime.VerboseErrors = true
+	result, err = toolHandler.ExecuteTool(context.Background(), "html_error", map[string]interface{}{})
+	require.NoError(t, err)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "<h1>502 Bad Gateway</h1>")
+}
+
+func TestTransformReshapesResponse(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"user": {"login": "octo", "id": 1}, "repos": [{"name": "a", "size": 3}, {"name": "b", "size": 5}]}`))
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "transform-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{Name: "summary", Description: "Summary", Endpoint: upstream.URL, Method: "GET",
+				Transform: `{login: .user.login, repos: [.repos[].name], total_size: ([.repos[].size] | add)}`},
+			{Name: "broken", Description: "Broken", Endpoint: upstream.URL, Method: "GET",
+				Transform: `.user.login | tonumber`},
+			{Name: "runaway", Description: "Never finishes", Endpoint: upstream.URL, Method: "GET",
+				Transform: `last(range(1e15))`},
+		},
+	}
+
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("transform-test", "1.0.0"), cfg.Tools))
+
+	result, err := toolHandler.ExecuteTool(context.Background(), "summary", map[string]interface{}{})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.JSONEq(t, `{"login": "octo", "repos": ["a", "b"], "total_size": 8}`, result.Content[0].(mcp.TextContent).Text)
+
+	result, err = toolHandler.ExecuteTool(context.Background(), "broken", map[string]interface{}{})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "transform failed")
+
+	// The program stops with the call's context
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	result, err = toolHandler.ExecuteTool(ctx, "runaway", map[string]interface{}{})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "context deadline exceeded")
+
+	cfg.Tools[0].Transform = `.repos[`
+	require.Error(t, handlers.NewToolHandler(cfg, logrus.New()).RegisterTools(server.NewMCPServer("transform-test", "1.0.0"), cfg.Tools))
+}
+
+func TestStringArgumentsAreCoercedToDeclaredTypes(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Name: "coercion-test", Version: "1.0.0"},
+		Tools: []config.ToolConfig{
+			{
+				Name:        "create_job",
+				Description: "Create a job",
+				Endpoint:    "http://example.invalid/jobs",
+				Method:      "POST",
+				Parameters: []config.ParameterConfig{
+					{Name: "replicas", Type: "number", Description: "Replicas"},
+					{Name: "paused", Type: "boolean", Description: "Start paused"},
+					{Name: "weights", Type: "array", Description: "Weights", Items: &config.ParameterConfig{Type: "number"}},
+				},
+			},
+		},
+	}
+
+	toolHandler := handlers.NewToolHandler(cfg, logrus.New())
+	require.NoError(t, toolHandler.RegisterTools(server.NewMCPServer("coercion-test", "1.0.0"), cfg.Tools))
+
+	result, err := toolHandler.ExecuteTool(context.Background(), "create_job", map[string]interface{}{
+		"replicas": " 3 ", "paused": "TRUE", "weights": []interface{}{"0.5", 1.5}, "__dry_run": true,
+	})
+	require.NoError(t, err)
+	var rendered handlers.RenderedRequest
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &rendered))
+	require.JSONEq(t, `{"replicas": 3, "paused": true, "weights": [0.5, 1.5]}`, rendered.Body)
+
+	_, err = toolHandler.ExecuteTool(context.Background(), "create_job", map[string]interface{}{"paused": "yes please"})
+	require.ErrorContains(t, err, "expected boolean")
+}
@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mcp-server-template/internal/config"
+	"mcp-server-template/internal/server"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// TestToolsListGolden compares the tools/list result for a config exercising every
+// schema branch against a committed snapshot. Run with -update after an intended
+// change to the schema and review the diff.
+func TestToolsListGolden(t *testing.T) {
+	cfg, err := config.Load(filepath.Join("testdata", "tools_list_config.json"))
+	require.NoError(t, err)
+
+	ts, err := server.NewTestServer(cfg)
+	require.NoError(t, err)
+	defer ts.Close()
+
+	var result json.RawMessage
+	require.NoError(t, ts.Call(context.Background(), "tools/list", nil, &result))
+
+	var got bytes.Buffer
+	require.NoError(t, json.Indent(&got, result, "", "  "))
+	got.WriteByte('\n')
+
+	golden := filepath.Join("testdata", "tools_list.golden.json")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, got.Bytes(), 0o644))
+	}
+
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err, "run go test ./tests -run TestToolsListGolden -update to create it")
+	require.Equal(t, string(want), got.String())
+}